@@ -0,0 +1,33 @@
+package audit
+
+// Entry is a recorded action.
+type Entry struct {
+	ID       uint64 `json:"id" storm:"id,increment"`
+	Time     int64  `json:"time" storm:"index"`
+	UserID   uint   `json:"userID"`
+	Username string `json:"username" storm:"index"`
+	IP       string `json:"ip"`
+	Action   string `json:"action" storm:"index"`
+	// Path and Destination are relative to the user's scope.
+	Path        string `json:"path,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	// Target identifies what was changed by the actions that aren't
+	// only about files, such as the ID of an updated user or the
+	// hash of a share.
+	Target  string `json:"target,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Filter restricts the entries returned by a search. Zero values match
+// every entry.
+type Filter struct {
+	Username string
+	Action   string
+	// Path matches the entries whose path or destination
+	// is, or is inside of, this path.
+	Path  string
+	Since int64
+	Until int64
+	Limit int
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long entries are kept in the storage.
+	DefaultRetention = time.Hour * 24 * 90
+
+	// purgeInterval is how often the expired entries are removed.
+	purgeInterval = time.Hour * 24
+)
+
+// StorageBackend is the interface to implement for an audit storage.
+type StorageBackend interface {
+	Save(e *Entry) error
+	// Find returns the entries matching the filter, most recent first.
+	Find(f *Filter) ([]*Entry, error)
+	DeleteBefore(t int64) error
+}
+
+// Storage is an audit storage. Entries are saved to the backend and, if
+// an output is set, also written to it as JSON lines.
+type Storage struct {
+	back StorageBackend
+	out  io.Writer
+	mux  sync.Mutex
+}
+
+// NewStorage creates an audit storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// SetOutput sets where the entries are written as JSON lines.
+func (s *Storage) SetOutput(w io.Writer) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.out = w
+}
+
+// Save saves an entry, setting its time if it's not set yet.
+func (s *Storage) Save(e *Entry) error {
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+
+	if err := s.back.Save(e); err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.out == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.out.Write(append(line, '\n'))
+	return err
+}
+
+// Find wraps a StorageBackend.Find.
+func (s *Storage) Find(f *Filter) ([]*Entry, error) {
+	return s.back.Find(f)
+}
+
+// DeleteBefore removes the entries older than t.
+func (s *Storage) DeleteBefore(t time.Time) error {
+	return s.back.DeleteBefore(t.Unix())
+}
+
+// StartPurge removes the entries older than retention in the background,
+// once straight away and then periodically, until ctx is done. It does
+// nothing if retention isn't positive.
+func (s *Storage) StartPurge(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.DeleteBefore(time.Now().Add(-retention)); err != nil {
+				log.Printf("audit: failed to remove old entries: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -14,10 +14,6 @@ import (
 // MethodJSONAuth is used to identify json auth.
 const MethodJSONAuth settings.AuthMethod = "json"
 
-// dummyHash is used to prevent user enumeration timing attacks.
-// It MUST be a valid bcrypt hash.
-const dummyHash = "$2a$10$O4mEMeOL/nit6zqe.WQXauLRbRlzb3IgLHsa26Pf0N/GiU9b.wK1m"
-
 type jsonCred struct {
 	Password  string `json:"password"`
 	Username  string `json:"username"`
@@ -57,7 +53,7 @@ func (a JSONAuth) Auth(r *http.Request, usr users.Store, _ *settings.Settings, s
 
 	u, err := usr.Get(srv.Root, cred.Username)
 
-	hash := dummyHash
+	hash := users.DummyHash
 	if err == nil {
 		hash = u.Password
 	}
@@ -0,0 +1,604 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// MethodOIDCAuth is used to identify OpenID Connect auth.
+const MethodOIDCAuth settings.AuthMethod = "oidc"
+
+const (
+	// OIDCCallbackPath is where the provider redirects the users after
+	// they logged in, relative to the base URL.
+	OIDCCallbackPath = "/api/auth/oidc/callback"
+
+	// OIDCStateCookie holds the state of an authorization in progress.
+	OIDCStateCookie = "oidc_state"
+
+	oidcStateMaxAge       = 10 * time.Minute
+	oidcDiscoveryMaxAge   = time.Hour
+	oidcDefaultUsername   = "sub"
+	oidcHTTPClientTimeout = 10 * time.Second
+	// oidcKeysMinAge is how long the keys of a provider are kept before
+	// a token signed with an unknown key can make them be fetched again.
+	oidcKeysMinAge = time.Minute
+)
+
+// OIDCAuth is an OpenID Connect implementation of an Auther. The users
+// are authenticated by the provider with the authorization code flow and
+// PKCE, and are created on their first login.
+type OIDCAuth struct {
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// RedirectURL is the URL of the callback registered at the provider.
+	// It's derived from the request if empty.
+	RedirectURL string   `json:"redirectUrl"`
+	Scopes      []string `json:"scopes"`
+	// UsernameClaim is the claim holding the username, by default
+	// "sub". The claims which the users can change at the provider, like
+	// "preferred_username", are only safe if the provider forbids it.
+	UsernameClaim string `json:"usernameClaim"`
+	// Rules set the permissions and scope of the users from their
+	// claims. When several rules match, the last one wins.
+	Rules []OIDCRule `json:"rules"`
+}
+
+// OIDCRule maps users with a given claim value to permissions and a scope.
+type OIDCRule struct {
+	// Claim is the name of the claim, with dots to reach nested
+	// claims, such as "realm_access.roles".
+	Claim string `json:"claim"`
+	// Value matches claims equal to it or, for lists, containing it.
+	Value string             `json:"value"`
+	Perm  *users.Permissions `json:"perm,omitempty"`
+	Scope string             `json:"scope,omitempty"`
+}
+
+type oidcProvider struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+	fetched               time.Time
+
+	// keys are the signing keys of the provider, by their ID.
+	keys        map[string]interface{}
+	keysFetched time.Time
+	keysMux     sync.Mutex
+}
+
+// oidcSigningMethods are the algorithms accepted for the ID tokens. The
+// symmetric ones are left out, as the keys are public.
+var oidcSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+var (
+	oidcProviders    = map[string]*oidcProvider{}
+	oidcProvidersMux sync.Mutex
+	oidcClient       = &http.Client{Timeout: oidcHTTPClientTimeout}
+)
+
+// LoginURL returns the URL of the provider the user must be redirected
+// to, and the cookie holding the state of the authorization, which the
+// callback needs.
+func (a *OIDCAuth) LoginURL(r *http.Request, srv *settings.Server) (string, *http.Cookie, error) {
+	provider, err := a.provider(r.Context())
+	if err != nil {
+		return "", nil, err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", nil, err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", nil, err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	scopes := a.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.ClientID},
+		"redirect_uri":          {a.redirectURL(r, srv)},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	authURL, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", nil, err
+	}
+	for k, v := range authURL.Query() {
+		query[k] = v
+	}
+	authURL.RawQuery = query.Encode()
+
+	cookie := &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    strings.Join([]string{state, nonce, verifier}, "."),
+		Path:     srv.BaseURL + OIDCCallbackPath,
+		MaxAge:   int(oidcStateMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	return authURL.String(), cookie, nil
+}
+
+// Auth authenticates the user from the callback request of the provider.
+func (a *OIDCAuth) Auth(r *http.Request, usr users.Store, stg *settings.Settings, srv *settings.Server) (*users.User, error) {
+	cookie, err := r.Cookie(OIDCStateCookie)
+	if err != nil {
+		return nil, os.ErrPermission
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return nil, os.ErrPermission
+	}
+	state, nonce, verifier := parts[0], parts[1], parts[2]
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		return nil, fmt.Errorf("oidc: %s: %w", query.Get("error"), os.ErrPermission)
+	}
+	if query.Get("state") != state || query.Get("code") == "" {
+		return nil, os.ErrPermission
+	}
+
+	provider, err := a.provider(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := a.exchange(r.Context(), provider, query.Get("code"), verifier, a.redirectURL(r, srv))
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["nonce"] != nonce {
+		return nil, fmt.Errorf("oidc: invalid nonce: %w", os.ErrPermission)
+	}
+
+	usernameClaim := a.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = oidcDefaultUsername
+	}
+	username, _ := claimValue(claims, usernameClaim).(string)
+	if username == "" {
+		return nil, fmt.Errorf("oidc: missing %s claim: %w", usernameClaim, os.ErrPermission)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("oidc: missing sub claim: %w", os.ErrPermission)
+	}
+
+	user, err := usr.Get(srv.Root, username)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return provisionUser(usr, stg, srv, username, func(u *users.User) {
+			u.OIDCSubject = subject
+			a.applyRules(u, &stg.Defaults, claims)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The users are only bound to the accounts they were created for, so
+	// that the accounts of the provider can't take over the local users.
+	if user.OIDCSubject != subject {
+		return nil, fmt.Errorf("oidc: %s isn't bound to this account: %w", username, os.ErrPermission)
+	}
+
+	// The permissions and the scope follow the claims, which might have
+	// changed since the last login.
+	perm, scope := user.Perm, user.Scope
+	a.applyRules(user, &stg.Defaults, claims)
+	user.Scope, err = stg.MakeUserDir(user.Username, user.Scope, srv.Root)
+	if err != nil {
+		return nil, err
+	}
+	if user.Perm != perm || user.Scope != scope {
+		if err := usr.Update(user, "Perm", "Scope"); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// LoginPage tells that OIDC auth doesn't require a login page.
+func (a *OIDCAuth) LoginPage() bool {
+	return false
+}
+
+// applyRules resets the permissions and the scope of the user to the
+// defaults, without admin nor execute, and applies the matching rules.
+func (a *OIDCAuth) applyRules(user *users.User, defaults *settings.UserDefaults, claims jwt.MapClaims) {
+	user.Perm = defaults.Perm
+	user.Perm.Admin = false
+	user.Perm.Execute = false
+	user.Scope = defaults.Scope
+
+	for _, rule := range a.Rules {
+		if !rule.Matches(claims) {
+			continue
+		}
+		if rule.Perm != nil {
+			user.Perm = *rule.Perm
+		}
+		if rule.Scope != "" {
+			user.Scope = rule.Scope
+		}
+	}
+}
+
+// Matches reports whether the rule matches the claims.
+func (r OIDCRule) Matches(claims jwt.MapClaims) bool {
+	switch v := claimValue(claims, r.Claim).(type) {
+	case nil:
+		return false
+	case []interface{}:
+		return slices.ContainsFunc(v, func(item interface{}) bool {
+			return fmt.Sprint(item) == r.Value
+		})
+	default:
+		return fmt.Sprint(v) == r.Value
+	}
+}
+
+// claimValue returns the value of a claim, following the dots
+// into nested objects.
+func claimValue(claims map[string]interface{}, name string) interface{} {
+	key, rest, nested := strings.Cut(name, ".")
+	if v, ok := claims[name]; ok || !nested {
+		return v
+	}
+
+	if obj, ok := claims[key].(map[string]interface{}); ok {
+		return claimValue(obj, rest)
+	}
+	return nil
+}
+
+// exchange exchanges the authorization code for the tokens and returns
+// the validated claims of the ID token, completed by the user info.
+func (a *OIDCAuth) exchange(ctx context.Context, provider *oidcProvider, code, verifier, redirectURL string) (jwt.MapClaims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	}
+
+	// Secrets are sent with basic auth unless the provider
+	// only supports sending them in the body.
+	basicAuth := a.ClientSecret != "" &&
+		(len(provider.TokenAuthMethods) == 0 || slices.Contains(provider.TokenAuthMethods, "client_secret_basic"))
+	if !basicAuth {
+		form.Set("client_id", a.ClientID)
+		if a.ClientSecret != "" {
+			form.Set("client_secret", a.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+	}
+
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+	}
+	if err := doJSON(req, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: failed to exchange the code: %w", err)
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(a.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	_, err = parser.ParseWithClaims(tokens.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return provider.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid ID token: %w: %w", err, os.ErrPermission)
+	}
+
+	if provider.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		info, err := userinfo(ctx, provider, tokens.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		if info["sub"] != claims["sub"] {
+			return nil, fmt.Errorf("oidc: user info subject mismatch: %w", os.ErrPermission)
+		}
+		for k, v := range info {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+// key returns the signing key of the provider with the ID, which can be
+// empty if the provider has a single key. The keys are fetched again
+// when they're rotated.
+func (p *oidcProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.keysMux.Lock()
+	defer p.keysMux.Unlock()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetched) < oidcKeysMinAge {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := fetchKeys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *oidcProvider) findKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys fetches the signing keys of a JSON Web Key Set. The keys of
+// unsupported types are skipped.
+func fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	if jwksURI == "" {
+		return nil, errors.New("no jwks_uri in the discovery document")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("failed to get the signing keys: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > math.MaxInt32 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func userinfo(ctx context.Context, provider *oidcProvider, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.UserinfoEndpoint, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	info := map[string]interface{}{}
+	if err := doJSON(req, &info); err != nil {
+		return nil, fmt.Errorf("oidc: failed to get the user info: %w", err)
+	}
+	return info, nil
+}
+
+// provider returns the discovered configuration of the issuer.
+func (a *OIDCAuth) provider(ctx context.Context) (*oidcProvider, error) {
+	issuer := strings.TrimSuffix(a.Issuer, "/")
+
+	oidcProvidersMux.Lock()
+	provider, ok := oidcProviders[issuer]
+	oidcProvidersMux.Unlock()
+	if ok && time.Since(provider.fetched) < oidcDiscoveryMaxAge {
+		return provider, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	provider = &oidcProvider{}
+	if err := doJSON(req, provider); err != nil {
+		return nil, fmt.Errorf("oidc: failed to discover %s: %w", issuer, err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovered issuer %q doesn't match %q", provider.Issuer, a.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" {
+		return nil, fmt.Errorf("oidc: incomplete discovery document for %s", issuer)
+	}
+	provider.fetched = time.Now()
+
+	oidcProvidersMux.Lock()
+	oidcProviders[issuer] = provider
+	oidcProvidersMux.Unlock()
+
+	return provider, nil
+}
+
+// redirectURL returns the URL of the callback.
+func (a *OIDCAuth) redirectURL(r *http.Request, srv *settings.Server) string {
+	if a.RedirectURL != "" {
+		return a.RedirectURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host + srv.BaseURL + OIDCCallbackPath
+}
+
+func doJSON(req *http.Request, v interface{}) error {
+	res, err := oidcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func randomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// signToken signs the ID token of the mock provider with its key.
+func signToken(claims jwt.MapClaims, key *ecdsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "key"
+	signed, _ := token.SignedString(key)
+	return signed
+}
+
+// newMockOIDCProvider starts a provider that issues an ID token with
+// the given claims, once the authorization code and PKCE verifier
+// are checked. The token is signed with sign, or signToken if nil.
+func newMockOIDCProvider(t *testing.T, claims jwt.MapClaims, sign func(jwt.MapClaims, *ecdsa.PrivateKey) string) *httptest.Server {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+	if sign == nil {
+		sign = signToken
+	}
+
+	var challenge, nonce string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		point, _ := key.PublicKey.Bytes()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "EC",
+				"kid": "key",
+				"use": "sig",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+				"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		challenge = r.URL.Query().Get("code_challenge")
+		nonce = r.URL.Query().Get("nonce")
+		redirect := r.URL.Query().Get("redirect_uri") + "?code=code&state=" + r.URL.Query().Get("state")
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "client" || secret != "secret" || r.FormValue("code") != "code" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tokenClaims := jwt.MapClaims{
+			"iss":   server.URL,
+			"aud":   "client",
+			"sub":   "1234",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": nonce,
+		}
+		for k, v := range claims {
+			tokenClaims[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": sign(tokenClaims, key)})
+	})
+
+	return server
+}
+
+// oidcLogin goes through the authorization with the provider and returns
+// the callback request.
+func oidcLogin(t *testing.T, a *OIDCAuth, srv *settings.Server) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "http://files.example.com/api/auth/oidc/login", http.NoBody)
+	loginURL, cookie, err := a.LoginURL(req, srv)
+	if err != nil {
+		t.Fatalf("failed to get the login URL: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(loginURL)
+	if err != nil {
+		t.Fatalf("failed to authorize: %v", err)
+	}
+	res.Body.Close()
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid callback: %v", err)
+	}
+	if callback.Path != OIDCCallbackPath {
+		t.Fatalf("expected the callback to be %s, got %s", OIDCCallbackPath, callback.Path)
+	}
+
+	callbackReq := httptest.NewRequest(http.MethodGet, callback.String(), http.NoBody)
+	callbackReq.AddCookie(cookie)
+	return callbackReq
+}
+
+func TestOIDCAuth(t *testing.T) {
+	t.Parallel()
+
+	adminPerm := &users.Permissions{Admin: true, Download: true}
+	testCases := map[string]struct {
+		claims        jwt.MapClaims
+		sign          func(jwt.MapClaims, *ecdsa.PrivateKey) string
+		usernameClaim string
+		existing      *users.User
+		expectedUser  string
+		expectedAdmin bool
+		expectedErr   error
+	}{
+		"creates the user from the subject": {
+			claims:       jwt.MapClaims{"preferred_username": "alice"},
+			expectedUser: "1234",
+		},
+		"maps claims to permissions": {
+			claims:        jwt.MapClaims{"preferred_username": "alice", "groups": []string{"staff", "admins"}},
+			usernameClaim: "preferred_username",
+			expectedUser:  "alice",
+			expectedAdmin: true,
+		},
+		"maps nested claims": {
+			claims:        jwt.MapClaims{"preferred_username": "alice", "realm_access": map[string]interface{}{"roles": []string{"admins"}}},
+			usernameClaim: "preferred_username",
+			expectedUser:  "alice",
+			expectedAdmin: true,
+		},
+		"updates the permissions of existing users": {
+			claims:        jwt.MapClaims{"preferred_username": "bob", "groups": []string{"admins"}},
+			usernameClaim: "preferred_username",
+			existing:      &users.User{Username: "bob", Scope: "/bob", OIDCSubject: "1234"},
+			expectedUser:  "bob",
+			expectedAdmin: true,
+		},
+		"resets the permissions of existing users": {
+			claims:        jwt.MapClaims{"preferred_username": "bob", "groups": []string{"staff"}},
+			usernameClaim: "preferred_username",
+			existing:      &users.User{Username: "bob", Scope: "/bob", OIDCSubject: "1234", Perm: *adminPerm},
+			expectedUser:  "bob",
+		},
+		"refuses local users": {
+			claims:        jwt.MapClaims{"preferred_username": "bob"},
+			usernameClaim: "preferred_username",
+			existing:      &users.User{Username: "bob", Scope: "/bob"},
+			expectedErr:   os.ErrPermission,
+		},
+		"refuses the users of other accounts": {
+			claims:        jwt.MapClaims{"preferred_username": "bob"},
+			usernameClaim: "preferred_username",
+			existing:      &users.User{Username: "bob", Scope: "/bob", OIDCSubject: "5678"},
+			expectedErr:   os.ErrPermission,
+		},
+		"custom username claim": {
+			claims:        jwt.MapClaims{"email": "carol@example.com"},
+			usernameClaim: "email",
+			expectedUser:  "carol@example.com",
+		},
+		"missing username": {
+			claims:        jwt.MapClaims{},
+			usernameClaim: "preferred_username",
+			expectedErr:   os.ErrPermission,
+		},
+		"unknown signing key": {
+			claims: jwt.MapClaims{"preferred_username": "alice"},
+			sign: func(claims jwt.MapClaims, _ *ecdsa.PrivateKey) string {
+				key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				return signToken(claims, key)
+			},
+			expectedErr: os.ErrPermission,
+		},
+		"symmetric signature": {
+			claims: jwt.MapClaims{"preferred_username": "alice"},
+			sign: func(claims jwt.MapClaims, _ *ecdsa.PrivateKey) string {
+				token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("key"))
+				return token
+			},
+			expectedErr: os.ErrPermission,
+		},
+		"unsigned token": {
+			claims: jwt.MapClaims{"preferred_username": "alice"},
+			sign: func(claims jwt.MapClaims, _ *ecdsa.PrivateKey) string {
+				token, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return token
+			},
+			expectedErr: os.ErrPermission,
+		},
+		"wrong audience": {
+			claims:      jwt.MapClaims{"preferred_username": "alice", "aud": "other"},
+			expectedErr: os.ErrPermission,
+		},
+		"expired token": {
+			claims:      jwt.MapClaims{"preferred_username": "alice", "exp": time.Now().Add(-time.Hour).Unix()},
+			expectedErr: os.ErrPermission,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			provider := newMockOIDCProvider(t, tc.claims, tc.sign)
+			a := &OIDCAuth{
+				Issuer:        provider.URL,
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				UsernameClaim: tc.usernameClaim,
+				Rules: []OIDCRule{
+					{Claim: "groups", Value: "admins", Perm: adminPerm},
+					{Claim: "realm_access.roles", Value: "admins", Perm: adminPerm},
+				},
+			}
+
+			store := &mockUserStore{users: map[string]*users.User{}}
+			if tc.existing != nil {
+				store.users[tc.existing.Username] = tc.existing
+			}
+			srv := &settings.Server{Root: t.TempDir()}
+			stg := &settings.Settings{Defaults: settings.UserDefaults{Perm: users.Permissions{Download: true}}}
+
+			user, err := a.Auth(oidcLogin(t, a, srv), store, stg, srv)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to authenticate: %v", err)
+			}
+
+			if user.Username != tc.expectedUser {
+				t.Errorf("expected user %q, got %q", tc.expectedUser, user.Username)
+			}
+			if user.Perm.Admin != tc.expectedAdmin {
+				t.Errorf("expected admin to be %v, got %v", tc.expectedAdmin, user.Perm.Admin)
+			}
+			saved, ok := store.users[tc.expectedUser]
+			if !ok {
+				t.Fatalf("expected user %q to be saved", tc.expectedUser)
+			}
+			if saved.OIDCSubject != "1234" {
+				t.Errorf("expected user %q to be bound to 1234, got %q", tc.expectedUser, saved.OIDCSubject)
+			}
+		})
+	}
+}
+
+func TestOIDCAuthRejectsInvalidState(t *testing.T) {
+	t.Parallel()
+
+	provider := newMockOIDCProvider(t, jwt.MapClaims{"preferred_username": "alice"}, nil)
+	a := &OIDCAuth{Issuer: provider.URL, ClientID: "client", ClientSecret: "secret"}
+	srv := &settings.Server{Root: t.TempDir()}
+
+	req := oidcLogin(t, a, srv)
+	query := req.URL.Query()
+	query.Set("state", "forged")
+	req.URL.RawQuery = query.Encode()
+
+	store := &mockUserStore{users: map[string]*users.User{}}
+	if _, err := a.Auth(req, store, &settings.Settings{}, srv); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("expected a forged state to be rejected, got %v", err)
+	}
+}
@@ -28,6 +28,13 @@ func (a ProxyAuth) Auth(r *http.Request, usr users.Store, setting *settings.Sett
 }
 
 func (a ProxyAuth) createUser(usr users.Store, setting *settings.Settings, srv *settings.Server, username string) (*users.User, error) {
+	return provisionUser(usr, setting, srv, username, nil)
+}
+
+// provisionUser creates a user authenticated by an external source. It gets
+// a random password and the default settings, without the admin and execute
+// permissions, unless customize grants them.
+func provisionUser(usr users.Store, setting *settings.Settings, srv *settings.Server, username string, customize func(*users.User)) (*users.User, error) {
 	const randomPasswordLength = settings.DefaultMinimumPasswordLength + 10
 	pwd, err := users.RandomPwd(randomPasswordLength)
 	if err != nil {
@@ -50,6 +57,10 @@ func (a ProxyAuth) createUser(usr users.Store, setting *settings.Settings, srv *
 	user.Perm.Execute = false
 	user.Commands = []string{}
 
+	if customize != nil {
+		customize(user)
+	}
+
 	var userHome string
 	userHome, err = setting.MakeUserDir(user.Username, user.Scope, srv.Root)
 	if err != nil {
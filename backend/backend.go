@@ -0,0 +1,114 @@
+// Package backend describes where the files of a user are stored and
+// creates the filesystems for them.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/backend/s3"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Type is the type of a storage backend.
+type Type string
+
+const (
+	// TypeLocal stores the files in the local filesystem, under the root
+	// of the server. It's the default.
+	TypeLocal Type = "local"
+	// TypeS3 stores the files in an S3-compatible object storage.
+	TypeS3 Type = "s3"
+)
+
+// s3HTTPClient is shared by the S3 filesystems so that the connections
+// are reused between the requests of the users.
+var s3HTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// Config is the storage backend of a user.
+type Config struct {
+	Type Type     `json:"type"`
+	S3   S3Config `json:"s3"`
+}
+
+// S3Config is the configuration of an S3-compatible object storage. The
+// scope of the users is relative to the prefix of the bucket.
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	PathStyle bool   `json:"pathStyle"`
+}
+
+// IsLocal reports whether the files are stored in the local filesystem.
+func (c *Config) IsLocal() bool {
+	return c.Type == "" || c.Type == TypeLocal
+}
+
+// Validate checks that the backend is fully configured.
+func (c *Config) Validate() error {
+	switch c.Type {
+	case "", TypeLocal:
+		return nil
+	case TypeS3:
+		if c.S3.Bucket == "" || c.S3.Endpoint == "" {
+			return fmt.Errorf("s3 backend requires an endpoint and a bucket: %w", fberrors.ErrInvalidOption)
+		}
+		if _, err := url.Parse(c.S3.Endpoint); err != nil {
+			return fmt.Errorf("invalid s3 endpoint: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage backend %q: %w", c.Type, fberrors.ErrInvalidOption)
+	}
+}
+
+// NewFs creates the filesystem of a scope. For the local backend, the
+// scope is relative to root, the root of the server.
+func (c *Config) NewFs(root, scope string) (afero.Fs, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.IsLocal() {
+		scope = filepath.Join(root, filepath.Join("/", scope))
+		return afero.NewBasePathFs(afero.NewOsFs(), scope), nil
+	}
+
+	endpoint, err := url.Parse(c.S3.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	region := c.S3.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	client := &s3.Client{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    c.S3.Bucket,
+		AccessKey: c.S3.AccessKey,
+		SecretKey: c.S3.SecretKey,
+		PathStyle: c.S3.PathStyle,
+		HTTP:      s3HTTPClient,
+	}
+
+	return s3.NewFs(client, path.Join(c.S3.Prefix, filepath.ToSlash(scope))), nil
+}
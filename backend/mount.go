@@ -0,0 +1,411 @@
+package backend
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/mem"
+)
+
+// MountFs composes the filesystems of several mount points into a single
+// tree. The paths which aren't under any mount point are routed to the
+// root filesystem, and the directories leading to a mount point exist
+// even if the root filesystem doesn't have them.
+type MountFs struct {
+	root   afero.Fs
+	mounts []*mountPoint
+}
+
+type mountPoint struct {
+	path     string
+	fs       afero.Fs
+	readOnly bool
+}
+
+// NewMountFs creates a MountFs with the given root filesystem.
+func NewMountFs(root afero.Fs) *MountFs {
+	return &MountFs{root: root}
+}
+
+// Mount attaches a filesystem at the given path. The mount points are
+// matched from the most to the least specific one.
+func (m *MountFs) Mount(name string, fs afero.Fs, readOnly bool) {
+	m.mounts = append(m.mounts, &mountPoint{path: cleanPath(name), fs: fs, readOnly: readOnly})
+	slices.SortStableFunc(m.mounts, func(a, b *mountPoint) int {
+		return len(b.path) - len(a.path)
+	})
+}
+
+// Root returns the root filesystem, without the mount points.
+func (m *MountFs) Root() afero.Fs {
+	return m.root
+}
+
+func cleanPath(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+// resolve returns the mount point of a path, or nil for the root
+// filesystem, and the path relative to it.
+func (m *MountFs) resolve(name string) (*mountPoint, string) {
+	name = cleanPath(name)
+	for _, mp := range m.mounts {
+		if name == mp.path {
+			return mp, "/"
+		}
+		if strings.HasPrefix(name, mp.path+"/") {
+			return mp, name[len(mp.path):]
+		}
+	}
+	return nil, name
+}
+
+func (mp *mountPoint) afs(root afero.Fs) afero.Fs {
+	if mp == nil {
+		return root
+	}
+	return mp.fs
+}
+
+// children returns the names of the entries of a directory which are
+// mount points or lead to one.
+func (m *MountFs) children(dir string) []string {
+	prefix := cleanPath(dir)
+	if prefix != "/" {
+		prefix += "/"
+	}
+
+	var names []string
+	for _, mp := range m.mounts {
+		if rest, ok := strings.CutPrefix(mp.path, prefix); ok && rest != "" {
+			name, _, _ := strings.Cut(rest, "/")
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// isMountPoint reports whether a path is a mount point or leads to one.
+func (m *MountFs) isMountPoint(name string) bool {
+	name = cleanPath(name)
+	for _, mp := range m.mounts {
+		if name == mp.path || name == "/" || strings.HasPrefix(mp.path, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writable resolves a path which is about to be modified.
+func (m *MountFs) writable(op, name string) (afero.Fs, string, error) {
+	mp, rel := m.resolve(name)
+	if mp != nil && mp.readOnly {
+		return nil, "", &os.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return mp.afs(m.root), rel, nil
+}
+
+// Name implements afero.Fs.
+func (m *MountFs) Name() string {
+	return "MountFs"
+}
+
+// Create implements afero.Fs.
+func (m *MountFs) Create(name string) (afero.File, error) {
+	return m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Mkdir implements afero.Fs.
+func (m *MountFs) Mkdir(name string, perm os.FileMode) error {
+	afs, rel, err := m.writable("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return afs.Mkdir(rel, perm)
+}
+
+// MkdirAll implements afero.Fs.
+func (m *MountFs) MkdirAll(name string, perm os.FileMode) error {
+	if m.isMountPoint(name) {
+		return nil
+	}
+	afs, rel, err := m.writable("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return afs.MkdirAll(rel, perm)
+}
+
+// Open implements afero.Fs.
+func (m *MountFs) Open(name string) (afero.File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements afero.Fs.
+func (m *MountFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	mp, rel := m.resolve(name)
+	write := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+	if write && mp != nil && mp.readOnly {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	children := m.children(name)
+	f, err := mp.afs(m.root).OpenFile(rel, flag, perm)
+	if err != nil {
+		if !os.IsNotExist(err) || write || len(children) == 0 {
+			return nil, err
+		}
+		// A directory which only exists because it leads to a mount point.
+		f = mem.NewFileHandle(mem.CreateDir(name))
+	}
+
+	if mp == nil && len(children) == 0 {
+		return f, nil
+	}
+	return &mountFile{File: f, fs: m, name: cleanPath(name), children: children}, nil
+}
+
+// Remove implements afero.Fs.
+func (m *MountFs) Remove(name string) error {
+	if m.isMountPoint(name) {
+		return &os.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+	}
+	afs, rel, err := m.writable("remove", name)
+	if err != nil {
+		return err
+	}
+	return afs.Remove(rel)
+}
+
+// RemoveAll implements afero.Fs.
+func (m *MountFs) RemoveAll(name string) error {
+	if m.isMountPoint(name) {
+		return &os.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+	}
+	afs, rel, err := m.writable("remove", name)
+	if err != nil {
+		return err
+	}
+	return afs.RemoveAll(rel)
+}
+
+// Rename implements afero.Fs. The files can't be renamed from one mount
+// point to another, which must be done by copying them instead.
+func (m *MountFs) Rename(oldname, newname string) error {
+	if m.isMountPoint(oldname) || m.isMountPoint(newname) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrPermission}
+	}
+
+	oldMp, oldRel := m.resolve(oldname)
+	newMp, newRel := m.resolve(newname)
+	switch {
+	case oldMp != newMp:
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	case oldMp != nil && oldMp.readOnly:
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrPermission}
+	}
+	return oldMp.afs(m.root).Rename(oldRel, newRel)
+}
+
+// Stat implements afero.Fs.
+func (m *MountFs) Stat(name string) (os.FileInfo, error) {
+	mp, rel := m.resolve(name)
+	info, err := mp.afs(m.root).Stat(rel)
+	return m.fixInfo(name, mp, rel, info, err)
+}
+
+// LstatIfPossible implements afero.Lstater.
+func (m *MountFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	mp, rel := m.resolve(name)
+	afs := mp.afs(m.root)
+
+	lstater, ok := afs.(afero.Lstater)
+	if !ok {
+		info, err := m.Stat(name)
+		return info, false, err
+	}
+
+	info, lstat, err := lstater.LstatIfPossible(rel)
+	info, err = m.fixInfo(name, mp, rel, info, err)
+	return info, lstat, err
+}
+
+// fixInfo names the root of the mount points after them, and makes up
+// the directories leading to a mount point.
+func (m *MountFs) fixInfo(name string, mp *mountPoint, rel string, info os.FileInfo, err error) (os.FileInfo, error) {
+	switch {
+	case err == nil && mp != nil && rel == "/":
+		return &namedInfo{FileInfo: info, name: path.Base(mp.path)}, nil
+	case os.IsNotExist(err) && len(m.children(name)) > 0:
+		return mem.GetFileInfo(mem.CreateDir(path.Base(cleanPath(name)))), nil
+	}
+	return info, err
+}
+
+// ReadlinkIfPossible implements afero.LinkReader.
+func (m *MountFs) ReadlinkIfPossible(name string) (string, error) {
+	mp, rel := m.resolve(name)
+	if reader, ok := mp.afs(m.root).(afero.LinkReader); ok {
+		return reader.ReadlinkIfPossible(rel)
+	}
+	return "", &os.PathError{Op: "readlink", Path: name, Err: afero.ErrNoReadlink}
+}
+
+// SymlinkIfPossible implements afero.Linker. The link is created in the
+// filesystem of newname, and oldname must be in it too.
+func (m *MountFs) SymlinkIfPossible(oldname, newname string) error {
+	oldMp, oldRel := m.resolve(oldname)
+	newMp, newRel := m.resolve(newname)
+	switch {
+	case oldMp != newMp:
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: syscall.EXDEV}
+	case newMp != nil && newMp.readOnly:
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrPermission}
+	}
+
+	if linker, ok := newMp.afs(m.root).(afero.Linker); ok {
+		return linker.SymlinkIfPossible(oldRel, newRel)
+	}
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: afero.ErrNoSymlink}
+}
+
+// Chmod implements afero.Fs.
+func (m *MountFs) Chmod(name string, mode os.FileMode) error {
+	afs, rel, err := m.writable("chmod", name)
+	if err != nil {
+		return err
+	}
+	return afs.Chmod(rel, mode)
+}
+
+// Chown implements afero.Fs.
+func (m *MountFs) Chown(name string, uid, gid int) error {
+	afs, rel, err := m.writable("chown", name)
+	if err != nil {
+		return err
+	}
+	return afs.Chown(rel, uid, gid)
+}
+
+// Chtimes implements afero.Fs.
+func (m *MountFs) Chtimes(name string, atime, mtime time.Time) error {
+	afs, rel, err := m.writable("chtimes", name)
+	if err != nil {
+		return err
+	}
+	return afs.Chtimes(rel, atime, mtime)
+}
+
+// RealPath returns the path of a file in the filesystem where it's
+// stored. See RealPath.
+func (m *MountFs) RealPath(name string) (string, error) {
+	mp, rel := m.resolve(name)
+	return RealPath(mp.afs(m.root), rel), nil
+}
+
+// RealPath returns the path of a file in the filesystem where it's
+// stored: the path in the local filesystem, or the URL of the object.
+// It falls back to name for the other filesystems.
+func RealPath(afs afero.Fs, name string) string {
+	switch afs := afs.(type) {
+	case *afero.BasePathFs:
+		return afero.FullBaseFsPath(afs, name)
+	case interface{ RealPath(string) (string, error) }:
+		if p, err := afs.RealPath(name); err == nil {
+			return p
+		}
+	}
+	return name
+}
+
+// mountFile is a file opened in a mount point, or a directory with mount
+// points in it.
+type mountFile struct {
+	afero.File
+	fs       *MountFs
+	name     string
+	children []string
+
+	entries []os.FileInfo
+	listed  bool
+}
+
+// Name implements afero.File.
+func (f *mountFile) Name() string {
+	return f.name
+}
+
+// Stat implements afero.File.
+func (f *mountFile) Stat() (os.FileInfo, error) {
+	return f.fs.Stat(f.name)
+}
+
+// Readdir implements afero.File, listing the mount points along with
+// the entries of the directory.
+func (f *mountFile) Readdir(count int) ([]os.FileInfo, error) {
+	if len(f.children) == 0 {
+		return f.File.Readdir(count)
+	}
+
+	if !f.listed {
+		entries, err := f.File.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+		entries = slices.DeleteFunc(entries, func(info os.FileInfo) bool {
+			return slices.Contains(f.children, info.Name())
+		})
+		for _, name := range f.children {
+			if info, err := f.fs.Stat(path.Join(f.name, name)); err == nil {
+				entries = append(entries, info)
+			}
+		}
+		f.entries = entries
+		f.listed = true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n := min(count, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
+
+// Readdirnames implements afero.File.
+func (f *mountFile) Readdirnames(n int) ([]string, error) {
+	entries, err := f.Readdir(n)
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names, err
+}
+
+type namedInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (i *namedInfo) Name() string {
+	return i.name
+}
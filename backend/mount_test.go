@@ -0,0 +1,140 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func newTestMountFs(t *testing.T) *MountFs {
+	t.Helper()
+
+	root := afero.NewMemMapFs()
+	www := afero.NewMemMapFs()
+	team := afero.NewMemMapFs()
+	for fs, name := range map[afero.Fs]string{root: "/home.txt", www: "/index.html", team: "/notes.txt"} {
+		if err := afero.WriteFile(fs, name, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mfs := NewMountFs(root)
+	mfs.Mount("/www", www, false)
+	mfs.Mount("/shared/team", team, true)
+	return mfs
+}
+
+func TestMountFsReadDir(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]string{
+		"/":            {"home.txt", "shared", "www"},
+		"/shared":      {"team"},
+		"/shared/team": {"notes.txt"},
+		"/www":         {"index.html"},
+	}
+
+	for dir, want := range tests {
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := newTestMountFs(t)
+			entries, err := afero.ReadDir(mfs, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			if !slices.Equal(names, want) {
+				t.Fatalf("got entries %v, want %v", names, want)
+			}
+
+			info, err := mfs.Stat(dir)
+			if err != nil || !info.IsDir() {
+				t.Fatalf("unexpected stat: %v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestMountFsOperations(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		op      func(fs *MountFs) error
+		wantErr error
+	}{
+		"write in a mount": {
+			op:      func(fs *MountFs) error { return afero.WriteFile(fs, "/www/new.html", nil, 0644) },
+			wantErr: nil,
+		},
+		"write in a read-only mount": {
+			op:      func(fs *MountFs) error { return afero.WriteFile(fs, "/shared/team/new.txt", nil, 0644) },
+			wantErr: os.ErrPermission,
+		},
+		"mkdir in a read-only mount": {
+			op:      func(fs *MountFs) error { return fs.MkdirAll("/shared/team/dir", 0755) },
+			wantErr: os.ErrPermission,
+		},
+		"remove a mount point": {
+			op:      func(fs *MountFs) error { return fs.RemoveAll("/www") },
+			wantErr: os.ErrPermission,
+		},
+		"remove a directory leading to a mount point": {
+			op:      func(fs *MountFs) error { return fs.RemoveAll("/shared") },
+			wantErr: os.ErrPermission,
+		},
+		"rename in a mount": {
+			op:      func(fs *MountFs) error { return fs.Rename("/www/index.html", "/www/home.html") },
+			wantErr: nil,
+		},
+		"rename across mounts": {
+			op:      func(fs *MountFs) error { return fs.Rename("/home.txt", "/www/home.txt") },
+			wantErr: syscall.EXDEV,
+		},
+		"remove from a read-only mount": {
+			op:      func(fs *MountFs) error { return fs.Remove("/shared/team/notes.txt") },
+			wantErr: os.ErrPermission,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.op(newTestMountFs(t))
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMountFsRealPath(t *testing.T) {
+	t.Parallel()
+
+	mfs := NewMountFs(afero.NewBasePathFs(afero.NewMemMapFs(), "/srv/home"))
+	mfs.Mount("/www", afero.NewBasePathFs(afero.NewMemMapFs(), "/var/www"), false)
+
+	tests := map[string]string{
+		"/file.txt":       "/srv/home/file.txt",
+		"/www":            "/var/www",
+		"/www/index.html": "/var/www/index.html",
+		"/wwwx/file.txt":  "/srv/home/wwwx/file.txt",
+	}
+	for name, want := range tests {
+		if got, _ := mfs.RealPath(name); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
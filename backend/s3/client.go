@@ -0,0 +1,500 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec // Content-MD5 is required by DeleteObjects.
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// Client is a minimal client for the S3 API, implementing only the
+// calls needed by Fs and signing them with AWS Signature Version 4.
+type Client struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path of the URL instead
+	// of the host, as required by most self-hosted servers.
+	PathStyle bool
+	HTTP      *http.Client
+}
+
+// Error is an error returned by the S3 API.
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("s3: %d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// Is maps the errors of the API to the errors of the fs package.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return e.StatusCode == http.StatusNotFound || e.Code == "NoSuchKey"
+	case fs.ErrPermission:
+		return e.StatusCode == http.StatusForbidden
+	default:
+		return false
+	}
+}
+
+// Object describes an object of the bucket.
+type Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// ListResult is a page of the listing of the objects.
+type ListResult struct {
+	Objects               []Object `xml:"Contents"`
+	CommonPrefixes        []string `xml:"CommonPrefixes>Prefix"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// Part is a part of a multipart upload.
+type Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (c *Client) url(key string, query url.Values) *url.URL {
+	u := *c.Endpoint
+	p := "/" + key
+	if c.PathStyle {
+		p = "/" + c.Bucket + p
+	} else {
+		u.Host = c.Bucket + "." + u.Host
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + p
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = encodeQuery(query)
+	return &u
+}
+
+// do signs and sends a request. Error responses are turned into an *Error.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values,
+	header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := c.url(key, query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	c.sign(req, time.Now().UTC())
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotModified {
+		defer res.Body.Close()
+		return nil, readError(res)
+	}
+
+	return res, nil
+}
+
+func readError(res *http.Response) error {
+	apiErr := &Error{StatusCode: res.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if len(data) > 0 {
+		_ = xml.Unmarshal(data, apiErr)
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = http.StatusText(res.StatusCode)
+	}
+	return apiErr
+}
+
+// decode decodes the XML body of a response. Some calls answer with
+// a 200 status and an error in the body, which is returned as such.
+func decode(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Contains(data[:min(len(data), 256)], []byte("<Error>")) {
+		apiErr := &Error{StatusCode: http.StatusInternalServerError}
+		if err := xml.Unmarshal(data, apiErr); err != nil {
+			return err
+		}
+		return apiErr
+	}
+
+	if v == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, v)
+}
+
+func (c *Client) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") || k == "content-md5" || k == "content-type" {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encodePath encodes a path as required by the signature, where
+// everything but the unreserved characters and the slashes is escaped.
+func encodePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// HeadObject gets the size and the modification time of an object.
+func (c *Client) HeadObject(ctx context.Context, key string) (*Object, error) {
+	res, err := c.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	obj := &Object{Key: key, Size: res.ContentLength}
+	obj.LastModified, _ = http.ParseTime(res.Header.Get("Last-Modified"))
+	return obj, nil
+}
+
+// GetObject gets the content of an object from offset. A negative
+// length reads until the end of the object.
+func (c *Client) GetObject(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	switch {
+	case length >= 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := c.do(ctx, http.MethodGet, key, nil, header, nil, 0)
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+// PutObject uploads an object in a single request.
+func (c *Client) PutObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	if body == nil {
+		body = http.NoBody
+	}
+
+	res, err := c.do(ctx, http.MethodPut, key, nil, nil, body, size)
+	if err != nil {
+		return err
+	}
+	return decode(res, nil)
+}
+
+// CopyObject copies an object of up to 5 GiB inside the bucket.
+func (c *Client) CopyObject(ctx context.Context, src, dst string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", c.copySource(src))
+
+	res, err := c.do(ctx, http.MethodPut, dst, nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	return decode(res, nil)
+}
+
+func (c *Client) copySource(key string) string {
+	return encodePath("/" + c.Bucket + "/" + key)
+}
+
+// DeleteObject deletes an object. Deleting a missing object succeeds.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	res, err := c.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// DeleteObjects deletes up to 1000 objects in a single request.
+func (c *Client) DeleteObjects(ctx context.Context, keys []string) error {
+	type object struct {
+		Key string `xml:"Key"`
+	}
+	type deleteRequest struct {
+		XMLName xml.Name `xml:"Delete"`
+		Quiet   bool     `xml:"Quiet"`
+		Objects []object `xml:"Object"`
+	}
+
+	req := deleteRequest{Quiet: true}
+	for _, k := range keys {
+		req.Objects = append(req.Objects, object{Key: k})
+	}
+
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	sum := md5.Sum(body) //nolint:gosec
+	header := http.Header{}
+	header.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+	header.Set("Content-Type", "application/xml")
+
+	res, err := c.do(ctx, http.MethodPost, "", url.Values{"delete": {""}}, header, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Errors []Error `xml:"Error"`
+	}
+	if err := decode(res, &result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		result.Errors[0].StatusCode = http.StatusInternalServerError
+		return &result.Errors[0]
+	}
+	return nil
+}
+
+// ListObjects lists a page of the objects whose key starts with prefix.
+// With a delimiter, the keys containing it after the prefix are grouped
+// into common prefixes.
+func (c *Client) ListObjects(ctx context.Context, prefix, delimiter, token string, maxKeys int) (*ListResult, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	if maxKeys > 0 {
+		query.Set("max-keys", strconv.Itoa(maxKeys))
+	}
+
+	res, err := c.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{}
+	if err := decode(res, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateMultipartUpload starts a multipart upload and returns its ID.
+func (c *Client) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	res, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := decode(res, &result); err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart uploads a part of a multipart upload.
+func (c *Client) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+
+	res, err := c.do(ctx, http.MethodPut, key, query, nil, body, size)
+	if err != nil {
+		return Part{}, err
+	}
+	res.Body.Close()
+
+	return Part{PartNumber: number, ETag: res.Header.Get("ETag")}, nil
+}
+
+// UploadPartCopy uses a range of an existing object as a part of a
+// multipart upload.
+func (c *Client) UploadPartCopy(ctx context.Context, key, uploadID string, number int, src string, offset, length int64) (Part, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", c.copySource(src))
+	header.Set("X-Amz-Copy-Source-Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	res, err := c.do(ctx, http.MethodPut, key, query, header, nil, 0)
+	if err != nil {
+		return Part{}, err
+	}
+
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	if err := decode(res, &result); err != nil {
+		return Part{}, err
+	}
+	return Part{PartNumber: number, ETag: result.ETag}, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the object.
+func (c *Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	type completeRequest struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []Part   `xml:"Part"`
+	}
+
+	body, err := xml.Marshal(completeRequest{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	return decode(res, nil)
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+func (c *Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	res, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
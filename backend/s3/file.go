@@ -0,0 +1,484 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+var errNotSequential = errors.New("s3: files can only be written sequentially")
+
+// File is a file or a directory of an Fs.
+type File struct {
+	fs   *Fs
+	name string
+	key  string
+	info *fileInfo
+
+	// offset is the position of the reads, and body streams the object
+	// from bodyOffset for the sequential reads.
+	offset     int64
+	body       io.ReadCloser
+	bodyOffset int64
+
+	entries []os.FileInfo
+	listed  bool
+
+	w      *writer
+	closed bool
+}
+
+// writer buffers the data written to a file in a temporary file until
+// it's uploaded.
+type writer struct {
+	// created and truncate tell whether the object must be uploaded
+	// even if nothing is written to it.
+	created  bool
+	truncate bool
+	// base is the size of the existing object which is kept, and pos
+	// the position of the next write.
+	base int64
+	pos  int64
+	tmp  *os.File
+	size int64
+}
+
+// Name implements afero.File.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Stat implements afero.File.
+func (f *File) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, pathError("stat", f.name, fs.ErrClosed)
+	}
+	return f.info, nil
+}
+
+// Read implements afero.File.
+func (f *File) Read(p []byte) (int, error) {
+	if err := f.checkRead("read"); err != nil {
+		return 0, err
+	}
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+
+	if f.body != nil && f.bodyOffset != f.offset {
+		f.body.Close()
+		f.body = nil
+	}
+	if f.body == nil {
+		body, err := f.fs.client.GetObject(context.Background(), f.key, f.offset, -1)
+		if err != nil {
+			return 0, pathError("read", f.name, err)
+		}
+		f.body = body
+		f.bodyOffset = f.offset
+	}
+
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	f.bodyOffset = f.offset
+	if err == io.EOF && f.offset < f.info.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// ReadAt implements afero.File with a ranged request.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.checkRead("read"); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, pathError("read", f.name, syscall.EINVAL)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+
+	length := min(int64(len(p)), f.info.size-off)
+	body, err := f.fs.client.GetObject(context.Background(), f.key, off, length)
+	if err != nil {
+		return 0, pathError("read", f.name, err)
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:length])
+	if err == nil && length < int64(len(p)) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *File) checkRead(op string) error {
+	switch {
+	case f.closed:
+		return pathError(op, f.name, fs.ErrClosed)
+	case f.info.IsDir():
+		return pathError(op, f.name, syscall.EISDIR)
+	case f.w != nil:
+		return pathError(op, f.name, syscall.EBADF)
+	}
+	return nil
+}
+
+// Seek implements afero.File. Files opened for writing can only
+// be seeked to the position of the next write.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, pathError("seek", f.name, fs.ErrClosed)
+	}
+
+	current, size := f.offset, f.info.size
+	if f.w != nil {
+		current, size = f.w.pos, f.w.end()
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += current
+	case io.SeekEnd:
+		offset += size
+	default:
+		return 0, pathError("seek", f.name, syscall.EINVAL)
+	}
+
+	if offset < 0 {
+		return 0, pathError("seek", f.name, syscall.EINVAL)
+	}
+
+	if f.w != nil {
+		if offset != f.w.end() && offset != f.w.pos {
+			return 0, pathError("seek", f.name, errNotSequential)
+		}
+		f.w.pos = offset
+		return offset, nil
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+// Readdir implements afero.File.
+func (f *File) Readdir(count int) ([]os.FileInfo, error) {
+	if f.closed {
+		return nil, pathError("readdir", f.name, fs.ErrClosed)
+	}
+	if !f.info.IsDir() {
+		return nil, pathError("readdir", f.name, syscall.ENOTDIR)
+	}
+
+	if !f.listed {
+		entries, err := f.fs.readDir(context.Background(), f.key)
+		if err != nil {
+			return nil, pathError("readdir", f.name, err)
+		}
+		f.entries = entries
+		f.listed = true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n := min(count, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
+
+// Readdirnames implements afero.File.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	entries, err := f.Readdir(n)
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names, err
+}
+
+// Write implements afero.File.
+func (f *File) Write(p []byte) (int, error) {
+	if err := f.checkWrite("write"); err != nil {
+		return 0, err
+	}
+	if f.w.pos != f.w.end() {
+		return 0, pathError("write", f.name, errNotSequential)
+	}
+
+	if f.w.tmp == nil {
+		tmp, err := os.CreateTemp("", "filebrowser-s3-")
+		if err != nil {
+			return 0, pathError("write", f.name, err)
+		}
+		f.w.tmp = tmp
+	}
+
+	n, err := f.w.tmp.Write(p)
+	f.w.size += int64(n)
+	f.w.pos += int64(n)
+	f.info.size = f.w.end()
+	f.info.modTime = time.Now()
+	if err != nil {
+		return n, pathError("write", f.name, err)
+	}
+	return n, nil
+}
+
+// WriteAt implements afero.File, only at the position of the next write.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.checkWrite("write"); err != nil {
+		return 0, err
+	}
+	if off != f.w.end() {
+		return 0, pathError("write", f.name, errNotSequential)
+	}
+
+	f.w.pos = off
+	return f.Write(p)
+}
+
+// WriteString implements afero.File.
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Truncate implements afero.File. Files can only be truncated
+// to their current size or to zero.
+func (f *File) Truncate(size int64) error {
+	if err := f.checkWrite("truncate"); err != nil {
+		return err
+	}
+
+	switch {
+	case size == f.w.end():
+		return nil
+	case size == 0:
+		f.w.discard()
+		f.w.truncate = true
+		f.w.base = 0
+		f.w.pos = 0
+		f.info.size = 0
+		return nil
+	default:
+		return pathError("truncate", f.name, errNotSequential)
+	}
+}
+
+func (f *File) checkWrite(op string) error {
+	switch {
+	case f.closed:
+		return pathError(op, f.name, fs.ErrClosed)
+	case f.w == nil:
+		return pathError(op, f.name, syscall.EBADF)
+	}
+	return nil
+}
+
+// Sync implements afero.File by uploading what was written so far.
+func (f *File) Sync() error {
+	if f.closed {
+		return pathError("sync", f.name, fs.ErrClosed)
+	}
+	if f.w == nil {
+		return nil
+	}
+	return pathError("sync", f.name, f.flush())
+}
+
+// Close implements afero.File, uploading what was written.
+func (f *File) Close() error {
+	if f.closed {
+		return pathError("close", f.name, fs.ErrClosed)
+	}
+	f.closed = true
+
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+
+	if f.w == nil {
+		return nil
+	}
+
+	err := f.flush()
+	f.w.discard()
+	return pathError("close", f.name, err)
+}
+
+// flush uploads the object if it changed. Afterwards, the uploaded
+// object becomes the base of the next writes.
+func (f *File) flush() error {
+	w := f.w
+	if w.size == 0 && !w.created && !w.truncate {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	var err error
+	switch {
+	case w.size == 0 || w.base == 0:
+		err = f.fs.upload(ctx, f.key, w.reader(), w.size)
+	case w.base < minPartSize:
+		// The existing object is too small to be a part, so it's
+		// uploaded again along with the new data.
+		var body io.ReadCloser
+		body, err = f.fs.client.GetObject(ctx, f.key, 0, w.base)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		err = f.fs.upload(ctx, f.key, io.MultiReader(io.LimitReader(body, w.base), w.reader()), w.base+w.size)
+	default:
+		err = f.fs.appendObject(ctx, f.key, w.base, w.reader(), w.size)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.base += w.size
+	w.created = false
+	w.truncate = false
+	w.discard()
+	return nil
+}
+
+func (w *writer) end() int64 {
+	return w.base + w.size
+}
+
+func (w *writer) reader() io.Reader {
+	if w.tmp == nil {
+		return bytes.NewReader(nil)
+	}
+	return io.NewSectionReader(w.tmp, 0, w.size)
+}
+
+// discard removes the temporary file.
+func (w *writer) discard() {
+	if w.tmp != nil {
+		w.tmp.Close()
+		os.Remove(w.tmp.Name())
+		w.tmp = nil
+	}
+	w.size = 0
+}
+
+// upload uploads an object in a single request or, if it's
+// big enough, with a multipart upload.
+func (f *Fs) upload(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size <= partSize {
+		return f.client.PutObject(ctx, key, r, size)
+	}
+
+	up, err := f.newUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := up.write(r, size); err != nil {
+		up.abort()
+		return err
+	}
+	return up.complete()
+}
+
+// appendObject appends data to an object of at least minPartSize bytes
+// by copying it on the server as the first parts of a multipart upload.
+func (f *Fs) appendObject(ctx context.Context, key string, base int64, r io.Reader, size int64) error {
+	up, err := f.newUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := up.copy(key, 0, base); err != nil {
+		up.abort()
+		return err
+	}
+	if err := up.write(r, size); err != nil {
+		up.abort()
+		return err
+	}
+	return up.complete()
+}
+
+type upload struct {
+	ctx   context.Context
+	fs    *Fs
+	key   string
+	id    string
+	parts []Part
+}
+
+func (f *Fs) newUpload(ctx context.Context, key string) (*upload, error) {
+	id, err := f.client.CreateMultipartUpload(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &upload{ctx: ctx, fs: f, key: key, id: id}, nil
+}
+
+// copy adds a range of an existing object as parts of the upload.
+func (u *upload) copy(src string, offset, length int64) error {
+	for length > 0 {
+		n := min(length, maxCopySize)
+		// Avoid leaving a last copied part under the minimum size.
+		if rest := length - n; rest > 0 && rest < minPartSize {
+			n -= minPartSize
+		}
+
+		part, err := u.fs.client.UploadPartCopy(u.ctx, u.key, u.id, len(u.parts)+1, src, offset, n)
+		if err != nil {
+			return err
+		}
+		u.parts = append(u.parts, part)
+		offset += n
+		length -= n
+	}
+	return nil
+}
+
+// write adds the data of r as parts of the upload.
+func (u *upload) write(r io.Reader, size int64) error {
+	buf := make([]byte, min(size, partSize))
+	for size > 0 {
+		n := min(size, partSize)
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return err
+		}
+
+		part, err := u.fs.client.UploadPart(u.ctx, u.key, u.id, len(u.parts)+1, bytes.NewReader(buf[:n]), n)
+		if err != nil {
+			return err
+		}
+		u.parts = append(u.parts, part)
+		size -= n
+	}
+	return nil
+}
+
+func (u *upload) complete() error {
+	return u.fs.client.CompleteMultipartUpload(u.ctx, u.key, u.id, u.parts)
+}
+
+func (u *upload) abort() {
+	_ = u.fs.client.AbortMultipartUpload(u.ctx, u.key, u.id)
+}
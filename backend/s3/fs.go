@@ -0,0 +1,504 @@
+// Package s3 implements an afero.Fs backed by a bucket of an
+// S3-compatible object storage.
+//
+// Objects are mapped to files and the slashes of their keys to
+// directories. Empty directories are kept with a zero-length object
+// whose key ends with a slash, as most tools do. Files are written
+// to a temporary file and uploaded when synced or closed, and
+// appending to a file uses a multipart upload that copies the existing
+// object on the server, which makes resumable uploads efficient.
+package s3
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// partSize is the size of the parts of the multipart uploads.
+	// Smaller files are uploaded in a single request.
+	partSize = 16 << 20
+	// minPartSize is the minimum size of all but the last part
+	// of a multipart upload.
+	minPartSize = 5 << 20
+	// maxCopySize is the maximum size of an object, or of a part,
+	// that can be copied in a single request.
+	maxCopySize = 5 << 30
+	// deleteBatchSize is the maximum number of objects deleted
+	// in a single request.
+	deleteBatchSize = 1000
+
+	fileMode = 0644
+	dirMode  = fs.ModeDir | 0755
+)
+
+var errNotEmpty = errors.New("directory not empty")
+
+// Fs is an afero.Fs whose root is a prefix of a bucket.
+type Fs struct {
+	client *Client
+	prefix string
+}
+
+// NewFs creates a filesystem rooted at the given prefix of the bucket.
+func NewFs(client *Client, prefix string) *Fs {
+	prefix = strings.Trim(path.Clean("/"+prefix), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &Fs{client: client, prefix: prefix}
+}
+
+// key returns the key of the object of a path, which is empty for the root.
+func (f *Fs) key(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+filepathToSlash(name)), "/")
+	if name == "" {
+		return strings.TrimSuffix(f.prefix, "/")
+	}
+	return f.prefix + name
+}
+
+// dirPrefix returns the prefix of the keys under a directory.
+func (f *Fs) dirPrefix(key string) string {
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func (f *Fs) isRoot(key string) bool {
+	return key == strings.TrimSuffix(f.prefix, "/")
+}
+
+func filepathToSlash(name string) string {
+	return strings.ReplaceAll(name, "\\", "/")
+}
+
+func pathError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		err = fs.ErrNotExist
+	case errors.Is(err, fs.ErrPermission):
+		err = fs.ErrPermission
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Name implements afero.Fs.
+func (f *Fs) Name() string {
+	return "s3"
+}
+
+// RealPath returns the URL of the object of a path.
+func (f *Fs) RealPath(name string) (string, error) {
+	return "s3://" + f.client.Bucket + "/" + f.key(name), nil
+}
+
+// Stat implements afero.Fs.
+func (f *Fs) Stat(name string) (os.FileInfo, error) {
+	info, err := f.stat(context.Background(), f.key(name))
+	return info, pathError("stat", name, err)
+}
+
+func (f *Fs) stat(ctx context.Context, key string) (*fileInfo, error) {
+	if f.isRoot(key) {
+		return &fileInfo{name: "/", mode: dirMode}, nil
+	}
+
+	obj, err := f.client.HeadObject(ctx, key)
+	if err == nil {
+		return newFileInfo(*obj), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	res, err := f.client.ListObjects(ctx, f.dirPrefix(key), "", "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Objects) == 0 {
+		return nil, fs.ErrNotExist
+	}
+
+	info := &fileInfo{name: path.Base(key), mode: dirMode}
+	if res.Objects[0].Key == f.dirPrefix(key) {
+		info.modTime = res.Objects[0].LastModified
+	}
+	return info, nil
+}
+
+// Mkdir implements afero.Fs.
+func (f *Fs) Mkdir(name string, _ os.FileMode) error {
+	ctx := context.Background()
+	key := f.key(name)
+
+	if _, err := f.stat(ctx, key); err == nil {
+		return pathError("mkdir", name, fs.ErrExist)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return pathError("mkdir", name, err)
+	}
+
+	if parent := path.Dir(key); !f.isRoot(key) && !f.isRoot(parent) && parent != "." {
+		info, err := f.stat(ctx, parent)
+		if err != nil {
+			return pathError("mkdir", name, err)
+		}
+		if !info.IsDir() {
+			return pathError("mkdir", name, syscall.ENOTDIR)
+		}
+	}
+
+	return pathError("mkdir", name, f.client.PutObject(ctx, f.dirPrefix(key), nil, 0))
+}
+
+// MkdirAll implements afero.Fs. Only the directory itself gets a
+// marker, its parents exist as long as it does.
+func (f *Fs) MkdirAll(name string, _ os.FileMode) error {
+	ctx := context.Background()
+	key := f.key(name)
+
+	info, err := f.stat(ctx, key)
+	switch {
+	case err == nil && info.IsDir():
+		return nil
+	case err == nil:
+		return pathError("mkdir", name, syscall.ENOTDIR)
+	case !errors.Is(err, fs.ErrNotExist):
+		return pathError("mkdir", name, err)
+	}
+
+	return pathError("mkdir", name, f.client.PutObject(ctx, f.dirPrefix(key), nil, 0))
+}
+
+// Create implements afero.Fs.
+func (f *Fs) Create(name string) (afero.File, error) {
+	return f.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, fileMode)
+}
+
+// Open implements afero.Fs.
+func (f *Fs) Open(name string) (afero.File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements afero.Fs. Files opened for writing can only be
+// written sequentially, from their start when truncated or from their
+// end otherwise.
+func (f *Fs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	ctx := context.Background()
+	key := f.key(name)
+
+	info, err := f.stat(ctx, key)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, pathError("open", name, err)
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		if err != nil {
+			return nil, pathError("open", name, err)
+		}
+		return &File{fs: f, name: name, key: key, info: info}, nil
+	}
+
+	switch {
+	case err == nil && info.IsDir():
+		return nil, pathError("open", name, syscall.EISDIR)
+	case err == nil && flag&os.O_EXCL != 0 && flag&os.O_CREATE != 0:
+		return nil, pathError("open", name, fs.ErrExist)
+	case err != nil && flag&os.O_CREATE == 0:
+		return nil, pathError("open", name, err)
+	case err != nil:
+		if parent := path.Dir(key); !f.isRoot(parent) && parent != "." {
+			if pInfo, err := f.stat(ctx, parent); err != nil {
+				return nil, pathError("open", name, err)
+			} else if !pInfo.IsDir() {
+				return nil, pathError("open", name, syscall.ENOTDIR)
+			}
+		}
+		info = &fileInfo{name: path.Base(key), mode: fileMode, modTime: time.Now()}
+	}
+
+	w := &writer{created: err != nil, truncate: err != nil || flag&os.O_TRUNC != 0}
+	if w.truncate {
+		info.size = 0
+	} else {
+		w.base = info.size
+		if flag&os.O_APPEND != 0 {
+			w.pos = info.size
+		}
+	}
+
+	return &File{fs: f, name: name, key: key, info: info, w: w}, nil
+}
+
+// Remove implements afero.Fs.
+func (f *Fs) Remove(name string) error {
+	ctx := context.Background()
+	key := f.key(name)
+
+	info, err := f.stat(ctx, key)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+
+	if info.IsDir() {
+		res, err := f.client.ListObjects(ctx, f.dirPrefix(key), "", "", 2)
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		for _, obj := range res.Objects {
+			if obj.Key != f.dirPrefix(key) {
+				return pathError("remove", name, errNotEmpty)
+			}
+		}
+		key = f.dirPrefix(key)
+	}
+
+	if err := f.client.DeleteObject(ctx, key); err != nil {
+		return pathError("remove", name, err)
+	}
+
+	return pathError("remove", name, f.keepParent(ctx, key))
+}
+
+// RemoveAll implements afero.Fs.
+func (f *Fs) RemoveAll(name string) error {
+	ctx := context.Background()
+	key := f.key(name)
+
+	var keys []string
+	err := f.walk(ctx, f.dirPrefix(key), func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		return pathError("removeall", name, err)
+	}
+	if !f.isRoot(key) {
+		keys = append(keys, key)
+	}
+
+	for len(keys) > 0 {
+		n := min(len(keys), deleteBatchSize)
+		if err := f.client.DeleteObjects(ctx, keys[:n]); err != nil {
+			return pathError("removeall", name, err)
+		}
+		keys = keys[n:]
+	}
+
+	if f.isRoot(key) {
+		return nil
+	}
+	return pathError("removeall", name, f.keepParent(ctx, key))
+}
+
+// keepParent keeps the parent directory of a removed object from
+// disappearing along with its last child.
+func (f *Fs) keepParent(ctx context.Context, key string) error {
+	parent := path.Dir(strings.TrimSuffix(key, "/"))
+	if parent == "." || f.isRoot(parent) {
+		return nil
+	}
+
+	res, err := f.client.ListObjects(ctx, f.dirPrefix(parent), "", "", 1)
+	if err != nil || len(res.Objects) > 0 {
+		return err
+	}
+	return f.client.PutObject(ctx, f.dirPrefix(parent), nil, 0)
+}
+
+// Rename implements afero.Fs. Objects are copied on the server and then
+// deleted, so renaming a directory takes a request per file.
+func (f *Fs) Rename(oldname, newname string) error {
+	ctx := context.Background()
+	src, dst := f.key(oldname), f.key(newname)
+
+	info, err := f.stat(ctx, src)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: unwrapPathError(err)}
+	}
+	if src == dst {
+		return nil
+	}
+
+	if !info.IsDir() {
+		if err := f.copyObject(ctx, src, dst, info.size); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+		}
+		if err := f.client.DeleteObject(ctx, src); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+		}
+		return f.keepParent(ctx, src)
+	}
+
+	if f.isRoot(src) || strings.HasPrefix(dst, f.dirPrefix(src)) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EINVAL}
+	}
+	if _, err := f.stat(ctx, dst); err == nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrExist}
+	}
+
+	var keys []string
+	err = f.walk(ctx, f.dirPrefix(src), func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return f.copyObject(ctx, obj.Key, f.dirPrefix(dst)+strings.TrimPrefix(obj.Key, f.dirPrefix(src)), obj.Size)
+	})
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	for len(keys) > 0 {
+		n := min(len(keys), deleteBatchSize)
+		if err := f.client.DeleteObjects(ctx, keys[:n]); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+		}
+		keys = keys[n:]
+	}
+
+	return f.keepParent(ctx, src)
+}
+
+func unwrapPathError(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+// copyObject copies an object, with a multipart upload if it's too
+// big to be copied in a single request.
+func (f *Fs) copyObject(ctx context.Context, src, dst string, size int64) error {
+	if size <= maxCopySize {
+		return f.client.CopyObject(ctx, src, dst)
+	}
+
+	up, err := f.newUpload(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if err := up.copy(src, 0, size); err != nil {
+		up.abort()
+		return err
+	}
+	return up.complete()
+}
+
+// walk calls fn for every object whose key starts with prefix.
+func (f *Fs) walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	token := ""
+	for {
+		res, err := f.client.ListObjects(ctx, prefix, "", token, 0)
+		if err != nil {
+			return err
+		}
+		for _, obj := range res.Objects {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// readDir lists the entries of a directory, sorted by name.
+func (f *Fs) readDir(ctx context.Context, key string) ([]os.FileInfo, error) {
+	prefix := f.dirPrefix(key)
+
+	var entries []os.FileInfo
+	token := ""
+	for {
+		res, err := f.client.ListObjects(ctx, prefix, "/", token, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range res.Objects {
+			if obj.Key == prefix {
+				continue
+			}
+			entries = append(entries, newFileInfo(obj))
+		}
+		for _, p := range res.CommonPrefixes {
+			entries = append(entries, &fileInfo{name: path.Base(p), mode: dirMode})
+		}
+
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			break
+		}
+		token = res.NextContinuationToken
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Chmod implements afero.Fs. Objects have no mode, so it does nothing.
+func (f *Fs) Chmod(string, os.FileMode) error {
+	return nil
+}
+
+// Chown implements afero.Fs. Objects have no owner, so it does nothing.
+func (f *Fs) Chown(string, int, int) error {
+	return nil
+}
+
+// Chtimes implements afero.Fs. The modification time of an object is
+// set by the server, so it does nothing.
+func (f *Fs) Chtimes(string, time.Time, time.Time) error {
+	return nil
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func newFileInfo(obj Object) *fileInfo {
+	return &fileInfo{
+		name:    path.Base(obj.Key),
+		size:    obj.Size,
+		mode:    fileMode,
+		modTime: obj.LastModified,
+	}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() os.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *fileInfo) Sys() interface{}   { return nil }
+
+var (
+	_ afero.Fs   = (*Fs)(nil)
+	_ afero.File = (*File)(nil)
+)
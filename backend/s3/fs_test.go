@@ -0,0 +1,225 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/backend/s3/s3test"
+)
+
+func newTestFs(t *testing.T, prefix string) (*Fs, *s3test.Server) {
+	t.Helper()
+
+	srv := s3test.NewServer("bucket")
+	t.Cleanup(srv.Close)
+
+	endpoint, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "bucket",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	}
+	return NewFs(client, prefix), srv
+}
+
+func pattern(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestFs(t *testing.T) {
+	t.Parallel()
+
+	fs, srv := newTestFs(t, "users/john")
+	afs := &afero.Afero{Fs: fs}
+
+	for _, dir := range []string{"/docs/empty", "/docs/sub"} {
+		if err := fs.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := afs.WriteFile("/docs/a.txt", []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afs.WriteFile("/docs/sub/b.txt", []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wantKeys := []string{"users/john/docs/a.txt", "users/john/docs/empty/", "users/john/docs/sub/", "users/john/docs/sub/b.txt"}
+	if keys := srv.Keys(); !slices.Equal(keys, wantKeys) {
+		t.Fatalf("got keys %v, want %v", keys, wantKeys)
+	}
+
+	info, err := fs.Stat("/docs/a.txt")
+	if err != nil || info.IsDir() || info.Size() != 11 {
+		t.Fatalf("unexpected stat of file: %v, %v", info, err)
+	}
+	for _, dir := range []string{"/", "/docs", "/docs/empty", "/docs/sub"} {
+		if info, err := fs.Stat(dir); err != nil || !info.IsDir() {
+			t.Fatalf("%s: unexpected stat of directory: %v, %v", dir, info, err)
+		}
+	}
+	if _, err := fs.Stat("/missing"); !os.IsNotExist(err) {
+		t.Fatalf("stat of missing file: got %v, want not exist", err)
+	}
+
+	entries, err := afs.ReadDir("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"a.txt", "empty", "sub"}; !slices.Equal(names, want) {
+		t.Fatalf("got entries %v, want %v", names, want)
+	}
+
+	f, err := fs.Open("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "world" {
+		t.Fatalf("read after seek: got %q, %v", data, err)
+	}
+	buf := make([]byte, 4)
+	if n, err := f.ReadAt(buf, 1); err != nil || string(buf[:n]) != "ello" {
+		t.Fatalf("read at: got %q, %v", buf[:n], err)
+	}
+	f.Close()
+
+	if err := fs.Remove("/docs"); err == nil {
+		t.Fatal("removed a directory which isn't empty")
+	}
+
+	if err := fs.Rename("/docs", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := afs.ReadFile("/moved/sub/b.txt"); err != nil || string(data) != "b" {
+		t.Fatalf("read renamed file: got %q, %v", data, err)
+	}
+	if _, err := fs.Stat("/docs"); !os.IsNotExist(err) {
+		t.Fatalf("stat of renamed directory: got %v, want not exist", err)
+	}
+
+	if err := fs.Remove("/moved/sub/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := fs.Stat("/moved/sub"); err != nil || !info.IsDir() {
+		t.Fatalf("parent of the last removed file: %v, %v", info, err)
+	}
+
+	if err := fs.RemoveAll("/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := srv.Keys(); len(keys) != 0 {
+		t.Fatalf("got keys %v after removing everything", keys)
+	}
+}
+
+func TestFsAppend(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		base   int
+		chunks []int
+	}{
+		"small file":               {base: 0, chunks: []int{10, 20}},
+		"multipart upload":         {base: 0, chunks: []int{partSize + 1000}},
+		"small base is reuploaded": {base: 1000, chunks: []int{minPartSize}},
+		"big base is copied":       {base: minPartSize + 10, chunks: []int{1000, 2000}},
+		"resumed upload":           {base: 0, chunks: []int{minPartSize, minPartSize, 10}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, srv := newTestFs(t, "")
+			want := pattern(tc.base)
+			if err := afero.WriteFile(fs, "/file", want, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Write like the tus handlers do, one request per chunk.
+			for _, size := range tc.chunks {
+				info, err := fs.Stat("/file")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				f, err := fs.OpenFile("/file", os.O_WRONLY|os.O_APPEND, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := f.Seek(info.Size(), io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+
+				chunk := bytes.Repeat([]byte{byte(size)}, size)
+				if _, err := f.Write(chunk); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.Sync(); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.Close(); err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, chunk...)
+			}
+
+			got, ok := srv.Object("file")
+			if !ok || !bytes.Equal(got, want) {
+				t.Fatalf("got %d bytes, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestFsWriteErrors(t *testing.T) {
+	t.Parallel()
+
+	fs, _ := newTestFs(t, "")
+	if err := afero.WriteFile(fs, "/file", []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fs.OpenFile("/missing/file", os.O_WRONLY|os.O_CREATE, 0644); !os.IsNotExist(err) {
+		t.Fatalf("create in missing directory: got %v, want not exist", err)
+	}
+	if _, err := fs.OpenFile("/file", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); !os.IsExist(err) {
+		t.Fatalf("exclusive create of existing file: got %v, want exist", err)
+	}
+
+	f, err := fs.OpenFile("/file", os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Fatal("overwrote the start of an existing file")
+	}
+	if _, err := f.Seek(2, io.SeekStart); err == nil {
+		t.Fatal("seeked in the middle of an existing file")
+	}
+}
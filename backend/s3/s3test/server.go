@@ -0,0 +1,420 @@
+// Package s3test provides an in-memory S3-compatible server for tests.
+// It implements the calls used by the s3 package with path-style
+// addressing, but doesn't check the signatures of the requests.
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // ETags of the objects.
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinPartSize is the minimum size of all but the last part of a
+// multipart upload, as enforced by S3.
+const MinPartSize = 5 << 20
+
+type object struct {
+	data    []byte
+	modTime time.Time
+}
+
+type upload struct {
+	key   string
+	parts map[int][]byte
+}
+
+// Server is an in-memory S3 server with a single bucket.
+type Server struct {
+	*httptest.Server
+	Bucket string
+
+	mux     sync.Mutex
+	objects map[string]*object
+	uploads map[string]*upload
+	nextID  int
+}
+
+// NewServer starts a server with an empty bucket.
+func NewServer(bucket string) *Server {
+	s := &Server{
+		Bucket:  bucket,
+		objects: map[string]*object{},
+		uploads: map[string]*upload{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Object returns the content of an object.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return bytes.Clone(obj.data), true
+}
+
+// Keys returns the keys of all the objects, sorted.
+func (s *Server) Keys() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data) //nolint:gosec
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=") {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, query)
+	case key == "" && r.Method == http.MethodPost && query.Has("delete"):
+		s.deleteObjects(w, r)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.nextID++
+		id := strconv.Itoa(s.nextID)
+		s.uploads[id] = &upload{key: key, parts: map[int][]byte{}}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: id})
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, r, key, query.Get("uploadId"))
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.uploadPart(w, r, key, query)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, key)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		s.getObject(w, r, key)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := 1000
+	if v := query.Get("max-keys"); v != "" {
+		maxKeys, _ = strconv.Atoi(v)
+	}
+	token := query.Get("continuation-token")
+
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	}{}
+
+	seen := map[string]bool{}
+	count := 0
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) || k <= token {
+			continue
+		}
+
+		entry := k
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				entry = k[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if seen[entry] {
+			continue
+		}
+
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		seen[entry] = true
+		count++
+
+		if entry != k {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: entry})
+			// Continue after every key of the prefix.
+			result.NextContinuationToken = entry + "\xff"
+			continue
+		}
+
+		obj := s.objects[k]
+		result.Contents = append(result.Contents, content{
+			Key:          k,
+			Size:         len(obj.data),
+			LastModified: obj.modTime.UTC().Format(time.RFC3339Nano),
+		})
+		result.NextContinuationToken = k
+	}
+
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+	writeXML(w, result)
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Md5") == "" {
+		writeError(w, http.StatusBadRequest, "MissingContentMD5")
+		return
+	}
+
+	var req struct {
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	for _, o := range req.Objects {
+		delete(s.objects, o.Key)
+	}
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"DeleteResult"`
+	}{})
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, key string) {
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		obj, ok := s.copySource(src)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		s.objects[key] = &object{data: bytes.Clone(obj.data), modTime: time.Now()}
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CopyObjectResult"`
+			ETag    string   `xml:"ETag"`
+		}{ETag: etag(obj.data)})
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	s.objects[key] = &object{data: data, modTime: time.Now()}
+	w.Header().Set("ETag", etag(data))
+}
+
+func (s *Server) copySource(src string) (*object, bool) {
+	src, err := url.PathUnescape(src)
+	if err != nil {
+		return nil, false
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+	if bucket != s.Bucket {
+		return nil, false
+	}
+	obj, ok := s.objects[key]
+	return obj, ok
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, key string) {
+	obj, ok := s.objects[key]
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	data := obj.data
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end, ok := parseRange(rng, int64(len(data)))
+		if !ok {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+			return
+		}
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("ETag", etag(obj.data))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func parseRange(rng string, size int64) (start, end int64, ok bool) {
+	spec, found := strings.CutPrefix(rng, "bytes=")
+	if !found {
+		return 0, 0, false
+	}
+	first, last, _ := strings.Cut(spec, "-")
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+
+	end = size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, key string, query url.Values) {
+	up, ok := s.uploads[query.Get("uploadId")]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || number < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		obj, ok := s.copySource(src)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		start, end, ok := parseRange(r.Header.Get("X-Amz-Copy-Source-Range"), int64(len(obj.data)))
+		if !ok {
+			writeError(w, http.StatusBadRequest, "InvalidRange")
+			return
+		}
+		up.parts[number] = bytes.Clone(obj.data[start : end+1])
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CopyPartResult"`
+			ETag    string   `xml:"ETag"`
+		}{ETag: etag(up.parts[number])})
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	up.parts[number] = data
+	w.Header().Set("ETag", etag(data))
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, key, id string) {
+	up, ok := s.uploads[id]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	var req struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	var data []byte
+	for i, p := range req.Parts {
+		part, ok := up.parts[p.PartNumber]
+		if !ok || etag(part) != p.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		if i < len(req.Parts)-1 && len(part) < MinPartSize {
+			// S3 answers some errors of this call with a 200 status.
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "<Error><Code>EntityTooSmall</Code><Message>part too small</Message></Error>")
+			return
+		}
+		data = append(data, part...)
+	}
+
+	delete(s.uploads, id)
+	s.objects[key] = &object{data: data, modTime: time.Now()}
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string   `xml:"Key"`
+	}{Key: key})
+}
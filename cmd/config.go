@@ -7,11 +7,13 @@ import (
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/backend"
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/settings"
 )
@@ -35,6 +37,7 @@ func addConfigFlags(flags *pflag.FlagSet) {
 	flags.Bool("hideLoginButton", false, "hide login button from public pages")
 	flags.Bool("createUserDir", false, "generate user's home directory automatically")
 	flags.Uint("minimumPasswordLength", settings.DefaultMinimumPasswordLength, "minimum password length for new users")
+	flags.Bool("twoFactorRequired", false, "require users of auth.method=json to set up two-factor authentication")
 	flags.String("shell", "", "shell command to which other commands should be appended")
 
 	// NB: these are string so they can be presented as octal in the help text
@@ -47,6 +50,13 @@ func addConfigFlags(flags *pflag.FlagSet) {
 	flags.String("auth.logoutUrl", "", "Logout URL that should be called when auth.method=proxy")
 	flags.String("auth.command", "", "command for auth.method=hook")
 	flags.String("auth.logoutPage", "", "url of custom logout page")
+	flags.String("auth.oidc.issuer", "", "issuer URL for auth.method=oidc")
+	flags.String("auth.oidc.clientId", "", "client ID for auth.method=oidc")
+	flags.String("auth.oidc.clientSecret", "", "client secret for auth.method=oidc")
+	flags.String("auth.oidc.redirectUrl", "", "callback URL registered at the provider for auth.method=oidc (derived from the request if empty)")
+	flags.String("auth.oidc.scopes", "", "comma separated scopes requested for auth.method=oidc (default \"openid,profile,email\")")
+	flags.String("auth.oidc.usernameClaim", "", "claim holding the username for auth.method=oidc (default \"sub\")")
+	flags.String("auth.oidc.rules", "", "JSON list of rules mapping claims to permissions and scope for auth.method=oidc")
 
 	flags.String("recaptcha.host", "https://www.google.com", "use another host for ReCAPTCHA. recaptcha.net might be useful in China")
 	flags.String("recaptcha.key", "", "ReCaptcha site key")
@@ -61,6 +71,14 @@ func addConfigFlags(flags *pflag.FlagSet) {
 
 	flags.Uint64("tus.chunkSize", settings.DefaultTusChunkSize, "the tus chunk size")
 	flags.Uint16("tus.retryCount", settings.DefaultTusRetryCount, "the tus retry count")
+
+	flags.String("limits.userBandwidth", "0", "bandwidth of each user per second, e.g. 10M (0 for unlimited)")
+	flags.String("limits.shareBandwidth", "0", "bandwidth of each share per second, e.g. 10M (0 for unlimited)")
+	flags.Uint("limits.loginRate", 0, "login requests a minute of each client (0 for unlimited)")
+	flags.Uint("limits.publicRate", 0, "requests a minute of each client to the shares (0 for unlimited)")
+
+	flags.Uint("lockout.attempts", settings.DefaultLockoutAttempts, "failed logins of a username or an address before it's locked out (0 to disable)")
+	flags.String("lockout.duration", settings.DefaultLockoutDuration.String(), "duration of the lockouts")
 }
 
 func getAuthMethod(flags *pflag.FlagSet, defaults ...interface{}) (settings.AuthMethod, map[string]interface{}, error) {
@@ -170,6 +188,63 @@ func getHookAuth(flags *pflag.FlagSet, defaultAuther map[string]interface{}) (au
 	return &auth.HookAuth{Command: command}, nil
 }
 
+func getOIDCAuth(flags *pflag.FlagSet, defaultAuther map[string]interface{}) (auth.Auther, error) {
+	oidcAuth := &auth.OIDCAuth{}
+	if defaultAuther != nil {
+		raw, err := json.Marshal(defaultAuther)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, oidcAuth); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := map[string]*string{
+		"auth.oidc.issuer":        &oidcAuth.Issuer,
+		"auth.oidc.clientId":      &oidcAuth.ClientID,
+		"auth.oidc.clientSecret":  &oidcAuth.ClientSecret,
+		"auth.oidc.redirectUrl":   &oidcAuth.RedirectURL,
+		"auth.oidc.usernameClaim": &oidcAuth.UsernameClaim,
+	}
+	for name, field := range fields {
+		value, err := flags.GetString(name)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			*field = value
+		}
+	}
+
+	scopes, err := flags.GetString("auth.oidc.scopes")
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		oidcAuth.Scopes = strings.FieldsFunc(scopes, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	}
+
+	rules, err := flags.GetString("auth.oidc.rules")
+	if err != nil {
+		return nil, err
+	}
+	if rules != "" {
+		oidcAuth.Rules = []auth.OIDCRule{}
+		if err := json.Unmarshal([]byte(rules), &oidcAuth.Rules); err != nil {
+			return nil, fmt.Errorf("invalid flag 'auth.oidc.rules': %w", err)
+		}
+	}
+
+	if oidcAuth.Issuer == "" || oidcAuth.ClientID == "" {
+		return nil, errors.New("you must set the flags 'auth.oidc.issuer' and 'auth.oidc.clientId' for method 'oidc'")
+	}
+
+	return oidcAuth, nil
+}
+
 func getAuthentication(flags *pflag.FlagSet, defaults ...interface{}) (settings.AuthMethod, auth.Auther, error) {
 	method, defaultAuther, err := getAuthMethod(flags, defaults...)
 	if err != nil {
@@ -186,6 +261,8 @@ func getAuthentication(flags *pflag.FlagSet, defaults ...interface{}) (settings.
 		auther, err = getJSONAuth(flags, defaultAuther)
 	case auth.MethodHookAuth:
 		auther, err = getHookAuth(flags, defaultAuther)
+	case auth.MethodOIDCAuth:
+		auther, err = getOIDCAuth(flags, defaultAuther)
 	default:
 		return "", nil, fberrors.ErrInvalidAuthMethod
 	}
@@ -205,6 +282,7 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "Create User Dir:\t%t\n", set.CreateUserDir)
 	fmt.Fprintf(w, "Logout Page:\t%s\n", set.LogoutPage)
 	fmt.Fprintf(w, "Minimum Password Length:\t%d\n", set.MinimumPasswordLength)
+	fmt.Fprintf(w, "Two-Factor Required:\t%t\n", set.TwoFactorRequired)
 	fmt.Fprintf(w, "Auth Method:\t%s\n", set.AuthMethod)
 	fmt.Fprintf(w, "Shell:\t%s\t\n", strings.Join(set.Shell, " "))
 
@@ -226,6 +304,8 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\tTLS Cert:\t%s\n", ser.TLSCert)
 	fmt.Fprintf(w, "\tTLS Key:\t%s\n", ser.TLSKey)
 	fmt.Fprintf(w, "\tToken Expiration Time:\t%s\n", ser.TokenExpirationTime)
+	fmt.Fprintf(w, "\tTrash Retention:\t%s\n", ser.TrashRetention)
+	fmt.Fprintf(w, "\tTrust Proxy Headers:\t%t\n", ser.TrustProxyHeaders)
 	fmt.Fprintf(w, "\tExec Enabled:\t%t\n", ser.EnableExec)
 	fmt.Fprintf(w, "\tThumbnails Enabled:\t%t\n", ser.EnableThumbnails)
 	fmt.Fprintf(w, "\tResize Preview:\t%t\n", ser.ResizePreview)
@@ -235,6 +315,16 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\tChunk size:\t%d\n", set.Tus.ChunkSize)
 	fmt.Fprintf(w, "\tRetry count:\t%d\n", set.Tus.RetryCount)
 
+	fmt.Fprintln(w, "\nLimits:")
+	fmt.Fprintf(w, "\tUser bandwidth:\t%d\n", set.Limits.UserBandwidth)
+	fmt.Fprintf(w, "\tShare bandwidth:\t%d\n", set.Limits.ShareBandwidth)
+	fmt.Fprintf(w, "\tLogin rate:\t%d\n", set.Limits.LoginRate)
+	fmt.Fprintf(w, "\tPublic rate:\t%d\n", set.Limits.PublicRate)
+
+	fmt.Fprintln(w, "\nLockout:")
+	fmt.Fprintf(w, "\tAttempts:\t%d\n", set.Lockout.Attempts)
+	fmt.Fprintf(w, "\tDuration:\t%s\n", set.Lockout.Duration)
+
 	fmt.Fprintln(w, "\nDefaults:")
 	fmt.Fprintf(w, "\tScope:\t%s\n", set.Defaults.Scope)
 	fmt.Fprintf(w, "\tDateFormat:\t%t\n", set.Defaults.DateFormat)
@@ -247,6 +337,24 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\tDirectory Creation Mode:\t%O\n", set.DirMode)
 	fmt.Fprintf(w, "\tCommands:\t%s\n", strings.Join(set.Defaults.Commands, " "))
 	fmt.Fprintf(w, "\tAce editor syntax highlighting theme:\t%s\n", set.Defaults.AceEditorTheme)
+	fmt.Fprintf(w, "\tStorage backend:\t%s\n", set.Defaults.Backend.Type)
+	if set.Defaults.Backend.Type == backend.TypeS3 {
+		fmt.Fprintf(w, "\t\tEndpoint:\t%s\n", set.Defaults.Backend.S3.Endpoint)
+		fmt.Fprintf(w, "\t\tRegion:\t%s\n", set.Defaults.Backend.S3.Region)
+		fmt.Fprintf(w, "\t\tBucket:\t%s\n", set.Defaults.Backend.S3.Bucket)
+		fmt.Fprintf(w, "\t\tPrefix:\t%s\n", set.Defaults.Backend.S3.Prefix)
+		fmt.Fprintf(w, "\t\tPath Style:\t%t\n", set.Defaults.Backend.S3.PathStyle)
+	}
+	if len(set.Defaults.Mounts) > 0 {
+		fmt.Fprintf(w, "\tMounts:\n")
+		for _, m := range set.Defaults.Mounts {
+			fmt.Fprintf(w, "\t\t%s:\t%s (read-only: %t)\n", m.Path, m.Scope, m.ReadOnly)
+		}
+	}
+
+	fmt.Fprintf(w, "\tQuota:\n")
+	fmt.Fprintf(w, "\t\tSpace:\t%d\n", set.Defaults.Quota.Space)
+	fmt.Fprintf(w, "\t\tInodes:\t%d\n", set.Defaults.Quota.Inodes)
 
 	fmt.Fprintf(w, "\tSorting:\n")
 	fmt.Fprintf(w, "\t\tBy:\t%s\n", set.Defaults.Sorting.By)
@@ -299,6 +407,10 @@ func getSettings(flags *pflag.FlagSet, set *settings.Settings, ser *settings.Ser
 			ser.BaseURL, err = flags.GetString(flag.Name)
 		case "tokenExpirationTime":
 			ser.TokenExpirationTime, err = flags.GetString(flag.Name)
+		case "trashRetention":
+			ser.TrashRetention, err = flags.GetString(flag.Name)
+		case "trustProxyHeaders":
+			ser.TrustProxyHeaders, err = flags.GetBool(flag.Name)
 		case "disableThumbnails":
 			ser.EnableThumbnails, err = flags.GetBool(flag.Name)
 			ser.EnableThumbnails = !ser.EnableThumbnails
@@ -328,6 +440,8 @@ func getSettings(flags *pflag.FlagSet, set *settings.Settings, ser *settings.Ser
 			set.CreateUserDir, err = flags.GetBool(flag.Name)
 		case "minimumPasswordLength":
 			set.MinimumPasswordLength, err = flags.GetUint(flag.Name)
+		case "twoFactorRequired":
+			set.TwoFactorRequired, err = flags.GetBool(flag.Name)
 		case "shell":
 			var shell string
 			shell, err = flags.GetString(flag.Name)
@@ -358,6 +472,21 @@ func getSettings(flags *pflag.FlagSet, set *settings.Settings, ser *settings.Ser
 			set.Tus.ChunkSize, err = flags.GetUint64(flag.Name)
 		case "tus.retryCount":
 			set.Tus.RetryCount, err = flags.GetUint16(flag.Name)
+		case "limits.userBandwidth":
+			set.Limits.UserBandwidth, err = getSize(flags, flag.Name)
+		case "limits.shareBandwidth":
+			set.Limits.ShareBandwidth, err = getSize(flags, flag.Name)
+		case "limits.loginRate":
+			set.Limits.LoginRate, err = flags.GetUint(flag.Name)
+		case "limits.publicRate":
+			set.Limits.PublicRate, err = flags.GetUint(flag.Name)
+		case "lockout.attempts":
+			set.Lockout.Attempts, err = flags.GetUint(flag.Name)
+		case "lockout.duration":
+			set.Lockout.Duration, err = flags.GetString(flag.Name)
+			if err == nil {
+				_, err = time.ParseDuration(set.Lockout.Duration)
+			}
 		case "auth.logoutUrl":
 			set.AuthLogoutURL, err = flags.GetString(flag.Name)
 		}
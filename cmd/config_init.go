@@ -26,7 +26,7 @@ override the options.`,
 		flags := cmd.Flags()
 
 		// Initialize config
-		s := &settings.Settings{Key: generateKey()}
+		s := &settings.Settings{Key: generateKey(), Lockout: settings.DefaultLockout()}
 		ser := &settings.Server{}
 
 		// Fill config with options
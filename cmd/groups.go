@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+)
+
+func init() {
+	rootCmd.AddCommand(groupsCmd)
+}
+
+var groupsCmd = &cobra.Command{
+	Use:   "groups",
+	Short: "Groups management utility",
+	Long: `Groups management utility. The permissions and commands
+of a group are added to the ones of its members, and its rules
+are applied before theirs. Its quota applies to the members
+without their own.`,
+	Args: cobra.NoArgs,
+}
+
+func printGroups(list []*groups.Group) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tName\tAdmin\tExecute\tCreate\tRename\tModify\tDelete\tShare\tDownload\tCommands\tRules")
+
+	for _, g := range list {
+		fmt.Fprintf(w, "%d\t%s\t%t\t%t\t%t\t%t\t%t\t%t\t%t\t%t\t%s\t%d\t\n",
+			g.ID,
+			g.Name,
+			g.Perm.Admin,
+			g.Perm.Execute,
+			g.Perm.Create,
+			g.Perm.Rename,
+			g.Perm.Modify,
+			g.Perm.Delete,
+			g.Perm.Share,
+			g.Perm.Download,
+			strings.Join(g.Commands, " "),
+			len(g.Rules),
+		)
+	}
+
+	w.Flush()
+}
+
+func addGroupFlags(flags *pflag.FlagSet) {
+	flags.Bool("perm.admin", false, "admin perm for the members")
+	flags.Bool("perm.execute", false, "execute perm for the members")
+	flags.Bool("perm.create", false, "create perm for the members")
+	flags.Bool("perm.rename", false, "rename perm for the members")
+	flags.Bool("perm.modify", false, "modify perm for the members")
+	flags.Bool("perm.delete", false, "delete perm for the members")
+	flags.Bool("perm.share", false, "share perm for the members")
+	flags.Bool("perm.download", false, "download perm for the members")
+	flags.StringSlice("commands", nil, "a list of the commands the members can execute")
+	flags.String("quota.space", "0", "disk space quota for the members without their own, e.g. 10G (0 for unlimited)")
+	flags.Uint64("quota.inodes", 0, "maximum number of files and directories for the members without their own (0 for unlimited)")
+}
+
+// getGroupFlags sets the options of a group from the flags which were
+// changed.
+func getGroupFlags(flags *pflag.FlagSet, g *groups.Group) error {
+	errs := []error{}
+
+	flags.Visit(func(flag *pflag.Flag) {
+		var err error
+		switch flag.Name {
+		case "perm.admin":
+			g.Perm.Admin, err = flags.GetBool(flag.Name)
+		case "perm.execute":
+			g.Perm.Execute, err = flags.GetBool(flag.Name)
+		case "perm.create":
+			g.Perm.Create, err = flags.GetBool(flag.Name)
+		case "perm.rename":
+			g.Perm.Rename, err = flags.GetBool(flag.Name)
+		case "perm.modify":
+			g.Perm.Modify, err = flags.GetBool(flag.Name)
+		case "perm.delete":
+			g.Perm.Delete, err = flags.GetBool(flag.Name)
+		case "perm.share":
+			g.Perm.Share, err = flags.GetBool(flag.Name)
+		case "perm.download":
+			g.Perm.Download, err = flags.GetBool(flag.Name)
+		case "commands":
+			g.Commands, err = flags.GetStringSlice(flag.Name)
+		case "quota.space":
+			g.Quota.Space, err = getSize(flags, flag.Name)
+		case "quota.inodes":
+			g.Quota.Inodes, err = flags.GetUint64(flag.Name)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// getGroup gets a group by its name or id.
+func getGroup(st *store, arg string) (*groups.Group, error) {
+	name, id := parseUsernameOrID(arg)
+	if name != "" {
+		return st.Groups.Get(name)
+	}
+	return st.Groups.Get(id)
+}
+
+// getGroupIDs gets the ids of the groups with the given names or ids.
+func getGroupIDs(st *store, args []string) ([]uint, error) {
+	ids := []uint{}
+	for _, arg := range args {
+		g, err := getGroup(st, arg)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", arg, err)
+		}
+		ids = append(ids, g.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsAddCmd)
+	addGroupFlags(groupsAddCmd.Flags())
+}
+
+var groupsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a new group",
+	Long:  `Create a new group and add it to the database.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: withStore(func(cmd *cobra.Command, args []string, st *store) error {
+		g := &groups.Group{Name: args[0]}
+		if err := getGroupFlags(cmd.Flags(), g); err != nil {
+			return err
+		}
+
+		if err := st.Groups.Save(g); err != nil {
+			return err
+		}
+		printGroups([]*groups.Group{g})
+		return nil
+	}, storeOptions{}),
+}
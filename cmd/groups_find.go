@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsFindCmd)
+	groupsCmd.AddCommand(groupsLsCmd)
+}
+
+var groupsFindCmd = &cobra.Command{
+	Use:   "find <id|name>",
+	Short: "Find a group by name or id",
+	Long:  `Find a group by name or id.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  findGroups,
+}
+
+var groupsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List all groups.",
+	Args:  cobra.NoArgs,
+	RunE:  findGroups,
+}
+
+var findGroups = withStore(func(_ *cobra.Command, args []string, st *store) error {
+	var (
+		list []*groups.Group
+		err  error
+	)
+
+	if len(args) == 1 {
+		var g *groups.Group
+		g, err = getGroup(st, args[0])
+		list = []*groups.Group{g}
+	} else {
+		list, err = st.Groups.Gets()
+	}
+
+	if err != nil {
+		return err
+	}
+	printGroups(list)
+	return nil
+}, storeOptions{})
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsRmCmd)
+}
+
+var groupsRmCmd = &cobra.Command{
+	Use:   "rm <id|name>",
+	Short: "Delete a group by name or id",
+	Long:  `Delete a group by name or id. Its members are removed from it.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: withStore(func(_ *cobra.Command, args []string, st *store) error {
+		name, id := parseUsernameOrID(args[0])
+		var err error
+
+		if name != "" {
+			err = st.Groups.Delete(name)
+		} else {
+			err = st.Groups.Delete(id)
+		}
+
+		if err != nil {
+			return err
+		}
+		fmt.Println("group deleted successfully")
+		return nil
+	}, storeOptions{}),
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsUpdateCmd)
+	groupsUpdateCmd.Flags().StringP("name", "n", "", "new name")
+	addGroupFlags(groupsUpdateCmd.Flags())
+}
+
+var groupsUpdateCmd = &cobra.Command{
+	Use:   "update <id|name>",
+	Short: "Updates an existing group",
+	Long: `Updates an existing group. Set the flags for the
+options you want to change.`,
+	Args: cobra.ExactArgs(1),
+	RunE: withStore(func(cmd *cobra.Command, args []string, st *store) error {
+		flags := cmd.Flags()
+		g, err := getGroup(st, args[0])
+		if err != nil {
+			return err
+		}
+
+		name, err := flags.GetString("name")
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			g.Name = name
+		}
+
+		if err := getGroupFlags(flags, g); err != nil {
+			return err
+		}
+
+		if err := st.Groups.Save(g); err != nil {
+			return err
+		}
+		printGroups([]*groups.Group{g})
+		return nil
+	}, storeOptions{}),
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/filebrowser/filebrowser/v2/groups"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
@@ -58,11 +59,16 @@ including 'index_end'.`,
 			return st.Users.Save(u)
 		}
 
+		group := func(g *groups.Group) error {
+			g.Rules = append(g.Rules[:i], g.Rules[f+1:]...)
+			return st.Groups.Save(g)
+		}
+
 		global := func(s *settings.Settings) error {
 			s.Rules = append(s.Rules[:i], s.Rules[f+1:]...)
 			return st.Settings.Save(s)
 		}
 
-		return runRules(st.Storage, cmd, user, global)
+		return runRules(st.Storage, cmd, user, group, global)
 	}, storeOptions{}),
 }
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/filebrowser/filebrowser/v2/groups"
 	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/storage"
@@ -16,20 +19,49 @@ func init() {
 	rootCmd.AddCommand(rulesCmd)
 	rulesCmd.PersistentFlags().StringP("username", "u", "", "username of user to which the rules apply")
 	rulesCmd.PersistentFlags().UintP("id", "i", 0, "id of user to which the rules apply")
+	rulesCmd.PersistentFlags().StringP("group", "g", "", "name or id of group to which the rules apply")
 }
 
 var rulesCmd = &cobra.Command{
 	Use:   "rules",
 	Short: "Rules management utility",
-	Long: `On each subcommand you'll have available at least two flags:
-"username" and "id". You must either set only one of them
-or none. If you set one of them, the command will apply to
-an user, otherwise it will be applied to the global set or
-rules.`,
+	Long: `On each subcommand you'll have available at least three flags:
+"username", "id" and "group". You must either set only one of them
+or none. If you set "username" or "id", the command will apply to
+an user, if you set "group", it will apply to a group, otherwise it
+will be applied to the global set or rules.`,
 	Args: cobra.NoArgs,
 }
 
-func runRules(st *storage.Storage, cmd *cobra.Command, usersFn func(*users.User) error, globalFn func(*settings.Settings) error) error {
+func runRules(st *storage.Storage, cmd *cobra.Command, usersFn func(*users.User) error, groupsFn func(*groups.Group) error, globalFn func(*settings.Settings) error) error {
+	group, err := cmd.Flags().GetString("group")
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		name, gid := parseUsernameOrID(group)
+		var g *groups.Group
+		if name != "" {
+			g, err = st.Groups.Get(name)
+		} else {
+			g, err = st.Groups.Get(gid)
+		}
+		if err != nil {
+			return err
+		}
+
+		if groupsFn != nil {
+			err = groupsFn(g)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Rules for group %s:\n\n", g.Name)
+		printRuleList(g.Rules)
+		return nil
+	}
+
 	id, err := getUserIdentifier(cmd.Flags())
 	if err != nil {
 		return err
@@ -95,8 +127,20 @@ func printRules(rulez []rules.Rule, id interface{}) {
 		fmt.Printf("Rules for user %v:\n\n", id)
 	}
 
+	printRuleList(rulez)
+}
+
+func printRuleList(rulez []rules.Rule) {
 	for id, rule := range rulez {
 		fmt.Printf("(%d) ", id)
+		if rule.IsPerm() {
+			printPermRule(rule)
+			continue
+		}
+		if rule.Glob != nil {
+			printGlobRule(rule)
+			continue
+		}
 		if rule.Regex {
 			if rule.Allow {
 				fmt.Printf("Allow Regex: \t%s\n", rule.Regexp.Raw)
@@ -112,3 +156,62 @@ func printRules(rulez []rules.Rule, id interface{}) {
 		}
 	}
 }
+
+func printGlobRule(rule rules.Rule) {
+	if rule.Allow {
+		fmt.Printf("Allow Glob: \t%s\n", globExp(rule))
+	} else {
+		fmt.Printf("Disallow Glob: \t%s\n", globExp(rule))
+	}
+}
+
+func globExp(rule rules.Rule) string {
+	if rule.Path == "" || rule.Path == "/" {
+		return rule.Glob.Raw
+	}
+	return rule.Glob.Raw + " (in " + rule.Path + ")"
+}
+
+func printPermRule(rule rules.Rule) {
+	exp := rule.Path
+	if rule.Regex {
+		exp = rule.Regexp.Raw
+	}
+	if rule.Glob != nil {
+		exp = globExp(rule)
+	}
+
+	fmt.Printf("Permissions: \t%s", exp)
+	if len(rule.Grant) > 0 {
+		fmt.Printf("\tgrant %s", joinPerms(rule.Grant))
+	}
+	if len(rule.Revoke) > 0 {
+		fmt.Printf("\trevoke %s", joinPerms(rule.Revoke))
+	}
+	fmt.Println()
+}
+
+func joinPerms(perms []rules.Perm) string {
+	names := make([]string, len(perms))
+	for i, perm := range perms {
+		names[i] = string(perm)
+	}
+	return strings.Join(names, ",")
+}
+
+func getPerms(flags *pflag.FlagSet, name string) ([]rules.Perm, error) {
+	names, err := flags.GetStringSlice(name)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make([]rules.Perm, 0, len(names))
+	for _, name := range names {
+		perm := rules.Perm(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(rules.Perms, perm) {
+			return nil, fmt.Errorf("invalid permission %q, must be one of %s", name, joinPerms(rules.Perms))
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
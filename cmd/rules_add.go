@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"regexp"
 
 	"github.com/spf13/cobra"
 
+	"github.com/filebrowser/filebrowser/v2/groups"
 	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/users"
@@ -14,13 +16,24 @@ func init() {
 	rulesCmd.AddCommand(rulesAddCmd)
 	rulesAddCmd.Flags().BoolP("allow", "a", false, "indicates this is an allow rule")
 	rulesAddCmd.Flags().BoolP("regex", "r", false, "indicates this is a regex rule")
+	rulesAddCmd.Flags().Bool("glob", false, "indicates this is a gitignore-style glob rule")
+	rulesAddCmd.Flags().String("dir", "/", "directory to which the glob pattern is relative")
+	rulesAddCmd.Flags().StringSlice("grant", nil, "permissions granted under the path (create, rename, modify, delete, share, download)")
+	rulesAddCmd.Flags().StringSlice("revoke", nil, "permissions revoked under the path (create, rename, modify, delete, share, download)")
 }
 
 var rulesAddCmd = &cobra.Command{
-	Use:   "add <path|expression>",
+	Use:   "add <path|expression|pattern>",
 	Short: "Add a global rule or user rule",
-	Long:  `Add a global rule or user rule.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Add a global rule or user rule.
+
+A rule with --grant or --revoke is a permission rule: instead of hiding
+the matching paths, it changes the permissions of the user on them.
+
+A glob rule uses a gitignore-style pattern, such as "**/*.env" or
+"node_modules/", relative to --dir. A pattern starting with "!" is
+negated: it allows what it would otherwise disallow.`,
+	Args: cobra.ExactArgs(1),
 	RunE: withStore(func(cmd *cobra.Command, args []string, st *store) error {
 		flags := cmd.Flags()
 
@@ -34,6 +47,25 @@ var rulesAddCmd = &cobra.Command{
 			return err
 		}
 
+		glob, err := flags.GetBool("glob")
+		if err != nil {
+			return err
+		}
+
+		if regex && glob {
+			return errors.New("a rule can't be both a regex and a glob rule")
+		}
+
+		grant, err := getPerms(flags, "grant")
+		if err != nil {
+			return err
+		}
+
+		revoke, err := getPerms(flags, "revoke")
+		if err != nil {
+			return err
+		}
+
 		exp := args[0]
 
 		if regex {
@@ -41,13 +73,29 @@ var rulesAddCmd = &cobra.Command{
 		}
 
 		rule := rules.Rule{
-			Allow: allow,
-			Regex: regex,
+			Allow:  allow,
+			Regex:  regex,
+			Grant:  grant,
+			Revoke: revoke,
 		}
 
-		if regex {
+		switch {
+		case regex:
 			rule.Regexp = &rules.Regexp{Raw: exp}
-		} else {
+		case glob:
+			dir, err := flags.GetString("dir")
+			if err != nil {
+				return err
+			}
+
+			globRule, err := rules.NewGlobRule(exp, dir, allow)
+			if err != nil {
+				return err
+			}
+			rule.Allow = globRule.Allow
+			rule.Path = globRule.Path
+			rule.Glob = globRule.Glob
+		default:
 			rule.Path = exp
 		}
 
@@ -56,11 +104,16 @@ var rulesAddCmd = &cobra.Command{
 			return st.Users.Save(u)
 		}
 
+		group := func(g *groups.Group) error {
+			g.Rules = append(g.Rules, rule)
+			return st.Groups.Save(g)
+		}
+
 		global := func(s *settings.Settings) error {
 			s.Rules = append(s.Rules, rule)
 			return st.Settings.Save(s)
 		}
 
-		return runRules(st.Storage, cmd, user, global)
+		return runRules(st.Storage, cmd, user, group, global)
 	}, storeOptions{}),
 }
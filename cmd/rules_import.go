@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func init() {
+	rulesCmd.AddCommand(rulesImportCmd)
+	rulesImportCmd.Flags().String("dir", "/", "directory to which the patterns are relative")
+}
+
+var rulesImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import glob rules from a " + rules.IgnoreFile + " file",
+	Long: `Import glob rules from a ` + rules.IgnoreFile + ` file.
+
+The file has the syntax of a .gitignore file: one gitignore-style
+pattern per line, blank lines and lines starting with "#" being
+skipped, and patterns starting with "!" allowing what the previous
+ones disallow. The patterns are relative to --dir, which usually is
+the directory where the file was found.`,
+	Args: cobra.ExactArgs(1),
+	RunE: withStore(func(cmd *cobra.Command, args []string, st *store) error {
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		imported, err := rules.ParseIgnore(f, dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+
+		user := func(u *users.User) error {
+			u.Rules = append(u.Rules, imported...)
+			return st.Users.Save(u)
+		}
+
+		group := func(g *groups.Group) error {
+			g.Rules = append(g.Rules, imported...)
+			return st.Groups.Save(g)
+		}
+
+		global := func(s *settings.Settings) error {
+			s.Rules = append(s.Rules, imported...)
+			return st.Settings.Save(s)
+		}
+
+		return runRules(st.Storage, cmd, user, group, global)
+	}, storeOptions{}),
+}
@@ -14,6 +14,6 @@ var rulesLsCommand = &cobra.Command{
 	Long:  `List global rules or user specific rules.`,
 	Args:  cobra.NoArgs,
 	RunE: withStore(func(cmd *cobra.Command, _ []string, st *store) error {
-		return runRules(st.Storage, cmd, nil, nil)
+		return runRules(st.Storage, cmd, nil, nil, nil)
 	}, storeOptions{}),
 }
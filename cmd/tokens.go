@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+}
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Personal API tokens management utility",
+	Long: `Personal API tokens management utility. A token authenticates
+the requests of its user with the "Authorization: Bearer <token>"
+header, with the permissions of the user or, if it has a scope, only
+the ones of the scope as well.`,
+	Args: cobra.NoArgs,
+}
+
+func printTokens(list []*tokens.Token) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUser ID\tName\tScope\tExpires\tLast Used")
+
+	for _, t := range list {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t\n",
+			t.ID,
+			t.UserID,
+			t.Name,
+			tokenScope(t.Perm),
+			formatUnix(t.Expires, "never"),
+			formatUnix(t.LastUsed, "never"),
+		)
+	}
+
+	w.Flush()
+}
+
+func formatUnix(sec int64, zero string) string {
+	if sec == 0 {
+		return zero
+	}
+	return time.Unix(sec, 0).Format(time.RFC3339)
+}
+
+// tokenPerms are the permissions a token can be scoped to.
+func tokenPerms(p *users.Permissions) map[string]*bool {
+	return map[string]*bool{
+		"admin":    &p.Admin,
+		"execute":  &p.Execute,
+		"create":   &p.Create,
+		"rename":   &p.Rename,
+		"modify":   &p.Modify,
+		"delete":   &p.Delete,
+		"share":    &p.Share,
+		"download": &p.Download,
+	}
+}
+
+func tokenScope(p *users.Permissions) string {
+	if p == nil {
+		return "all"
+	}
+
+	var names []string
+	for _, name := range []string{"admin", "execute", "create", "rename", "modify", "delete", "share", "download"} {
+		if *tokenPerms(p)[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// parseTokenScope parses a list of permissions into the scope of a
+// token.
+func parseTokenScope(names []string) (*users.Permissions, error) {
+	p := &users.Permissions{}
+	perms := tokenPerms(p)
+	for _, name := range names {
+		perm, ok := perms[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid permission %q", name)
+		}
+		*perm = true
+	}
+	return p, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func init() {
+	tokensCmd.AddCommand(tokensAddCmd)
+	tokensAddCmd.Flags().StringSlice("scope", nil, "permissions the token is restricted to, e.g. create,download (all the ones of the user if empty)")
+	tokensAddCmd.Flags().String("expires", "", "duration after which the token expires, e.g. 720h (never if empty)")
+}
+
+var tokensAddCmd = &cobra.Command{
+	Use:   "add <id|username> <name>",
+	Short: "Create a new token for a user",
+	Long: `Create a new personal API token for a user. The token is only
+shown once, as only its hash is kept.`,
+	Args: cobra.ExactArgs(2),
+	RunE: withStore(func(cmd *cobra.Command, args []string, st *store) error {
+		username, id := parseUsernameOrID(args[0])
+		var user *users.User
+		var err error
+
+		if username != "" {
+			user, err = st.Users.Get("", username)
+		} else {
+			user, err = st.Users.Get("", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		token := &tokens.Token{UserID: user.ID, Name: args[1]}
+
+		flags := cmd.Flags()
+		if flags.Changed("scope") {
+			names, err := flags.GetStringSlice("scope")
+			if err != nil {
+				return err
+			}
+			if token.Perm, err = parseTokenScope(names); err != nil {
+				return err
+			}
+		}
+
+		expires, err := flags.GetString("expires")
+		if err != nil {
+			return err
+		}
+		if expires != "" {
+			duration, err := time.ParseDuration(expires)
+			if err != nil {
+				return err
+			}
+			token.Expires = time.Now().Add(duration).Unix()
+		}
+
+		secret, err := st.Tokens.Create(token)
+		if err != nil {
+			return err
+		}
+
+		printTokens([]*tokens.Token{token})
+		fmt.Printf("\nToken: %s\n", secret)
+		return nil
+	}, storeOptions{}),
+}
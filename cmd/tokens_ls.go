@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func init() {
+	tokensCmd.AddCommand(tokensLsCmd)
+}
+
+var tokensLsCmd = &cobra.Command{
+	Use:   "ls [id|username]",
+	Short: "List all tokens, or the ones of a user",
+	Long:  `List all tokens, or the ones of a user.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: withStore(func(_ *cobra.Command, args []string, st *store) error {
+		var (
+			list []*tokens.Token
+			err  error
+		)
+
+		if len(args) == 1 {
+			username, id := parseUsernameOrID(args[0])
+			var user *users.User
+			if username != "" {
+				user, err = st.Users.Get("", username)
+			} else {
+				user, err = st.Users.Get("", id)
+			}
+			if err != nil {
+				return err
+			}
+			list, err = st.Tokens.FindByUserID(user.ID)
+		} else {
+			list, err = st.Tokens.All()
+		}
+
+		if err != nil {
+			return err
+		}
+		printTokens(list)
+		return nil
+	}, storeOptions{}),
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tokensCmd.AddCommand(tokensRmCmd)
+}
+
+var tokensRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Revoke a token by id",
+	Long:  `Revoke a token by id.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: withStore(func(_ *cobra.Command, args []string, st *store) error {
+		id, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := st.Tokens.Delete(uint(id)); err != nil {
+			return err
+		}
+		fmt.Println("token revoked successfully")
+		return nil
+	}, storeOptions{}),
+}
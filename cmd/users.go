@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/filebrowser/filebrowser/v2/backend"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
@@ -77,7 +80,12 @@ func addUserFlags(flags *pflag.FlagSet) {
 	flags.String("scope", ".", "scope for users")
 	flags.String("tmpDir", "", "tmp directory path for users")
 	flags.String("trashDir", "", "trash directory path for users")
+	flags.String("versionsDir", "", "directory path where the previous versions of overwritten files are kept (disabled if empty)")
+	flags.Uint("versionsMaxCount", 10, "maximum number of versions kept per file (0 for unlimited)")
+	flags.String("versionsMaxAge", "", "maximum age of the versions kept, e.g. 720h (unlimited if empty)")
 	flags.String("quotaFile", "", "path to file with quota data")
+	flags.String("quota.space", "0", "disk space quota for users, e.g. 10G (0 for unlimited)")
+	flags.Uint64("quota.inodes", 0, "maximum number of files and directories for users (0 for unlimited)")
 	flags.String("locale", "en_GB", "locale for users")
 	flags.String("viewMode", string(users.ListViewMode), "view mode for users")
 	flags.Bool("singleClick", false, "use single clicks only")
@@ -85,6 +93,65 @@ func addUserFlags(flags *pflag.FlagSet) {
 	flags.Bool("dateFormat", false, "use date format (true for absolute time, false for relative)")
 	flags.Bool("hideDotfiles", false, "hide dotfiles")
 	flags.String("aceEditorTheme", "", "ace editor's syntax highlighting theme for users")
+	flags.String("backend.type", string(backend.TypeLocal), "storage backend for users (local or s3)")
+	flags.String("backend.s3.endpoint", "", "endpoint URL of the S3 storage backend")
+	flags.String("backend.s3.region", "", "region of the S3 storage backend (default us-east-1)")
+	flags.String("backend.s3.bucket", "", "bucket of the S3 storage backend")
+	flags.String("backend.s3.prefix", "", "key prefix in the bucket under which the scopes of the users are")
+	flags.String("backend.s3.accessKey", "", "access key of the S3 storage backend")
+	flags.String("backend.s3.secretKey", "", "secret key of the S3 storage backend")
+	flags.Bool("backend.s3.pathStyle", false, "use path-style requests for the S3 storage backend")
+	flags.StringArray("mount", nil, "a mount point for users as path=scope, with a ,ro suffix for read-only ones (e.g. /www=/var/www,ro)")
+}
+
+// parseMounts parses the mount points given as path=scope[,ro]. The
+// empty values are ignored, so that --mount "" removes them all.
+func parseMounts(values []string) ([]users.Mount, error) {
+	mounts := []users.Mount{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		p, scope, ok := strings.Cut(value, "=")
+		if !ok || p == "" || scope == "" {
+			return nil, fmt.Errorf("invalid mount %q, it must be path=scope[,ro]", value)
+		}
+
+		mount := users.Mount{Path: p, Scope: scope}
+		if scope, ok := strings.CutSuffix(scope, ",ro"); ok {
+			mount.Scope = scope
+			mount.ReadOnly = true
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, nil
+}
+
+var sizeUnits = map[string]uint64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// getSize parses a size flag, in bytes or with a K, M, G or T suffix.
+func getSize(flags *pflag.FlagSet, name string) (uint64, error) {
+	value, err := flags.GetString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	value = strings.ToUpper(strings.TrimSpace(value))
+	number := strings.TrimRight(value, "KMGTB")
+	unit, ok := sizeUnits[strings.TrimSuffix(strings.TrimPrefix(value, number), "B")]
+	n, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q for %s", value, name)
+	}
+
+	return uint64(n * float64(unit)), nil
 }
 
 func getAndParseViewMode(flags *pflag.FlagSet) (users.ViewMode, error) {
@@ -113,8 +180,21 @@ func getUserDefaults(flags *pflag.FlagSet, defaults *settings.UserDefaults, all
 			defaults.TmpDir, err = flags.GetString(flag.Name)
 		case "trashDir":
 			defaults.TrashDir, err = flags.GetString(flag.Name)
+		case "versionsDir":
+			defaults.VersionsDir, err = flags.GetString(flag.Name)
+		case "versionsMaxCount":
+			defaults.VersionsMaxCount, err = flags.GetUint(flag.Name)
+		case "versionsMaxAge":
+			defaults.VersionsMaxAge, err = flags.GetString(flag.Name)
+			if err == nil && defaults.VersionsMaxAge != "" {
+				_, err = time.ParseDuration(defaults.VersionsMaxAge)
+			}
 		case "quotaFile":
 			defaults.QuotaFile, err = flags.GetString(flag.Name)
+		case "quota.space":
+			defaults.Quota.Space, err = getSize(flags, flag.Name)
+		case "quota.inodes":
+			defaults.Quota.Inodes, err = flags.GetUint64(flag.Name)
 		case "locale":
 			defaults.Locale, err = flags.GetString(flag.Name)
 		case "viewMode":
@@ -151,6 +231,30 @@ func getUserDefaults(flags *pflag.FlagSet, defaults *settings.UserDefaults, all
 			defaults.DateFormat, err = flags.GetBool(flag.Name)
 		case "hideDotfiles":
 			defaults.HideDotfiles, err = flags.GetBool(flag.Name)
+		case "backend.type":
+			var t string
+			t, err = flags.GetString(flag.Name)
+			defaults.Backend.Type = backend.Type(t)
+		case "backend.s3.endpoint":
+			defaults.Backend.S3.Endpoint, err = flags.GetString(flag.Name)
+		case "backend.s3.region":
+			defaults.Backend.S3.Region, err = flags.GetString(flag.Name)
+		case "backend.s3.bucket":
+			defaults.Backend.S3.Bucket, err = flags.GetString(flag.Name)
+		case "backend.s3.prefix":
+			defaults.Backend.S3.Prefix, err = flags.GetString(flag.Name)
+		case "backend.s3.accessKey":
+			defaults.Backend.S3.AccessKey, err = flags.GetString(flag.Name)
+		case "backend.s3.secretKey":
+			defaults.Backend.S3.SecretKey, err = flags.GetString(flag.Name)
+		case "backend.s3.pathStyle":
+			defaults.Backend.S3.PathStyle, err = flags.GetBool(flag.Name)
+		case "mount":
+			var values []string
+			values, err = flags.GetStringArray(flag.Name)
+			if err == nil {
+				defaults.Mounts, err = parseMounts(values)
+			}
 		}
 
 		if err != nil {
@@ -164,5 +268,9 @@ func getUserDefaults(flags *pflag.FlagSet, defaults *settings.UserDefaults, all
 		flags.Visit(visit)
 	}
 
+	if err := defaults.Backend.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
 	return errors.Join(errs...)
 }
@@ -8,6 +8,7 @@ import (
 
 func init() {
 	usersCmd.AddCommand(usersAddCmd)
+	usersAddCmd.Flags().StringSlice("groups", nil, "names or ids of the groups of the user")
 	addUserFlags(usersAddCmd.Flags())
 }
 
@@ -54,6 +55,15 @@ var usersAddCmd = &cobra.Command{
 
 		s.Defaults.Apply(user)
 
+		groupNames, err := flags.GetStringSlice("groups")
+		if err != nil {
+			return err
+		}
+		user.Groups, err = getGroupIDs(st, groupNames)
+		if err != nil {
+			return err
+		}
+
 		servSettings, err := st.Settings.GetServer()
 		if err != nil {
 			return err
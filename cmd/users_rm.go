@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 func init() {
@@ -17,17 +19,27 @@ var usersRmCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: withStore(func(_ *cobra.Command, args []string, st *store) error {
 		username, id := parseUsernameOrID(args[0])
+		var user *users.User
 		var err error
 
 		if username != "" {
-			err = st.Users.Delete(username)
+			user, err = st.Users.Get("", username)
 		} else {
-			err = st.Users.Delete(id)
+			user, err = st.Users.Get("", id)
 		}
-
 		if err != nil {
 			return err
 		}
+
+		if err := st.Users.Delete(user.ID); err != nil {
+			return err
+		}
+		if err := st.Tokens.DeleteByUserID(user.ID); err != nil {
+			return err
+		}
+		if err := st.Sessions.DeleteByUserID(user.ID); err != nil {
+			return err
+		}
 		fmt.Println("user deleted successfully")
 		return nil
 	}, storeOptions{}),
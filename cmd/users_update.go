@@ -12,6 +12,8 @@ func init() {
 
 	usersUpdateCmd.Flags().StringP("password", "p", "", "new password")
 	usersUpdateCmd.Flags().StringP("username", "u", "", "new username")
+	usersUpdateCmd.Flags().Bool("reset-2fa", false, "disable the two-factor authentication of the user")
+	usersUpdateCmd.Flags().StringSlice("groups", nil, "names or ids of the groups of the user, replacing the current ones")
 	addUserFlags(usersUpdateCmd.Flags())
 }
 
@@ -55,6 +57,9 @@ options you want to change.`,
 			Scope:                 user.Scope,
 			TmpDir:                user.TmpDir,
 			TrashDir:              user.TrashDir,
+			VersionsDir:           user.VersionsDir,
+			VersionsMaxCount:      user.VersionsMaxCount,
+			VersionsMaxAge:        user.VersionsMaxAge,
 			Locale:                user.Locale,
 			ViewMode:              user.ViewMode,
 			SingleClick:           user.SingleClick,
@@ -62,6 +67,9 @@ options you want to change.`,
 			Perm:                  user.Perm,
 			Sorting:               user.Sorting,
 			Commands:              user.Commands,
+			Backend:               user.Backend,
+			Mounts:                user.Mounts,
+			Quota:                 user.Quota,
 		}
 
 		err = getUserDefaults(flags, &defaults, false)
@@ -72,6 +80,9 @@ options you want to change.`,
 		user.Scope = defaults.Scope
 		user.TmpDir = defaults.TmpDir
 		user.TrashDir = defaults.TrashDir
+		user.VersionsDir = defaults.VersionsDir
+		user.VersionsMaxCount = defaults.VersionsMaxCount
+		user.VersionsMaxAge = defaults.VersionsMaxAge
 		user.Locale = defaults.Locale
 		user.ViewMode = defaults.ViewMode
 		user.SingleClick = defaults.SingleClick
@@ -79,6 +90,9 @@ options you want to change.`,
 		user.Perm = defaults.Perm
 		user.Commands = defaults.Commands
 		user.Sorting = defaults.Sorting
+		user.Backend = defaults.Backend
+		user.Mounts = defaults.Mounts
+		user.Quota = defaults.Quota
 		user.LockPassword, err = flags.GetBool("lockPassword")
 		if err != nil {
 			return err
@@ -105,6 +119,25 @@ options you want to change.`,
 			}
 		}
 
+		if flags.Changed("groups") {
+			groupNames, err := flags.GetStringSlice("groups")
+			if err != nil {
+				return err
+			}
+			user.Groups, err = getGroupIDs(st, groupNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		reset2FA, err := flags.GetBool("reset-2fa")
+		if err != nil {
+			return err
+		}
+		if reset2FA {
+			user.ResetTwoFactor()
+		}
+
 		err = st.Users.Update(user)
 		if err != nil {
 			return err
@@ -12,6 +12,8 @@ var (
 	ErrEmptyPassword            = errors.New("password is empty")
 	ErrEasyPassword             = errors.New("password is too easy")
 	ErrEmptyUsername            = errors.New("username is empty")
+	ErrEmptyGroupName           = errors.New("group name is empty")
+	ErrEmptyTokenName           = errors.New("token name is empty")
 	ErrEmptyRequest             = errors.New("empty request")
 	ErrScopeIsRelative          = errors.New("scope is a relative path")
 	ErrInvalidDataType          = errors.New("invalid data type")
@@ -24,6 +26,16 @@ var (
 	ErrRootUserDeletion         = errors.New("the sole admin can't be deleted")
 	ErrCurrentPasswordIncorrect = errors.New("the current password is incorrect")
 	ErrShareRequiresDownload    = errors.New("permission to share requires permission to download")
+	ErrTwoFactorRequired        = errors.New("two-factor authentication must be set up")
+	ErrInvalidTwoFactorCode     = errors.New("the two-factor authentication code is incorrect")
+	ErrQuotaExceeded            = errors.New("the storage quota is exceeded")
+	ErrLockedOut                = errors.New("too many failed login attempts")
+	ErrUploadTooLarge           = errors.New("the file is too large")
+	ErrUploadTypeNotAllowed     = errors.New("the file type is not allowed")
+	ErrUploadLimitReached       = errors.New("the upload limit of the share is reached")
+	ErrShareExhausted           = errors.New("the share reached its maximum number of downloads")
+	ErrInvalidSlug              = errors.New("the slug must have 3 to 64 letters, digits, dashes or underscores")
+	ErrTooManyJobs              = errors.New("too many jobs are running")
 )
 
 type ErrShortPassword struct {
@@ -2,6 +2,7 @@ package fileutils
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"syscall"
 
 	"github.com/spf13/afero"
 )
@@ -17,12 +19,22 @@ import (
 // By default the rename filesystem system call is used. If src and dst point to different volumes
 // the file copy is used as a fallback
 func MoveFile(afs afero.Fs, src, dst string, fileMode, dirMode fs.FileMode) error {
-	if afs.Rename(src, dst) == nil {
+	renameErr := afs.Rename(src, dst)
+	if renameErr == nil {
 		return nil
 	}
 
 	if info, err := afs.Stat(src); err == nil && info.IsDir() {
-		return CopyFolder(afs, src, dst)
+		if !errors.Is(renameErr, syscall.EXDEV) {
+			// The destination might exist, in which case it's replaced.
+			return CopyFolder(afs, src, dst)
+		}
+		// The destination is in another device or mount point, so the
+		// directory is copied and then removed.
+		if err := CopyDir(afs, src, dst, fileMode, dirMode); err != nil {
+			return err
+		}
+		return afs.RemoveAll(src)
 	}
 
 	// fallback
@@ -1,6 +1,14 @@
 package fileutils
 
-import "testing"
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+)
 
 func TestCommonPrefix(t *testing.T) {
 	testCases := map[string]struct {
@@ -44,3 +52,53 @@ func TestCommonPrefix(t *testing.T) {
 		})
 	}
 }
+
+type renameErrFs struct {
+	afero.Fs
+	err error
+}
+
+func (f renameErrFs) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: f.err}
+}
+
+func TestMoveFolder(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		renameErr error
+		moved     bool
+	}{
+		"another mount point": {
+			renameErr: syscall.EXDEV,
+			moved:     true,
+		},
+		"permission denied": {
+			renameErr: fs.ErrPermission,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := renameErrFs{Fs: afero.NewMemMapFs(), err: tc.renameErr}
+			_ = afero.WriteFile(afs, "/src/a.txt", []byte("content"), 0644)
+
+			err := MoveFile(afs, "/src", "/dst", 0644, 0755)
+			if tc.moved && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.moved && !errors.Is(err, tc.renameErr) {
+				t.Fatalf("expected error %v, got %v", tc.renameErr, err)
+			}
+
+			if _, err := afs.Stat("/src/a.txt"); tc.moved == (err == nil) {
+				t.Errorf("expected the source to be moved: %v, got error %v", tc.moved, err)
+			}
+			if _, err := afs.Stat("/dst/a.txt"); tc.moved != (err == nil) {
+				t.Errorf("expected the destination to exist: %v, got error %v", tc.moved, err)
+			}
+		})
+	}
+}
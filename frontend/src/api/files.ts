@@ -1,6 +1,7 @@
 import { useAuthStore } from "@/stores/auth";
 import { useLayoutStore } from "@/stores/layout";
 import { baseURL } from "@/utils/constants";
+import * as jobs from "./jobs";
 import { upload as postTus, useTus } from "./tus";
 import { createURL, fetchURL, removePrefix, StatusError } from "./utils";
 import { isEncodableResponse, makeRawResource } from "@/utils/encodings";
@@ -228,14 +229,16 @@ export async function archive(
     url += `algo=${format}&`;
   }
 
-  return post(url);
+  const res = await post(url);
+  return jobs.wait(JSON.parse(res as string));
 }
 
 export async function unarchive(path: string, name: string, override: boolean) {
   const to = removePrefix(name);
   const action = `unarchive`;
   const url = `${path}?action=${action}&destination=${to}&override=${override}`;
-  return resourceAction(url, "PATCH");
+  const res = await resourceAction(url, "PATCH");
+  return jobs.wait(await res.json());
 }
 
 export async function chmod(
@@ -249,7 +252,8 @@ export async function chmod(
   if (recursive) {
     url += `&type=${recursionType}`;
   }
-  return resourceAction(url, "PATCH");
+  const res = await resourceAction(url, "PATCH");
+  return jobs.wait(await res.json());
 }
 
 export function getDownloadURL(file: ResourceItem, inline: any) {
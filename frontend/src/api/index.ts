@@ -2,9 +2,22 @@ import * as files from "./files";
 import * as share from "./share";
 import * as users from "./users";
 import * as quota from "./quota";
+import * as jobs from "./jobs";
 import * as settings from "./settings";
 import * as pub from "./pub";
+import * as twofactor from "./twofactor";
 import search from "./search";
 import commands from "./commands";
 
-export { files, share, users, quota, settings, pub, commands, search };
+export {
+  files,
+  share,
+  users,
+  quota,
+  jobs,
+  settings,
+  pub,
+  commands,
+  search,
+  twofactor,
+};
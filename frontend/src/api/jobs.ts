@@ -0,0 +1,26 @@
+import { fetchJSON, fetchURL } from "./utils";
+
+// How often the running jobs are polled, in milliseconds.
+const pollInterval = 1000;
+
+export async function get(id: string) {
+  return fetchJSON<IJob>(`/api/jobs/${id}`, {});
+}
+
+export async function cancel(id: string) {
+  await fetchURL(`/api/jobs/${id}`, { method: "DELETE" });
+}
+
+// wait polls a job started in the background until it finishes, and
+// throws if it didn't succeed.
+export async function wait(job: IJob) {
+  while (job.status === "running") {
+    await new Promise((resolve) => setTimeout(resolve, pollInterval));
+    job = await get(job.id);
+  }
+
+  if (job.status !== "done") {
+    throw new Error(job.error || job.status);
+  }
+  return job;
+}
@@ -1,5 +1,6 @@
+import * as tus from "tus-js-client";
 import { fetchURL, removePrefix, createURL } from "./utils";
-import { baseURL } from "@/utils/constants";
+import { baseURL, origin, tusSettings } from "@/utils/constants";
 
 export async function fetch(url: string, password: string = "") {
   url = removePrefix(url);
@@ -73,3 +74,52 @@ export function getDownloadURL(res: Resource, inline = false) {
 
   return createURL("api/public/dl/" + res.hash + res.path, params);
 }
+
+// getPreviewURL returns the URL of the resized preview of a shared
+// image, which the links to preview only still serve.
+export function getPreviewURL(res: Resource) {
+  const params = {
+    ...(res.token && { token: res.token }),
+  };
+
+  return createURL("api/public/preview/" + res.hash + res.path, params);
+}
+
+// upload uploads a file to a share which accepts uploads, into the
+// directory at path.
+export function upload(
+  hash: string,
+  path: string,
+  file: File,
+  password = "",
+  token = "",
+  onprogress?: (loaded: number) => void
+) {
+  const dir = (path.endsWith("/") ? path : path + "/")
+    .split("/")
+    .map(encodeURIComponent)
+    .join("/");
+  const query = token ? `?token=${token}` : "";
+  const endpoint = `${origin}${baseURL}/api/public/tus/${hash}${dir}${encodeURIComponent(file.name)}${query}`;
+
+  return new Promise<void>((resolve, reject) => {
+    const upload = new tus.Upload(file, {
+      endpoint,
+      chunkSize: tusSettings?.chunkSize,
+      parallelUploads: 1,
+      storeFingerprintForResuming: false,
+      headers: { "X-SHARE-PASSWORD": encodeURIComponent(password) },
+      onShouldRetry: () => false,
+      onError: (error: Error | tus.DetailedError) => {
+        const message =
+          error instanceof tus.DetailedError && error.originalResponse
+            ? error.originalResponse.getBody()
+            : error.message;
+        reject(new Error(message));
+      },
+      onProgress: (loaded) => onprogress?.(loaded),
+      onSuccess: () => resolve(),
+    });
+    upload.start();
+  });
+}
@@ -19,7 +19,12 @@ export async function create(
   url: string,
   password = "",
   expires = "",
-  unit = "hours"
+  unit = "hours",
+  upload: ShareUpload | null = null,
+  maxDownloads = 0,
+  paths: string[] = [],
+  slug = "",
+  perm: SharePermissions | null = null
 ) {
   url = removePrefix(url);
   url = `/api/share${url}`;
@@ -27,11 +32,25 @@ export async function create(
     url += `?expires=${expires}&unit=${unit}`;
   }
   let body = "{}";
-  if (password != "" || expires !== "" || unit !== "hours") {
+  if (
+    password != "" ||
+    expires !== "" ||
+    unit !== "hours" ||
+    upload ||
+    maxDownloads ||
+    paths.length > 0 ||
+    slug !== "" ||
+    perm
+  ) {
     body = JSON.stringify({
       password: password,
       expires: expires.toString(), // backend expects string not number
       unit: unit,
+      ...(upload ? { upload } : {}),
+      ...(maxDownloads ? { maxDownloads } : {}),
+      ...(paths.length > 0 ? { paths } : {}),
+      ...(slug !== "" ? { slug } : {}),
+      ...(perm ? { perm } : {}),
     });
   }
   return fetchJSON(url, {
@@ -40,6 +59,13 @@ export async function create(
   });
 }
 
+export async function update(hash: string, body: ShareUpdate) {
+  return fetchJSON<Share>(`/api/share/${hash}`, {
+    method: "PUT",
+    body: JSON.stringify(body),
+  });
+}
+
 export function getShareURL(share: Share) {
   return createURL("share/" + share.hash, {});
 }
@@ -0,0 +1,28 @@
+import { fetchJSON, fetchURL } from "./utils";
+
+export async function setup() {
+  return await fetchJSON<ITwoFactorSetup>(`/api/2fa/setup`, {
+    method: "POST",
+  });
+}
+
+export async function enable(code: string) {
+  return await fetchJSON<IRecoveryCodes>(`/api/2fa/enable`, {
+    method: "POST",
+    body: JSON.stringify({ code }),
+  });
+}
+
+export async function regenerateRecoveryCodes(code: string) {
+  return await fetchJSON<IRecoveryCodes>(`/api/2fa/recovery-codes`, {
+    method: "POST",
+    body: JSON.stringify({ code }),
+  });
+}
+
+export async function disable(code: string) {
+  await fetchURL(`/api/2fa`, {
+    method: "DELETE",
+    body: JSON.stringify({ code }),
+  });
+}
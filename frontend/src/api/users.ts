@@ -53,3 +53,14 @@ export async function remove(
     }),
   });
 }
+
+export async function getSessions(id: number) {
+  return fetchJSON<ISession[]>(`/api/users/${id}/sessions`, {});
+}
+
+export async function revokeSessions(id: number, session = "") {
+  const path = session
+    ? `/api/users/${id}/sessions/${encodeURIComponent(session)}`
+    : `/api/users/${id}/sessions`;
+  await fetchURL(path, { method: "DELETE" });
+}
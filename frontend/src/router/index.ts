@@ -14,8 +14,13 @@ import Errors from "@/views/Errors.vue";
 import { useAuthStore } from "@/stores/auth";
 import { baseURL, name } from "@/utils/constants";
 import i18n from "@/i18n";
-import { recaptcha, loginPage } from "@/utils/constants";
-import { login, validateLogin } from "@/utils/auth";
+import {
+  recaptcha,
+  loginPage,
+  authMethod,
+  twoFactorRequired,
+} from "@/utils/constants";
+import { login, oidcLogin, validateLogin } from "@/utils/auth";
 
 const titles = {
   Login: "sidebar.login",
@@ -153,6 +158,8 @@ const routes = [
 async function initAuth() {
   if (loginPage) {
     await validateLogin();
+  } else if (authMethod === "oidc") {
+    await oidcLogin();
   } else {
     await login("", "", "");
   }
@@ -207,6 +214,15 @@ router.beforeResolve(async (to, from, next) => {
       return;
     }
 
+    if (
+      twoFactorRequired &&
+      authStore.user?.totpEnabled === false &&
+      to.path !== "/settings/profile"
+    ) {
+      next({ path: "/settings/profile" });
+      return;
+    }
+
     if (to.matched.some((record) => record.meta.requiresAdmin)) {
       if (authStore.user === null || !authStore.user.perm.admin) {
         next({ path: "/403" });
@@ -27,6 +27,40 @@ interface Share {
   userID?: number;
   token?: string;
   username?: string;
+  upload?: ShareUpload;
+  maxDownloads?: number;
+  downloads?: number;
+  accesses?: ShareAccess[];
+  paths?: string[];
+  perm?: SharePermissions;
+}
+
+interface SharePermissions {
+  download: boolean;
+  archive: boolean;
+}
+
+interface ShareUpdate {
+  password?: string;
+  expires?: string;
+  unit?: string;
+  maxDownloads?: number;
+  perm?: SharePermissions;
+}
+
+interface ShareAccess {
+  time: number;
+  ip: string;
+  file: string;
+  download: boolean;
+}
+
+interface ShareUpload {
+  writeOnly: boolean;
+  maxSize: number;
+  types: string[];
+  maxFiles: number;
+  count?: number;
 }
 
 interface SearchParams {
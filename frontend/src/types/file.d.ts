@@ -19,6 +19,8 @@ interface Resource extends ResourceBase {
   sorting: Sorting;
   hash?: string;
   token?: string;
+  upload?: ShareUpload;
+  perm?: SharePermissions;
   index: number;
   subtitles?: string[];
   content?: string;
@@ -0,0 +1,15 @@
+type JobStatus = "running" | "done" | "failed" | "canceled";
+
+interface IJob {
+  id: string;
+  userID: number;
+  action: string;
+  source: string;
+  destination?: string;
+  status: JobStatus;
+  error?: string;
+  files: number;
+  bytes: number;
+  created: number;
+  updated: number;
+}
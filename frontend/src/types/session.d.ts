@@ -0,0 +1,8 @@
+interface ISession {
+  id: string;
+  userID: number;
+  created: number;
+  expires: number;
+  ip: string;
+  userAgent: string;
+}
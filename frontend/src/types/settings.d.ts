@@ -2,6 +2,7 @@ interface ISettings {
   signup: boolean;
   createUserDir: boolean;
   hideLoginButton: boolean;
+  twoFactorRequired: boolean;
   minimumPasswordLength: number;
   userHomeBasePath: string;
   defaults: SettingsDefaults;
@@ -9,6 +10,8 @@ interface ISettings {
   rules: any[];
   branding: SettingsBranding;
   tus: SettingsTus;
+  limits: SettingsLimits;
+  lockout: SettingsLockout;
   shell: string[];
   commands: SettingsCommand;
 }
@@ -41,6 +44,18 @@ interface SettingsTus {
   retryCount: number;
 }
 
+interface SettingsLimits {
+  userBandwidth: number;
+  shareBandwidth: number;
+  loginRate: number;
+  publicRate: number;
+}
+
+interface SettingsLockout {
+  attempts: number;
+  duration: string;
+}
+
 interface SettingsCommand {
   after_copy?: string[];
   after_delete?: string[];
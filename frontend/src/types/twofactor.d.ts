@@ -0,0 +1,8 @@
+interface ITwoFactorSetup {
+  secret: string;
+  uri: string;
+}
+
+interface IRecoveryCodes {
+  recoveryCodes: string[];
+}
@@ -15,6 +15,7 @@ interface IUser {
   viewMode: ViewModeType;
   sorting?: Sorting;
   aceEditorTheme: string;
+  totpEnabled?: boolean;
 }
 
 type ViewModeType = "list" | "mosaic" | "mosaic gallery";
@@ -60,6 +61,9 @@ interface IRule {
   path: string;
   regex: boolean;
   regexp: IRegexp;
+  glob?: IRegexp;
+  grant?: string[];
+  revoke?: string[];
 }
 
 interface IRegexp {
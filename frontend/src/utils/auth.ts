@@ -57,9 +57,10 @@ export async function validateLogin() {
 export async function login(
   username: string,
   password: string,
-  recaptcha: string
+  recaptcha: string,
+  otp = ""
 ) {
-  const data = { username, password, recaptcha };
+  const data = { username, password, recaptcha, otp };
 
   const res = await fetch(`${baseURL}/api/login`, {
     method: "POST",
@@ -101,6 +102,28 @@ export async function renew(jwt: string) {
   }
 }
 
+// oidcLogin renews the token set by the OpenID Connect callback or, if
+// there is none, redirects to the provider.
+export async function oidcLogin() {
+  const cookie = document.cookie
+    .split("; ")
+    .find((c) => c.startsWith("auth="));
+  const jwt = cookie?.substring("auth=".length) || localStorage.getItem("jwt");
+
+  if (jwt) {
+    try {
+      await renew(jwt);
+      return;
+    } catch (error) {
+      console.warn("Invalid JWT token, logging in again");
+    }
+  }
+
+  window.location.href = `${baseURL}/api/auth/oidc/login`;
+  // Never resolves, the page is being left.
+  await new Promise(() => {});
+}
+
 export async function signup(username: string, password: string) {
   const data = { username, password };
 
@@ -129,14 +152,28 @@ export function logout(reason?: string) {
     });
   }
 
+  const authStore = useAuthStore();
+
+  // The session is revoked, so that the token can't be used anymore.
+  if (authStore.jwt) {
+    fetch(`${baseURL}/api/logout`, {
+      method: "POST",
+      headers: { "X-Auth": authStore.jwt },
+      keepalive: true,
+    }).catch(() => {
+      console.warn("Failed to revoke the session");
+    });
+  }
+
   document.cookie = "auth=; Max-Age=0; Path=/; SameSite=Strict;";
 
-  const authStore = useAuthStore();
   authStore.clearUser();
 
   localStorage.setItem("jwt", "");
   if (noAuth) {
     window.location.reload();
+  } else if (authMethod === "oidc" && logoutPage === "/login") {
+    window.location.href = `${baseURL}/`;
   } else if (logoutPage !== "/login") {
     document.location.href = `${logoutPage}`;
   } else {
@@ -24,6 +24,7 @@ const tusSettings = window.FileBrowser.TusSettings;
 const origin = window.location.origin;
 const tusEndpoint = `/api/tus`;
 const hideLoginButton = window.FileBrowser.HideLoginButton;
+const twoFactorRequired: boolean = window.FileBrowser.TwoFactorRequired;
 
 export {
   name,
@@ -51,4 +52,5 @@ export {
   origin,
   tusEndpoint,
   hideLoginButton,
+  twoFactorRequired,
 };
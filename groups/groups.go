@@ -0,0 +1,61 @@
+// Package groups implements groups of users which share the same
+// permissions, rules and commands.
+package groups
+
+import (
+	"slices"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// Group describes a group of users. Its permissions, rules and commands
+// are added to the ones of its members. Its quota applies to the members
+// which don't have their own, the largest one winning.
+type Group struct {
+	ID       uint              `storm:"id,increment" json:"id"`
+	Name     string            `storm:"unique" json:"name"`
+	Perm     users.Permissions `json:"perm"`
+	Rules    []rules.Rule      `json:"rules"`
+	Commands []string          `json:"commands"`
+	Quota    users.Quota       `json:"quota"`
+}
+
+// GetRules implements rules.Provider.
+func (g *Group) GetRules() []rules.Rule {
+	return g.Rules
+}
+
+// Clean verifies that a group is alright to be saved.
+func (g *Group) Clean() error {
+	if g.Name == "" {
+		return fberrors.ErrEmptyGroupName
+	}
+	if g.Rules == nil {
+		g.Rules = []rules.Rule{}
+	}
+	if g.Commands == nil {
+		g.Commands = []string{}
+	}
+	return nil
+}
+
+// merge adds the permissions and commands of a group to a user. A
+// permission is granted if either the user or the group has it.
+func (g *Group) merge(u *users.User) {
+	u.Perm.Admin = u.Perm.Admin || g.Perm.Admin
+	u.Perm.Execute = u.Perm.Execute || g.Perm.Execute
+	u.Perm.Create = u.Perm.Create || g.Perm.Create
+	u.Perm.Rename = u.Perm.Rename || g.Perm.Rename
+	u.Perm.Modify = u.Perm.Modify || g.Perm.Modify
+	u.Perm.Delete = u.Perm.Delete || g.Perm.Delete
+	u.Perm.Share = u.Perm.Share || g.Perm.Share
+	u.Perm.Download = u.Perm.Download || g.Perm.Download
+
+	for _, cmd := range g.Commands {
+		if !slices.Contains(u.Commands, cmd) {
+			u.Commands = append(u.Commands, cmd)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package groups
+
+import (
+	"errors"
+	"slices"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// StorageBackend is the interface to implement for a groups storage.
+type StorageBackend interface {
+	GetBy(interface{}) (*Group, error)
+	Gets() ([]*Group, error)
+	Save(g *Group) error
+	DeleteByID(uint) error
+}
+
+// Storage is a groups storage.
+type Storage struct {
+	back  StorageBackend
+	users users.Store
+}
+
+// NewStorage creates a groups storage from a backend. The users store
+// is used to keep the membership of the users up to date.
+func NewStorage(back StorageBackend, userStore users.Store) *Storage {
+	return &Storage{back: back, users: userStore}
+}
+
+// Get gets a group by its id or name. The provided id must be a uint
+// for id lookup or a string for name lookup.
+func (s *Storage) Get(id interface{}) (*Group, error) {
+	return s.back.GetBy(id)
+}
+
+// Gets gets a list of all groups.
+func (s *Storage) Gets() ([]*Group, error) {
+	groups, err := s.back.Gets()
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Group{}, nil
+	}
+	return groups, err
+}
+
+// Save saves a group. The members of an existing group are marked as
+// updated so that they renew their tokens with the new permissions.
+func (s *Storage) Save(g *Group) error {
+	if err := g.Clean(); err != nil {
+		return err
+	}
+
+	if err := s.back.Save(g); err != nil {
+		return err
+	}
+
+	return s.updateMembers(g.ID, nil)
+}
+
+// Delete deletes a group by its id or name and removes its members
+// from it.
+func (s *Storage) Delete(id interface{}) error {
+	g, err := s.back.GetBy(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.back.DeleteByID(g.ID); err != nil {
+		return err
+	}
+
+	return s.updateMembers(g.ID, func(u *users.User) {
+		u.Groups = slices.DeleteFunc(u.Groups, func(gid uint) bool { return gid == g.ID })
+	})
+}
+
+// updateMembers updates the members of a group, after changing them
+// with fn if it isn't nil.
+func (s *Storage) updateMembers(id uint, fn func(*users.User)) error {
+	all, err := s.users.Gets("")
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, u := range all {
+		if !slices.Contains(u.Groups, id) {
+			continue
+		}
+		if fn != nil {
+			fn(u)
+		}
+		if err := s.users.Update(u, "Groups"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply merges the permissions, rules, commands and quotas of the groups
+// of a user into it. The rules of the groups come before the ones of the
+// user, so that the latter take precedence. Missing groups are ignored.
+// The resulting user must not be saved.
+func (s *Storage) Apply(u *users.User) error {
+	if len(u.Groups) == 0 {
+		return nil
+	}
+
+	var groupRules []rules.Rule
+	var groupQuota users.Quota
+	for _, id := range u.Groups {
+		g, err := s.back.GetBy(id)
+		if errors.Is(err, fberrors.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		g.merge(u)
+		groupRules = append(groupRules, g.Rules...)
+		groupQuota.Max(g.Quota)
+	}
+
+	u.Rules = append(groupRules, u.Rules...)
+	u.Quota.Merge(groupQuota)
+	return nil
+}
@@ -37,7 +37,14 @@ func AlgoToExtension(algo string) (string, error) {
 	}
 }
 
-func Unarchive(ctx context.Context, src, dst string, afs afero.Fs, overwrite bool, dirMode fs.FileMode) error {
+// Unarchive extracts the archive src to dst. It fails with
+// fbErrors.ErrQuotaExceeded once the extracted files exceed the limits,
+// which are checked against the sizes in the headers of the archive
+// before each file is written, and against the written bytes after.
+func Unarchive(ctx context.Context, src, dst string, afs afero.Fs, overwrite bool, dirMode fs.FileMode, limits Limits, progress Progress) error {
+	progress = progressOrNoop(progress)
+	used := &budget{limits: limits}
+
 	reader, err := afs.Open(src)
 	if err != nil {
 		return fmt.Errorf("archive open: %w", err)
@@ -54,11 +61,30 @@ func Unarchive(ctx context.Context, src, dst string, afs afero.Fs, overwrite boo
 		fullpath := filepath.Join(dst, filepath.Clean(file.NameInArchive))
 
 		if file.IsDir() {
+			if FileExists(afs, fullpath) {
+				return nil
+			}
+			if err := used.add(0, 1); err != nil {
+				return err
+			}
 			return afs.MkdirAll(fullpath, file.Mode())
 		}
 
-		if !overwrite && FileExists(afs, fullpath) {
-			return fbErrors.ErrExist
+		// An overwritten file frees its space and inode.
+		var freed, inodes int64 = 0, 1
+		if info, err := afs.Stat(fullpath); err == nil {
+			if !overwrite {
+				return fbErrors.ErrExist
+			}
+			freed, inodes = info.Size(), 0
+		}
+		if err := used.check(max(file.Size(), 0)-freed, inodes); err != nil {
+			return err
+		}
+		// The size in the header can be wrong, so the bytes are counted
+		// as they're written.
+		if err := used.add(-freed, inodes); err != nil {
+			return err
 		}
 
 		if err := afs.MkdirAll(filepath.Dir(fullpath), dirMode); err != nil {
@@ -87,8 +113,13 @@ func Unarchive(ctx context.Context, src, dst string, afs afero.Fs, overwrite boo
 
 		defer dstFd.Close()
 
-		_, err = io.Copy(dstFd, srcFd)
-		return err
+		_, err = io.Copy(&progressWriter{w: &budgetWriter{w: dstFd, budget: used}, progress: progress}, srcFd)
+		if err != nil {
+			return err
+		}
+
+		progress.AddFiles(1)
+		return nil
 	}
 
 	if ex, ok := format.(archives.Extractor); ok {
@@ -98,7 +129,12 @@ func Unarchive(ctx context.Context, src, dst string, afs afero.Fs, overwrite boo
 	return fbErrors.ErrInvalidDataType
 }
 
-func Archive(ctx context.Context, afs afero.Fs, archive, algo string, filenames []string, dirMode fs.FileMode) error {
+// Archive writes the files to archive, with the extension of algo. It
+// fails with fbErrors.ErrQuotaExceeded once the archive exceeds the
+// space limit.
+func Archive(ctx context.Context, afs afero.Fs, archive, algo string, filenames []string, dirMode fs.FileMode, limits Limits, progress Progress) error {
+	progress = progressOrNoop(progress)
+
 	extension, err := AlgoToExtension(algo)
 	if err != nil {
 		return fbErrors.ErrInvalidRequestParams
@@ -136,7 +172,20 @@ func Archive(ctx context.Context, afs afero.Fs, archive, algo string, filenames
 
 	defer out.Close()
 
-	return archiver.Archive(ctx, out, fileInfos)
+	for i, info := range fileInfos {
+		open := info.Open
+		fileInfos[i].Open = func() (fs.File, error) {
+			f, err := open()
+			if err != nil {
+				return nil, err
+			}
+
+			progress.AddFiles(1)
+			return &progressFile{File: f, progress: progress}, nil
+		}
+	}
+
+	return archiver.Archive(ctx, &budgetWriter{w: out, budget: &budget{limits: limits}}, fileInfos)
 }
 
 func GatherFiles(afs afero.Fs, filenames []string) ([]archives.FileInfo, error) {
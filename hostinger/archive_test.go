@@ -2,10 +2,13 @@ package hostinger
 
 import (
 	"context"
+	"errors"
 	"slices"
 	"testing"
 
 	"github.com/spf13/afero"
+
+	fbErrors "github.com/filebrowser/filebrowser/v2/errors"
 )
 
 func TestAlgoToExtension(t *testing.T) {
@@ -83,7 +86,7 @@ func TestArchive(t *testing.T) {
 	archivePath := "/out/archive"
 	filenames := []string{"/data/a.txt", "/data/b.txt"}
 
-	if err := Archive(context.Background(), fs, archivePath, "zip", filenames, 0755); err != nil {
+	if err := Archive(context.Background(), fs, archivePath, "zip", filenames, 0755, Limits{}, nil); err != nil {
 		t.Fatalf("Archive failed: %v", err)
 	}
 
@@ -109,12 +112,12 @@ func TestUnarchive(t *testing.T) {
 
 	archivePath := "/archive"
 	filenames := []string{"/data/a.txt", "/data/b.txt", "/data/subdir"}
-	if err := Archive(context.Background(), fs, archivePath, "zip", filenames, 0755); err != nil {
+	if err := Archive(context.Background(), fs, archivePath, "zip", filenames, 0755, Limits{}, nil); err != nil {
 		t.Fatalf("Archive failed: %v", err)
 	}
 
 	destDir := "/extracted"
-	if err := Unarchive(context.Background(), archivePath+".zip", destDir, fs, true, 0755); err != nil {
+	if err := Unarchive(context.Background(), archivePath+".zip", destDir, fs, true, 0755, Limits{}, nil); err != nil {
 		t.Fatalf("Unarchive failed: %v", err)
 	}
 
@@ -134,3 +137,48 @@ func TestUnarchive(t *testing.T) {
 		}
 	}
 }
+
+func TestUnarchiveLimits(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		limits      Limits
+		expectedErr error
+	}{
+		"no limit":             {},
+		"within the limits":    {limits: Limits{Space: 2 << 20, Inodes: 3}},
+		"exceeding the space":  {limits: Limits{Space: 1 << 20}, expectedErr: fbErrors.ErrQuotaExceeded},
+		"exceeding the inodes": {limits: Limits{Inodes: 1}, expectedErr: fbErrors.ErrQuotaExceeded},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// The zeros are compressed to much less than the limits.
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "/data/a.bin", make([]byte, 1<<20), 0644)
+			_ = afero.WriteFile(fs, "/data/b.bin", make([]byte, 1<<20), 0644)
+			if err := Archive(context.Background(), fs, "/archive", "zip", []string{"/data"}, 0755, Limits{}, nil); err != nil {
+				t.Fatalf("Archive failed: %v", err)
+			}
+
+			err := Unarchive(context.Background(), "/archive.zip", "/extracted", fs, true, 0755, tc.limits, nil)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/data/a.bin", make([]byte, 1<<20), 0644)
+
+	err := Archive(context.Background(), fs, "/archive", "tar", []string{"/data/a.bin"}, 0755, Limits{Space: 1 << 19}, nil)
+	if !errors.Is(err, fbErrors.ErrQuotaExceeded) {
+		t.Fatalf("expected error %v, got %v", fbErrors.ErrQuotaExceeded, err)
+	}
+}
@@ -0,0 +1,54 @@
+package hostinger
+
+import (
+	"io"
+
+	fbErrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Limits bound the disk space, in bytes, and the number of inodes that
+// the operations can add, like what's left of the quota of a user. A
+// zero limit is no limit.
+type Limits struct {
+	Space  int64
+	Inodes int64
+}
+
+// budget is what's left of the limits while an operation runs.
+type budget struct {
+	limits        Limits
+	space, inodes int64
+}
+
+// check returns fbErrors.ErrQuotaExceeded if adding space bytes and
+// inodes would exceed the limits.
+func (b *budget) check(space, inodes int64) error {
+	if (b.limits.Space > 0 && b.space+space > b.limits.Space) ||
+		(b.limits.Inodes > 0 && b.inodes+inodes > b.limits.Inodes) {
+		return fbErrors.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// add counts space bytes and inodes, unless they exceed the limits.
+func (b *budget) add(space, inodes int64) error {
+	if err := b.check(space, inodes); err != nil {
+		return err
+	}
+	b.space += space
+	b.inodes += inodes
+	return nil
+}
+
+// budgetWriter counts the bytes written to a writer.
+type budgetWriter struct {
+	w      io.Writer
+	budget *budget
+}
+
+func (w *budgetWriter) Write(b []byte) (int, error) {
+	if err := w.budget.add(int64(len(b)), 0); err != nil {
+		return 0, err
+	}
+	return w.w.Write(b)
+}
@@ -0,0 +1,48 @@
+package hostinger
+
+import (
+	"io"
+	"io/fs"
+)
+
+// Progress receives the amount of work done by long running operations.
+type Progress interface {
+	AddFiles(n int64)
+	AddBytes(n int64)
+}
+
+type noopProgress struct{}
+
+func (noopProgress) AddFiles(int64) {}
+func (noopProgress) AddBytes(int64) {}
+
+func progressOrNoop(p Progress) Progress {
+	if p == nil {
+		return noopProgress{}
+	}
+	return p
+}
+
+// progressFile reports the bytes read from a file.
+type progressFile struct {
+	fs.File
+	progress Progress
+}
+
+func (f *progressFile) Read(b []byte) (int, error) {
+	n, err := f.File.Read(b)
+	f.progress.AddBytes(int64(n))
+	return n, err
+}
+
+// progressWriter reports the bytes written to a writer.
+type progressWriter struct {
+	w        io.Writer
+	progress Progress
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.progress.AddBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,67 @@
+package fbhttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/audit"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+const defaultAuditListLimit = 100
+
+var auditGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if d.store.Audit == nil {
+		return http.StatusNotFound, nil
+	}
+
+	query := r.URL.Query()
+	filter := &audit.Filter{
+		Username: query.Get("user"),
+		Action:   query.Get("action"),
+		Path:     query.Get("path"),
+		Limit:    defaultAuditListLimit,
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return http.StatusBadRequest, fberrors.ErrInvalidRequestParams
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.Since, err = parseAuditTime(query.Get("since")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if filter.Until, err = parseAuditTime(query.Get("until")); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	list, err := d.store.Audit.Find(filter)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, list)
+})
+
+// parseAuditTime parses a unix timestamp or an RFC 3339 date.
+func parseAuditTime(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return unix, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, fberrors.ErrInvalidRequestParams
+	}
+
+	return t.Unix(), nil
+}
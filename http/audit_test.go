@@ -0,0 +1,106 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/audit"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestAuditRecordsActions(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{
+		Username: "username",
+		Password: pwd,
+		Perm:     users.Permissions{Create: true, Delete: true, Rename: true},
+	}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/docs/a.txt", []byte("content"), 0644)
+	storage.Users = &customFSUser{
+		Store: storage.Users,
+		fs:    afero.NewBasePathFs(fs, "/"),
+	}
+
+	handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+	for _, req := range []struct{ method, path string }{
+		{"MKCOL", "/dav/new"},
+		{http.MethodDelete, "/dav/docs/a.txt"},
+		{http.MethodDelete, "/dav/missing.txt"},
+	} {
+		r, err := http.NewRequest(req.method, req.path, http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		r.SetBasicAuth("username", "password")
+		r.RemoteAddr = "10.0.0.1:1234"
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	testCases := map[string]struct {
+		filter   audit.Filter
+		expected []string
+	}{
+		"all entries, most recent first": {
+			expected: []string{"delete /missing.txt", "delete /docs/a.txt", "mkdir /new"},
+		},
+		"by action": {
+			filter:   audit.Filter{Action: "mkdir"},
+			expected: []string{"mkdir /new"},
+		},
+		"by path": {
+			filter:   audit.Filter{Path: "/docs"},
+			expected: []string{"delete /docs/a.txt"},
+		},
+		"by other user": {
+			filter:   audit.Filter{Username: "other"},
+			expected: []string{},
+		},
+		"limited": {
+			filter:   audit.Filter{Limit: 1},
+			expected: []string{"delete /missing.txt"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			list, err := storage.Audit.Find(&tc.filter)
+			if err != nil {
+				t.Fatalf("failed to find entries: %v", err)
+			}
+
+			got := make([]string, len(list))
+			for i, entry := range list {
+				got[i] = entry.Action + " " + entry.Path
+				if entry.Username != "username" || entry.IP != "10.0.0.1" {
+					t.Errorf("expected entries to record the user and IP, got %q and %q", entry.Username, entry.IP)
+				}
+			}
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("expected %v, got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package fbhttp
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -14,6 +16,7 @@ import (
 
 	fbAuth "github.com/filebrowser/filebrowser/v2/auth"
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/sessions"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
@@ -35,6 +38,7 @@ type userInfo struct {
 	DateFormat            bool              `json:"dateFormat"`
 	Username              string            `json:"username"`
 	AceEditorTheme        string            `json:"aceEditorTheme"`
+	TOTPEnabled           bool              `json:"totpEnabled"`
 }
 
 type authToken struct {
@@ -54,6 +58,10 @@ func (e extractor) ExtractToken(r *http.Request) (string, error) {
 		return token, nil
 	}
 
+	if token := bearerToken(r); strings.Count(token, ".") == 2 {
+		return token, nil
+	}
+
 	if r.Method == http.MethodGet {
 		cookie, _ := r.Cookie("auth")
 		if cookie != nil && strings.Count(cookie.Value, ".") == 2 {
@@ -81,7 +89,26 @@ func renewableErr(err error, d *data) bool {
 }
 
 func withUser(fn handleFunc) handleFunc {
+	return withAuthenticatedUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if twoFactorSetupRequired(d) {
+			return http.StatusForbidden, fberrors.ErrTwoFactorRequired
+		}
+
+		return fn(d.throttleUser(w, r), r, d)
+	})
+}
+
+// withAuthenticatedUser is like withUser but also lets through the users
+// that still have to set up the two-factor authentication.
+func withAuthenticatedUser(fn handleFunc) handleFunc {
 	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if secret := apiToken(r); secret != "" {
+			if status, err := authenticateToken(d, secret); status != 0 || err != nil {
+				return status, err
+			}
+			return fn(w, r, d)
+		}
+
 		keyFunc := func(_ *jwt.Token) (interface{}, error) {
 			return d.settings.Key, nil
 		}
@@ -93,6 +120,15 @@ func withUser(fn handleFunc) handleFunc {
 			return http.StatusUnauthorized, nil
 		}
 
+		// The revoked sessions are logged out right away.
+		d.session, err = d.store.Sessions.Get(tk.ID)
+		if errors.Is(err, fberrors.ErrNotExist) || (err == nil && d.session.UserID != tk.User.ID) {
+			return http.StatusUnauthorized, nil
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
 		expiresSoon := tk.ExpiresAt != nil && time.Until(tk.ExpiresAt.Time) < time.Hour
 		updated := tk.IssuedAt != nil && tk.IssuedAt.Unix() < d.store.Users.LastUpdate(tk.User.ID)
 
@@ -101,13 +137,44 @@ func withUser(fn handleFunc) handleFunc {
 		}
 
 		d.user, err = d.store.Users.Get(d.server.Root, tk.User.ID)
+		if errors.Is(err, fberrors.ErrNotExist) {
+			return http.StatusUnauthorized, nil
+		}
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
+
+		// The permissions, rules and commands of the groups of the user
+		// are merged for each request, so that changes apply right away.
+		if err := d.store.Groups.Apply(d.user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		// The sessions of a demoted user are revoked, rather than renewed
+		// with the remaining permissions.
+		if demoted(&tk.User.Perm, &d.user.Perm) {
+			if err := d.store.Sessions.DeleteByUserID(d.user.ID); err != nil {
+				return http.StatusInternalServerError, err
+			}
+			return http.StatusUnauthorized, nil
+		}
 		return fn(w, r, d)
 	}
 }
 
+// demoted reports whether a permission of a token was taken away from
+// its user since it was signed.
+func demoted(signed, current *users.Permissions) bool {
+	return (signed.Admin && !current.Admin) ||
+		(signed.Execute && !current.Execute) ||
+		(signed.Create && !current.Create) ||
+		(signed.Rename && !current.Rename) ||
+		(signed.Modify && !current.Modify) ||
+		(signed.Delete && !current.Delete) ||
+		(signed.Share && !current.Share) ||
+		(signed.Download && !current.Download)
+}
+
 func withAdmin(fn handleFunc) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		if !d.user.Perm.Admin {
@@ -125,11 +192,43 @@ func loginHandler(tokenExpireTime time.Duration) handleFunc {
 			return http.StatusInternalServerError, err
 		}
 
+		// The body is read again to get the two-factor authentication code.
+		var body []byte
+		if r.Body != nil {
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		// The attempt counts as failed unless it's released.
+		keys := lockoutKeys(d, r, loginUsername(body))
+		if status, err := reserveLockout(w, d, keys); status != 0 || err != nil {
+			return status, err
+		}
+
 		user, err := auther.Auth(r, d.store.Users, d.settings, d.server)
 		switch {
 		case errors.Is(err, os.ErrPermission):
 			return http.StatusForbidden, nil
 		case err != nil:
+			return http.StatusInternalServerError, errors.Join(err, releaseLockout(d, keys, ""))
+		}
+
+		status, err := checkTwoFactor(w, d, user, body)
+		if status == http.StatusForbidden {
+			return status, err
+		}
+		if status != 0 || err != nil {
+			return status, errors.Join(err, releaseLockout(d, keys, ""))
+		}
+
+		if err := releaseLockout(d, keys, user.Username); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if err := d.store.Groups.Apply(user); err != nil {
 			return http.StatusInternalServerError, err
 		}
 
@@ -206,13 +305,53 @@ var signupHandler = func(_ http.ResponseWriter, r *http.Request, d *data) (int,
 }
 
 func renewHandler(tokenExpireTime time.Duration) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withAuthenticatedUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		// The sessions have all the permissions of the user, so an API
+		// token, which may be scoped, can't be exchanged for one.
+		if d.token != nil {
+			return http.StatusForbidden, nil
+		}
+
 		w.Header().Set("X-Renew-Token", "false")
 		return printToken(w, r, d, d.user, tokenExpireTime)
 	})
 }
 
-func printToken(w http.ResponseWriter, _ *http.Request, d *data, user *users.User, tokenExpirationTime time.Duration) (int, error) {
+func printToken(w http.ResponseWriter, r *http.Request, d *data, user *users.User, tokenExpirationTime time.Duration) (int, error) {
+	signed, err := signToken(r, d, user, tokenExpirationTime)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	if _, err := w.Write([]byte(signed)); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// signToken signs a token of a user. It renews the current session if
+// it's the one of the user, and opens a new one otherwise.
+func signToken(r *http.Request, d *data, user *users.User, tokenExpirationTime time.Duration) (string, error) {
+	now := time.Now()
+	session := d.session
+	if session == nil || session.UserID != user.ID {
+		session = &sessions.Session{
+			UserID:    user.ID,
+			Expires:   now.Add(tokenExpirationTime).Unix(),
+			IP:        d.clientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+		if err := d.store.Sessions.Create(session); err != nil {
+			return "", err
+		}
+	} else {
+		session.Expires = now.Add(tokenExpirationTime).Unix()
+		if err := d.store.Sessions.Save(session); err != nil {
+			return "", err
+		}
+	}
+
 	claims := &authToken{
 		User: userInfo{
 			ID:                    user.ID,
@@ -227,23 +366,16 @@ func printToken(w http.ResponseWriter, _ *http.Request, d *data, user *users.Use
 			DateFormat:            user.DateFormat,
 			Username:              user.Username,
 			AceEditorTheme:        user.AceEditorTheme,
+			TOTPEnabled:           user.TOTPEnabled,
 		},
 		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExpirationTime)),
+			ID:        session.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenExpirationTime)),
 			Issuer:    "File Browser",
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(d.settings.Key)
-	if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	w.Header().Set("Content-Type", "text/plain")
-	if _, err := w.Write([]byte(signed)); err != nil {
-		return http.StatusInternalServerError, err
-	}
-	return 0, nil
+	return token.SignedString(d.settings.Key)
 }
@@ -0,0 +1,253 @@
+package fbhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/backend"
+	"github.com/filebrowser/filebrowser/v2/backend/s3/s3test"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestS3BackendWebDAV(t *testing.T) {
+	t.Parallel()
+
+	srv := s3test.NewServer("bucket")
+	t.Cleanup(srv.Close)
+
+	storage := newTestStorage(t)
+
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{
+		Username: "username",
+		Password: pwd,
+		Scope:    "/john",
+		Perm:     users.Permissions{Create: true, Rename: true, Modify: true, Delete: true, Download: true},
+		Backend: backend.Config{
+			Type: backend.TypeS3,
+			S3: backend.S3Config{
+				Endpoint:  srv.URL,
+				Bucket:    "bucket",
+				Prefix:    "users",
+				AccessKey: "access",
+				SecretKey: "secret",
+				PathStyle: true,
+			},
+		},
+	}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+
+	// The steps depend on each other, so they run in order.
+	steps := []struct {
+		name               string
+		method             string
+		path               string
+		header             map[string]string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+		expectedKeys       []string
+	}{
+		{
+			name:               "MKCOL creates a directory marker",
+			method:             "MKCOL",
+			path:               "/dav/docs",
+			expectedStatusCode: http.StatusCreated,
+			expectedKeys:       []string{"users/john/docs/"},
+		},
+		{
+			name:               "PUT uploads an object",
+			method:             http.MethodPut,
+			path:               "/dav/docs/a.txt",
+			body:               "hello world",
+			expectedStatusCode: http.StatusCreated,
+			expectedKeys:       []string{"users/john/docs/", "users/john/docs/a.txt"},
+		},
+		{
+			name:               "GET with a range",
+			method:             http.MethodGet,
+			path:               "/dav/docs/a.txt",
+			header:             map[string]string{"Range": "bytes=6-"},
+			expectedStatusCode: http.StatusPartialContent,
+			expectedBody:       "world",
+		},
+		{
+			name:               "MOVE renames the objects",
+			method:             "MOVE",
+			path:               "/dav/docs",
+			header:             map[string]string{"Destination": "/dav/moved"},
+			expectedStatusCode: http.StatusCreated,
+			expectedKeys:       []string{"users/john/moved/", "users/john/moved/a.txt"},
+		},
+		{
+			name:               "GET of a moved file",
+			method:             http.MethodGet,
+			path:               "/dav/moved/a.txt",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "hello world",
+		},
+		{
+			name:               "DELETE removes the objects",
+			method:             http.MethodDelete,
+			path:               "/dav/moved",
+			expectedStatusCode: http.StatusNoContent,
+			expectedKeys:       []string{},
+		},
+	}
+
+	for _, step := range steps {
+		req, err := http.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		if err != nil {
+			t.Fatalf("%s: failed to construct request: %v", step.name, err)
+		}
+		req.SetBasicAuth("username", "password")
+		for k, v := range step.header {
+			req.Header.Set(k, v)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		result := recorder.Result()
+		body, err := io.ReadAll(result.Body)
+		result.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to read body: %v", step.name, err)
+		}
+
+		if result.StatusCode != step.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got status code %d: %s", step.name, step.expectedStatusCode, result.StatusCode, body)
+		}
+		if step.expectedBody != "" && string(body) != step.expectedBody {
+			t.Errorf("%s: expected body %q, got %q", step.name, step.expectedBody, body)
+		}
+		if step.expectedKeys != nil {
+			if keys := srv.Keys(); !slices.Equal(keys, step.expectedKeys) {
+				t.Errorf("%s: expected keys %v, got %v", step.name, step.expectedKeys, keys)
+			}
+		}
+	}
+}
+
+func TestMountsWebDAV(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"home/file.txt":       "home",
+		"www/index.html":      "index",
+		"www/secret/key.txt":  "key",
+		"team/notes/todo.txt": "todo",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storage := newTestStorage(t)
+
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{
+		Username: "username",
+		Password: pwd,
+		Scope:    "/home",
+		Perm:     users.Permissions{Create: true, Modify: true, Download: true},
+		Mounts: []users.Mount{
+			{Path: "/www", Scope: "/www", Rules: []rules.Rule{{Path: "/secret"}}},
+			{Path: "/shared/team", Scope: "/team", ReadOnly: true},
+		},
+	}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	testCases := map[string]struct {
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+		expectedInBody     string
+	}{
+		"PROPFIND lists the mount points": {
+			method:             "PROPFIND",
+			path:               "/dav/",
+			expectedStatusCode: http.StatusMultiStatus,
+			expectedInBody:     "/dav/www/",
+		},
+		"GET of a mounted file": {
+			method:             http.MethodGet,
+			path:               "/dav/shared/team/notes/todo.txt",
+			expectedStatusCode: http.StatusOK,
+			expectedInBody:     "todo",
+		},
+		"GET denied by the rules of a mount, 403": {
+			method:             http.MethodGet,
+			path:               "/dav/www/secret/key.txt",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"PUT in a mount": {
+			method:             http.MethodPut,
+			path:               "/dav/www/new.html",
+			body:               "new",
+			expectedStatusCode: http.StatusCreated,
+		},
+		"PUT in a read-only mount, 403": {
+			method:             http.MethodPut,
+			path:               "/dav/shared/team/new.txt",
+			body:               "new",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			recorder := httptest.NewRecorder()
+			handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{Root: root})
+			handler.ServeHTTP(recorder, req)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+			body, err := io.ReadAll(result.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+
+			if result.StatusCode != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d: %s", tc.expectedStatusCode, result.StatusCode, body)
+			}
+			if tc.expectedInBody != "" && !strings.Contains(string(body), tc.expectedInBody) {
+				t.Errorf("expected body to contain %q, got %q", tc.expectedInBody, body)
+			}
+		})
+	}
+}
@@ -2,15 +2,22 @@ package fbhttp
 
 import (
 	"log"
+	"net"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/tomasen/realip"
 
+	"github.com/filebrowser/filebrowser/v2/audit"
 	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/runner"
+	"github.com/filebrowser/filebrowser/v2/sessions"
 	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/share"
 	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/tokens"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
 
@@ -22,29 +29,159 @@ type data struct {
 	server   *settings.Server
 	store    *storage.Storage
 	user     *users.User
-	raw      interface{}
+	// token is the personal API token of the request, if any.
+	token *tokens.Token
+	// session is the session of the request, if any.
+	session *sessions.Session
+	// link is the share link of a public request, if any.
+	link *share.Link
+	raw  interface{}
 }
 
 // Check implements rules.Checker.
 func (d *data) Check(path string) bool {
+	// The shares of several files only show these files.
+	if d.link != nil && !d.link.Includes(path) {
+		return false
+	}
+
 	if d.user.HideDotfiles && rules.MatchHidden(path) {
 		return false
 	}
 
 	allow := true
-	for _, rule := range d.settings.Rules {
-		if rule.Matches(path) {
+	d.eachRule(path, func(rule *rules.Rule, path string) {
+		if !rule.IsPerm() && rule.Matches(path) {
 			allow = rule.Allow
 		}
+	})
+
+	return allow
+}
+
+// CheckPerm implements rules.PermChecker. The permissions of the user
+// are the default ones, which the permission rules can change.
+func (d *data) CheckPerm(path string, perm rules.Perm) bool {
+	// The rules can't grant what the scope of the token doesn't.
+	if d.token != nil && !d.token.Allows(perm) {
+		return false
 	}
 
-	for _, rule := range d.user.Rules {
-		if rule.Matches(path) {
-			allow = rule.Allow
+	granted := d.user.Perm.Has(perm)
+	d.eachRule(path, func(rule *rules.Rule, path string) {
+		if rule.IsPerm() && rule.Matches(path) {
+			granted = rule.ApplyPerm(perm, granted)
+		}
+	})
+
+	return granted
+}
+
+// checkPermTree is like CheckPerm, but for a path and everything under
+// it, like when a directory is deleted or renamed. A rule which revokes
+// the permission under the path denies it, even if another rule grants
+// it back.
+func (d *data) checkPermTree(p string, perm rules.Perm) bool {
+	if !d.CheckPerm(p, perm) {
+		return false
+	}
+
+	revokes := func(rule *rules.Rule) bool {
+		return slices.Contains(rule.Revoke, perm)
+	}
+
+	for _, list := range [][]rules.Rule{d.settings.Rules, d.user.Rules} {
+		for i := range list {
+			if revokes(&list[i]) && list[i].Under(p) {
+				return false
+			}
 		}
 	}
 
-	return allow
+	dir := strings.TrimSuffix(p, "/") + "/"
+	for _, mount := range d.user.Mounts {
+		rel, inMount := mount.Rel(p)
+		for i := range mount.Rules {
+			rule := &mount.Rules[i]
+			if !revokes(rule) {
+				continue
+			}
+			if (!inMount && strings.HasPrefix(mount.Path, dir)) || (inMount && rule.Under(rel)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// eachRule calls fn with the rules which may apply to a path, from the
+// least to the most specific ones, along with the path relative to them.
+func (d *data) eachRule(path string, fn func(rule *rules.Rule, path string)) {
+	for i := range d.settings.Rules {
+		fn(&d.settings.Rules[i], path)
+	}
+
+	for i := range d.user.Rules {
+		fn(&d.user.Rules[i], path)
+	}
+
+	// The rules of a mount are relative to its mount point.
+	if mount, rel := d.user.MountOf(path); mount != nil {
+		for i := range mount.Rules {
+			fn(&mount.Rules[i], rel)
+		}
+	}
+}
+
+// audit records an action of the current user. The actions that go
+// through RunHook are recorded automatically.
+func (d *data) audit(r *http.Request, action, path, dst, target string, err error) {
+	d.auditUser(r, d.user, action, path, dst, target, err)
+}
+
+// clientIP returns the address of the client of a request. The headers
+// set by the proxies are only trusted if the server is configured to,
+// since the clients can forge them.
+func (d *data) clientIP(r *http.Request) string {
+	if d.server.TrustProxyHeaders {
+		// The private addresses of the headers are skipped.
+		if ip := realip.FromRequest(r); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (d *data) auditUser(r *http.Request, user *users.User, action, path, dst, target string, err error) {
+	if d.store.Audit == nil {
+		return
+	}
+
+	entry := &audit.Entry{
+		IP:          d.clientIP(r),
+		Action:      action,
+		Path:        path,
+		Destination: dst,
+		Target:      target,
+		Success:     err == nil,
+	}
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Username = user.Username
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if err := d.store.Audit.Save(entry); err != nil {
+		log.Printf("audit: failed to record %s on %s: %v", action, path, err)
+	}
 }
 
 func handle(fn handleFunc, prefix string, store *storage.Storage, server *settings.Server) http.Handler {
@@ -59,15 +196,20 @@ func handle(fn handleFunc, prefix string, store *storage.Storage, server *settin
 			return
 		}
 
-		status, err := fn(w, r, &data{
+		d := &data{
 			Runner:   &runner.Runner{Enabled: server.EnableExec, Settings: settings},
 			store:    store,
 			settings: settings,
 			server:   server,
-		})
+		}
+		d.Observer = func(evt, path, dst string, user *users.User, err error) {
+			d.auditUser(r, user, evt, path, dst, d.Share, err)
+		}
+
+		status, err := fn(w, r, d)
 
 		if status >= 400 || err != nil {
-			clientIP := realip.FromRequest(r)
+			clientIP := d.clientIP(r)
 			log.Printf("%s: %v %s %v", r.URL.Path, status, clientIP, err)
 		}
 
@@ -0,0 +1,102 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestCheckPerm(t *testing.T) {
+	t.Parallel()
+
+	d := &data{
+		settings: &settings.Settings{
+			Rules: []rules.Rule{{Regex: true, Regexp: &rules.Regexp{Raw: `\.lock$`}, Revoke: []rules.Perm{rules.PermDelete}}},
+		},
+		user: &users.User{
+			Perm: users.Permissions{Create: true, Modify: true, Delete: true, Download: true},
+			Rules: []rules.Rule{
+				{Path: "/logs", Revoke: []rules.Perm{rules.PermCreate, rules.PermModify, rules.PermDelete}},
+				{Path: "/logs/public", Grant: []rules.Perm{rules.PermShare}},
+				{Path: "/www/keep", Revoke: []rules.Perm{rules.PermDelete}},
+			},
+			Mounts: []users.Mount{
+				{Path: "/shared", Rules: []rules.Rule{{Path: "/docs", Revoke: []rules.Perm{rules.PermDownload}}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		path string
+		perm rules.Perm
+		want bool
+	}{
+		{"/www/index.html", rules.PermModify, true},
+		{"/logs/app.log", rules.PermModify, false},
+		{"/logs/app.log", rules.PermDownload, true},
+		{"/logs/app.log", rules.PermShare, false},
+		{"/logs/public/app.log", rules.PermShare, true},
+		{"/logsx/app.log", rules.PermModify, true},
+		{"/www/app.lock", rules.PermDelete, false},
+		{"/shared/docs/a.txt", rules.PermDownload, false},
+		{"/docs/a.txt", rules.PermDownload, true},
+	}
+
+	for _, tc := range cases {
+		if got := d.CheckPerm(tc.path, tc.perm); got != tc.want {
+			t.Errorf("CheckPerm(%q, %s) = %v; want %v", tc.path, tc.perm, got, tc.want)
+		}
+	}
+
+	// The rules of the files under a directory apply to the directory too.
+	d.settings.Rules = nil
+	treeCases := map[string]bool{
+		"/www":       false,
+		"/www/other": true,
+		"/":          false,
+		"/docs":      true,
+	}
+
+	for path, want := range treeCases {
+		if got := d.checkPermTree(path, rules.PermDelete); got != want {
+			t.Errorf("checkPermTree(%q, delete) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		trustProxyHeaders bool
+		forwardedFor      string
+		expected          string
+	}{
+		"remote address":                 {expected: "10.0.0.1"},
+		"ignores the untrusted headers":  {forwardedFor: "203.0.113.2", expected: "10.0.0.1"},
+		"reads the trusted headers":      {trustProxyHeaders: true, forwardedFor: "203.0.113.2", expected: "203.0.113.2"},
+		"no public address in headers":   {trustProxyHeaders: true, forwardedFor: "10.0.0.2", expected: "10.0.0.1"},
+		"trusted headers without header": {trustProxyHeaders: true, expected: "10.0.0.1"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.RemoteAddr = "10.0.0.1:1234"
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			d := &data{server: &settings.Server{TrustProxyHeaders: tc.trustProxyHeaders}}
+			if got := d.clientIP(req); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
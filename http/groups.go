@@ -0,0 +1,166 @@
+package fbhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/filebrowser/filebrowser/v2/auth"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/groups"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+type modifyGroupRequest struct {
+	modifyRequest
+	Data *groups.Group `json:"data"`
+}
+
+func getGroup(r *http.Request) (*modifyGroupRequest, error) {
+	if r.Body == nil {
+		return nil, fberrors.ErrEmptyRequest
+	}
+
+	req := &modifyGroupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, err
+	}
+
+	if req.What != "group" || req.Data == nil {
+		return nil, fberrors.ErrInvalidDataType
+	}
+
+	return req, nil
+}
+
+// withGroupAdmin is like withAdmin, but also checks the current password
+// of the admin, since groups change the permissions of their members.
+func withGroupAdmin(fn func(w http.ResponseWriter, r *http.Request, d *data, req *modifyGroupRequest) (int, error)) handleFunc {
+	return withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		req, err := getGroup(r)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if d.settings.AuthMethod == auth.MethodJSONAuth && !users.CheckPwd(req.CurrentPassword, d.user.Password) {
+			return http.StatusBadRequest, fberrors.ErrCurrentPasswordIncorrect
+		}
+
+		if req.Data.Perm.Share && !req.Data.Perm.Download {
+			return http.StatusBadRequest, fberrors.ErrShareRequiresDownload
+		}
+
+		return fn(w, r, d, req)
+	})
+}
+
+func getGroupID(r *http.Request) (uint, error) {
+	i, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, err
+	}
+	return uint(i), nil
+}
+
+var groupsGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	list, err := d.store.Groups.Gets()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	return renderJSON(w, r, list)
+})
+
+var groupGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getGroupID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	g, err := d.store.Groups.Get(id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, g)
+})
+
+var groupPostHandler = withGroupAdmin(func(w http.ResponseWriter, r *http.Request, d *data, req *modifyGroupRequest) (int, error) {
+	req.Data.ID = 0
+	err := d.store.Groups.Save(req.Data)
+	d.audit(r, "group_create", "", "", req.Data.Name, err)
+	switch {
+	case errors.Is(err, fberrors.ErrEmptyGroupName):
+		return http.StatusBadRequest, err
+	case err != nil:
+		return errToStatus(err), err
+	}
+
+	w.Header().Set("Location", "/settings/groups/"+strconv.FormatUint(uint64(req.Data.ID), 10))
+	return http.StatusCreated, nil
+})
+
+var groupPutHandler = withGroupAdmin(func(_ http.ResponseWriter, r *http.Request, d *data, req *modifyGroupRequest) (int, error) {
+	id, err := getGroupID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if req.Data.ID != id {
+		return http.StatusBadRequest, nil
+	}
+
+	if _, err := d.store.Groups.Get(id); err != nil {
+		return errToStatus(err), err
+	}
+
+	err = d.store.Groups.Save(req.Data)
+	d.audit(r, "group_update", "", "", req.Data.Name, err)
+	switch {
+	case errors.Is(err, fberrors.ErrEmptyGroupName):
+		return http.StatusBadRequest, err
+	case err != nil:
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
+
+var groupDeleteHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if r.Body == nil {
+		return http.StatusBadRequest, fberrors.ErrEmptyRequest
+	}
+
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if d.settings.AuthMethod == auth.MethodJSONAuth && !users.CheckPwd(body.CurrentPassword, d.user.Password) {
+		return http.StatusBadRequest, fberrors.ErrCurrentPasswordIncorrect
+	}
+
+	id, err := getGroupID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	err = d.store.Groups.Delete(id)
+	d.audit(r, "group_delete", "", "", strconv.FormatUint(uint64(id), 10), err)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
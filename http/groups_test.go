@@ -0,0 +1,160 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/groups"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestGroupsWebDAV(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method             string
+		path               string
+		groups             []string
+		expectedStatusCode int
+	}{
+		"GET without groups, 403": {
+			method:             http.MethodGet,
+			path:               "/dav/visible.txt",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"GET with download permission from a group": {
+			method:             http.MethodGet,
+			path:               "/dav/visible.txt",
+			groups:             []string{"readers"},
+			expectedStatusCode: http.StatusOK,
+		},
+		"GET denied by the rules of a group, 403": {
+			method:             http.MethodGet,
+			path:               "/dav/secret/file.txt",
+			groups:             []string{"readers"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"GET allowed by the rules of the user": {
+			method:             http.MethodGet,
+			path:               "/dav/secret/allowed.txt",
+			groups:             []string{"readers"},
+			expectedStatusCode: http.StatusOK,
+		},
+		"MKCOL with permissions merged from several groups": {
+			method:             "MKCOL",
+			path:               "/dav/new",
+			groups:             []string{"readers", "writers"},
+			expectedStatusCode: http.StatusCreated,
+		},
+		"MKCOL without create permission, 403": {
+			method:             "MKCOL",
+			path:               "/dav/new",
+			groups:             []string{"readers"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := newTestStorage(t)
+
+			for _, g := range []*groups.Group{
+				{Name: "readers", Perm: users.Permissions{Download: true}, Rules: []rules.Rule{{Path: "/secret"}}},
+				{Name: "writers", Perm: users.Permissions{Create: true}},
+			} {
+				if err := storage.Groups.Save(g); err != nil {
+					t.Fatalf("failed to save group: %v", err)
+				}
+			}
+
+			var ids []uint
+			for _, name := range tc.groups {
+				g, err := storage.Groups.Get(name)
+				if err != nil {
+					t.Fatalf("failed to get group: %v", err)
+				}
+				ids = append(ids, g.ID)
+			}
+
+			pwd, err := users.HashPwd("password")
+			if err != nil {
+				t.Fatalf("failed to hash password: %v", err)
+			}
+			if err := storage.Users.Save(&users.User{
+				Username: "username",
+				Password: pwd,
+				Groups:   ids,
+				Rules:    []rules.Rule{{Path: "/secret/allowed.txt", Allow: true}},
+			}); err != nil {
+				t.Fatalf("failed to save user: %v", err)
+			}
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "/visible.txt", []byte("content"), 0644)
+			_ = afero.WriteFile(fs, "/secret/file.txt", []byte("secret"), 0644)
+			_ = afero.WriteFile(fs, "/secret/allowed.txt", []byte("allowed"), 0644)
+
+			storage.Users = &customFSUser{
+				Store: storage.Users,
+				fs:    afero.NewBasePathFs(fs, "/"),
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			recorder := httptest.NewRecorder()
+			handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+			handler.ServeHTTP(recorder, req)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+			if result.StatusCode != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, result.StatusCode)
+			}
+		})
+	}
+}
+
+func TestGroupsDeleteMembership(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+
+	first, second := &groups.Group{Name: "first"}, &groups.Group{Name: "second"}
+	for _, g := range []*groups.Group{first, second} {
+		if err := storage.Groups.Save(g); err != nil {
+			t.Fatalf("failed to save group: %v", err)
+		}
+	}
+	if err := storage.Groups.Save(&groups.Group{Name: "first"}); err == nil {
+		t.Fatal("saved a group with a duplicated name")
+	}
+
+	if err := storage.Users.Save(&users.User{Username: "username", Password: "password", Groups: []uint{first.ID, second.ID}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	if err := storage.Groups.Delete("first"); err != nil {
+		t.Fatalf("failed to delete group: %v", err)
+	}
+
+	u, err := storage.Users.Get("", "username")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if !slices.Equal(u.Groups, []uint{second.ID}) {
+		t.Errorf("expected groups %v, got %v", []uint{second.ID}, u.Groups)
+	}
+}
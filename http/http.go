@@ -1,13 +1,19 @@
 package fbhttp
 
 import (
+	"context"
 	"io/fs"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"golang.org/x/net/webdav"
 
+	"github.com/filebrowser/filebrowser/v2/audit"
+	"github.com/filebrowser/filebrowser/v2/jobs"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/trash"
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 type modifyRequest struct {
@@ -16,7 +22,10 @@ type modifyRequest struct {
 	CurrentPassword string   `json:"current_password"` // Answer to: user logged password
 }
 
+// NewHandler returns the handler of the server. The background tasks
+// it starts, like the purges, stop when ctx is done.
 func NewHandler(
+	ctx context.Context,
 	imgSvc ImgService,
 	fileCache FileCache,
 	uploadCache UploadCache,
@@ -26,6 +35,26 @@ func NewHandler(
 ) (http.Handler, error) {
 	server.Clean()
 
+	jobManager, err := jobs.NewManager(ctx, store.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	jobManager.StartPurge(ctx, jobs.DefaultRetention)
+
+	trashManager := trash.NewManager(store.Trash)
+	trashManager.StartPurge(ctx, server.GetTrashRetention(trash.DefaultRetention), func(id uint) (*users.User, error) {
+		user, err := store.Users.Get(server.Root, id)
+		if err == nil {
+			// The purged items free space which isn't tracked.
+			store.Quotas.Reset(user)
+		}
+		return user, err
+	})
+
+	if store.Audit != nil {
+		store.Audit.StartPurge(ctx, audit.DefaultRetention)
+	}
+
 	r := mux.NewRouter()
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -41,14 +70,23 @@ func NewHandler(
 
 	r.HandleFunc("/health", healthHandler)
 	r.PathPrefix("/static").Handler(static)
+	r.PathPrefix(webdavPrefix).Handler(monkey(webdavHandler(webdav.NewMemLS()), ""))
 	r.NotFoundHandler = index
 
 	api := r.PathPrefix("/api").Subrouter()
 
 	tokenExpirationTime := server.GetTokenExpirationTime(DefaultTokenExpirationTime)
-	api.Handle("/login", monkey(loginHandler(tokenExpirationTime), ""))
+	loginRate := func(l *settings.Limits) uint { return l.LoginRate }
+	api.Handle("/login", monkey(withRateLimit("login", loginRate, loginHandler(tokenExpirationTime)), ""))
 	api.Handle("/signup", monkey(signupHandler, ""))
 	api.Handle("/renew", monkey(renewHandler(tokenExpirationTime), ""))
+	api.Handle("/logout", monkey(logoutHandler, "")).Methods("POST")
+	api.Handle("/2fa/setup", monkey(twoFactorSetupHandler, "")).Methods("POST")
+	api.Handle("/2fa/enable", monkey(twoFactorEnableHandler, "")).Methods("POST")
+	api.Handle("/2fa/recovery-codes", monkey(twoFactorRecoveryCodesHandler, "")).Methods("POST")
+	api.Handle("/2fa", monkey(twoFactorDisableHandler, "")).Methods("DELETE")
+	api.Handle("/auth/oidc/login", monkey(oidcLoginHandler, "")).Methods("GET")
+	api.Handle("/auth/oidc/callback", monkey(oidcCallbackHandler(tokenExpirationTime), "")).Methods("GET")
 
 	users := api.PathPrefix("/users").Subrouter()
 	users.Handle("", monkey(usersGetHandler, "")).Methods("GET")
@@ -56,28 +94,58 @@ func NewHandler(
 	users.Handle("/{id:[0-9]+}", monkey(userPutHandler, "")).Methods("PUT")
 	users.Handle("/{id:[0-9]+}", monkey(userGetHandler, "")).Methods("GET")
 	users.Handle("/{id:[0-9]+}", monkey(userDeleteHandler, "")).Methods("DELETE")
+	users.Handle("/{id:[0-9]+}/unlock", monkey(userUnlockHandler, "")).Methods("POST")
+	users.Handle("/{id:[0-9]+}/sessions", monkey(userSessionsGetHandler, "")).Methods("GET")
+	users.Handle("/{id:[0-9]+}/sessions", monkey(userSessionsDeleteHandler, "")).Methods("DELETE")
+	users.Handle("/{id:[0-9]+}/sessions/{sid}", monkey(userSessionDeleteHandler, "")).Methods("DELETE")
+
+	groups := api.PathPrefix("/groups").Subrouter()
+	groups.Handle("", monkey(groupsGetHandler, "")).Methods("GET")
+	groups.Handle("", monkey(groupPostHandler, "")).Methods("POST")
+	groups.Handle("/{id:[0-9]+}", monkey(groupPutHandler, "")).Methods("PUT")
+	groups.Handle("/{id:[0-9]+}", monkey(groupGetHandler, "")).Methods("GET")
+	groups.Handle("/{id:[0-9]+}", monkey(groupDeleteHandler, "")).Methods("DELETE")
 
 	api.PathPrefix("/resources").Handler(monkey(resourceGetHandler, "/api/resources")).Methods("GET")
-	api.PathPrefix("/resources").Handler(monkey(resourceDeleteHandler(fileCache), "/api/resources")).Methods("DELETE")
-	api.PathPrefix("/resources").Handler(monkey(resourcePostHandler(fileCache), "/api/resources")).Methods("POST")
+	api.PathPrefix("/resources").Handler(monkey(resourceDeleteHandler(fileCache, trashManager), "/api/resources")).Methods("DELETE")
+	api.PathPrefix("/resources").Handler(monkey(resourcePostHandler(fileCache, jobManager), "/api/resources")).Methods("POST")
 	api.PathPrefix("/resources").Handler(monkey(resourcePutHandler, "/api/resources")).Methods("PUT")
-	api.PathPrefix("/resources").Handler(monkey(resourcePatchHandler(fileCache), "/api/resources")).Methods("PATCH")
+	api.PathPrefix("/resources").Handler(monkey(resourcePatchHandler(fileCache, jobManager), "/api/resources")).Methods("PATCH")
 
 	api.PathPrefix("/tus").Handler(monkey(tusPostHandler(uploadCache), "/api/tus")).Methods("POST")
 	api.PathPrefix("/tus").Handler(monkey(tusHeadHandler(uploadCache), "/api/tus")).Methods("HEAD", "GET")
 	api.PathPrefix("/tus").Handler(monkey(tusPatchHandler(uploadCache), "/api/tus")).Methods("PATCH")
 	api.PathPrefix("/tus").Handler(monkey(tusDeleteHandler(uploadCache), "/api/tus")).Methods("DELETE")
 
+	api.Handle("/tokens", monkey(tokensGetHandler, "")).Methods("GET")
+	api.Handle("/tokens", monkey(tokenPostHandler, "")).Methods("POST")
+	api.Handle("/tokens/{id:[0-9]+}", monkey(tokenDeleteHandler, "")).Methods("DELETE")
+
+	api.Handle("/jobs", monkey(jobsListHandler(jobManager), "")).Methods("GET")
+	api.Handle("/jobs/{id}", monkey(jobGetHandler(jobManager), "")).Methods("GET")
+	api.Handle("/jobs/{id}", monkey(jobCancelHandler(jobManager), "")).Methods("DELETE")
+
+	api.Handle("/trash", monkey(trashListHandler(trashManager), "")).Methods("GET")
+	api.Handle("/trash", monkey(trashEmptyHandler(trashManager), "")).Methods("DELETE")
+	api.Handle("/trash/{id}/restore", monkey(trashRestoreHandler(trashManager), "")).Methods("POST")
+	api.Handle("/trash/{id}", monkey(trashDeleteHandler(trashManager), "")).Methods("DELETE")
+
+	api.PathPrefix("/versions").Handler(monkey(versionsGetHandler, "/api/versions")).Methods("GET")
+	api.PathPrefix("/versions").Handler(monkey(versionRestoreHandler, "/api/versions")).Methods("POST")
+
 	api.PathPrefix("/usage").Handler(monkey(diskUsage, "/api/usage")).Methods("GET")
 
 	api.Handle("/shares", monkey(shareListHandler, "")).Methods("GET")
 	api.PathPrefix("/share").Handler(monkey(shareGetsHandler, "/api/share")).Methods("GET")
 	api.PathPrefix("/share").Handler(monkey(sharePostHandler, "/api/share")).Methods("POST")
+	api.PathPrefix("/share").Handler(monkey(sharePutHandler, "/api/share")).Methods("PUT")
 	api.PathPrefix("/share").Handler(monkey(shareDeleteHandler, "/api/share")).Methods("DELETE")
 
 	api.Handle("/settings", monkey(settingsGetHandler, "")).Methods("GET")
 	api.Handle("/settings", monkey(settingsPutHandler, "")).Methods("PUT")
 
+	api.Handle("/audit", monkey(auditGetHandler, "")).Methods("GET")
+
 	api.Handle("/quota", monkey(quotaGetHandler, "")).Methods("GET")
 
 	api.PathPrefix("/raw").Handler(monkey(rawHandler, "/api/raw")).Methods("GET")
@@ -87,9 +155,14 @@ func NewHandler(
 	api.PathPrefix("/search").Handler(monkey(searchHandler, "/api/search")).Methods("GET")
 	api.PathPrefix("/subtitle").Handler(monkey(subtitleHandler, "/api/subtitle")).Methods("GET")
 
+	publicRate := func(l *settings.Limits) uint { return l.PublicRate }
 	public := api.PathPrefix("/public").Subrouter()
-	public.PathPrefix("/dl").Handler(monkey(publicDlHandler, "/api/public/dl/")).Methods("GET")
-	public.PathPrefix("/share").Handler(monkey(publicShareHandler, "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/dl").Handler(monkey(withRateLimit("public", publicRate, publicDlHandler), "/api/public/dl/")).Methods("GET")
+	public.PathPrefix("/preview").Handler(monkey(withRateLimit("public", publicRate, publicPreviewHandler(imgSvc, fileCache)), "/api/public/preview/")).Methods("GET")
+	public.PathPrefix("/share").Handler(monkey(withRateLimit("public", publicRate, publicShareHandler), "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/tus").Handler(monkey(withRateLimit("public", publicRate, withShareUpload(tusPost(uploadCache))), "/api/public/tus/")).Methods("POST")
+	public.PathPrefix("/tus").Handler(monkey(withRateLimit("public", publicRate, withShareUpload(tusHead(uploadCache))), "/api/public/tus/")).Methods("HEAD", "GET")
+	public.PathPrefix("/tus").Handler(monkey(withRateLimit("public", publicRate, withShareUpload(tusPatch(uploadCache))), "/api/public/tus/")).Methods("PATCH")
 
 	return stripPrefix(server.BaseURL, r), nil
 }
@@ -0,0 +1,94 @@
+package fbhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/jobs"
+)
+
+const defaultJobsListLimit = 50
+
+// runJob starts fn as a background job and returns the job to the
+// client straight away, so that the long operations don't outlive the
+// timeouts of the proxies. The job is polled at /api/jobs/{id}. The
+// clients can still wait for fn within the request with "async=false".
+func runJob(w http.ResponseWriter, r *http.Request, d *data, manager *jobs.Manager, job *jobs.Job, fn jobs.Func) (int, error) {
+	if r.URL.Query().Get("async") == "false" {
+		err := fn(r.Context(), &jobs.Progress{})
+		return errToStatus(err), err
+	}
+
+	job.UserID = d.user.ID
+	if err := manager.Start(job, fn); err != nil {
+		return errToStatus(err), err
+	}
+
+	w.Header().Set("Location", d.server.BaseURL+"/api/jobs/"+job.ID)
+	return renderJSON(w, r, job)
+}
+
+func withJob(manager *jobs.Manager, fn func(w http.ResponseWriter, r *http.Request, d *data, job *jobs.Job) (int, error)) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		job, err := manager.Get(mux.Vars(r)["id"])
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if job.UserID != d.user.ID && !d.user.Perm.Admin {
+			return http.StatusNotFound, nil
+		}
+
+		return fn(w, r, d, job)
+	})
+}
+
+func jobsListHandler(manager *jobs.Manager) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		limit := defaultJobsListLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 {
+				return http.StatusBadRequest, fberrors.ErrInvalidRequestParams
+			}
+		}
+
+		list, err := manager.FindByUserID(d.user.ID)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if len(list) > limit {
+			list = list[:limit]
+		}
+
+		return renderJSON(w, r, list)
+	})
+}
+
+func jobGetHandler(manager *jobs.Manager) handleFunc {
+	return withJob(manager, func(w http.ResponseWriter, r *http.Request, _ *data, job *jobs.Job) (int, error) {
+		return renderJSON(w, r, job)
+	})
+}
+
+func jobCancelHandler(manager *jobs.Manager) handleFunc {
+	return withJob(manager, func(_ http.ResponseWriter, _ *http.Request, _ *data, job *jobs.Job) (int, error) {
+		if job.Finished() {
+			return http.StatusConflict, nil
+		}
+
+		err := manager.Cancel(job.ID)
+		if errors.Is(err, fberrors.ErrNotExist) {
+			// The job finished in the meantime.
+			return http.StatusConflict, nil
+		}
+
+		return errToStatus(err), err
+	})
+}
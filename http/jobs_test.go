@@ -0,0 +1,101 @@
+package fbhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/jobs"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestRunJob(t *testing.T) {
+	t.Parallel()
+
+	st := newTestStorage(t)
+	user := &users.User{Username: "username", Password: "pw"}
+	if err := st.Users.Save(user); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	set, err := st.Settings.Get()
+	if err != nil {
+		t.Fatalf("failed to get settings: %v", err)
+	}
+	manager, err := jobs.NewManager(context.Background(), st.Jobs)
+	if err != nil {
+		t.Fatalf("failed to create jobs manager: %v", err)
+	}
+
+	testCases := map[string]struct {
+		query      string
+		background bool
+	}{
+		"Background by default": {"", true},
+		"Within the request":    {"?async=false", false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var ran atomic.Bool
+			release := make(chan struct{})
+			handler := withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+				return runJob(w, r, d, manager, &jobs.Job{Action: "chmod", Source: "/"}, func(_ context.Context, _ *jobs.Progress) error {
+					if tc.background {
+						<-release
+					}
+					ran.Store(true)
+					return nil
+				})
+			})
+
+			req, err := http.NewRequest(http.MethodPatch, "/"+tc.query, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			signed, err := signToken(req, &data{store: st, settings: set, server: &settings.Server{}}, user, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to sign token: %v", err)
+			}
+			req.Header.Set("X-Auth", signed)
+
+			recorder := httptest.NewRecorder()
+			handle(handler, "", st, &settings.Server{}).ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code 200, got status code %d", recorder.Code)
+			}
+
+			if !tc.background {
+				if !ran.Load() || recorder.Header().Get("Location") != "" {
+					t.Fatal("expected the job to run within the request")
+				}
+				return
+			}
+
+			// The job is still running when the request returns.
+			var job jobs.Job
+			if err := json.NewDecoder(recorder.Body).Decode(&job); err != nil {
+				t.Fatalf("failed to decode job: %v", err)
+			}
+			if ran.Load() || job.Status != jobs.StatusRunning || recorder.Header().Get("Location") != "/api/jobs/"+job.ID {
+				t.Fatalf("expected a running job, got %+v", job)
+			}
+
+			close(release)
+			manager.Wait(job.ID)
+			got, err := manager.Get(job.ID)
+			if err != nil {
+				t.Fatalf("failed to get job: %v", err)
+			}
+			if got.Status != jobs.StatusDone {
+				t.Errorf("expected the job to be done, got %s", got.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package fbhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/lockout"
+	"github.com/filebrowser/filebrowser/v2/settings"
+)
+
+// lockoutPolicy returns the lockout policy of the settings.
+func lockoutPolicy(d *data) lockout.Policy {
+	l := d.settings.Lockout
+	if l == nil {
+		l = settings.DefaultLockout()
+	}
+
+	return lockout.Policy{
+		Attempts: l.Attempts,
+		Duration: l.GetDuration(),
+	}
+}
+
+// lockoutKeys returns the keys of the failed attempts of a login
+// request: its client address and, if it has one, its username.
+func lockoutKeys(d *data, r *http.Request, username string) []string {
+	keys := []string{lockout.AddrKey(d.clientIP(r))}
+	if username != "" {
+		keys = append(keys, lockout.UserKey(username))
+	}
+
+	return keys
+}
+
+// loginUsername returns the username of the body of a login request.
+func loginUsername(body []byte) string {
+	var cred struct {
+		Username string `json:"username"`
+	}
+	_ = json.Unmarshal(body, &cred)
+	return cred.Username
+}
+
+// reserveLockout refuses the login requests which come too soon after
+// failed ones, with a 429 response telling when to retry. The allowed
+// ones count as failed until releaseLockout is called.
+func reserveLockout(w http.ResponseWriter, d *data, keys []string) (int, error) {
+	wait, err := d.store.Lockouts.Reserve(lockoutPolicy(d), keys...)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return http.StatusTooManyRequests, fberrors.ErrLockedOut
+}
+
+// releaseLockout forgets the failed attempt reserved for a login request
+// which didn't fail. If it succeeded, the attempts of its username are
+// reset. Those of the address are kept, so that a valid login doesn't
+// let it try other usernames.
+func releaseLockout(d *data, keys []string, username string) error {
+	userKey := lockout.UserKey(username)
+	var errs []error
+	for _, key := range keys {
+		if username == "" || key != userKey {
+			errs = append(errs, d.store.Lockouts.Release(lockoutPolicy(d), key))
+		}
+	}
+	if username != "" {
+		errs = append(errs, d.store.Lockouts.Reset(userKey))
+	}
+
+	return errors.Join(errs...)
+}
+
+var userUnlockHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	user, err := d.store.Users.Get(d.server.Root, id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	err = d.store.Lockouts.Unlock(lockout.UserKey(user.Username))
+	d.audit(r, "user_unlock", "", "", user.Username, err)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+})
@@ -0,0 +1,171 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/lockout"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestLoginLockout(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: pwd}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	if err := storage.Auth.Save(&auth.JSONAuth{}); err != nil {
+		t.Fatalf("failed to save auther: %v", err)
+	}
+	if err := storage.Settings.Save(&settings.Settings{
+		Key:        []byte("key"),
+		AuthMethod: auth.MethodJSONAuth,
+		Lockout:    &settings.Lockout{Attempts: 2, Duration: "1h"},
+	}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+
+	handler := handle(loginHandler(DefaultTokenExpirationTime), "", storage, &settings.Server{})
+	attempts := 0
+	login := func(password string) *httptest.ResponseRecorder {
+		attempts++
+		body := `{"username":"username","password":"` + password + `"}`
+		req, err := http.NewRequest(http.MethodPost, "/api/login", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		// The forged headers don't change the address.
+		req.Header.Set("X-Forwarded-For", "203.0.113."+strconv.Itoa(attempts))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	steps := []struct {
+		name               string
+		password           string
+		expectedStatusCode int
+	}{
+		{"first failure", "wrong", http.StatusForbidden},
+		{"second failure", "wrong", http.StatusForbidden},
+		{"locked out, even with the password", "password", http.StatusTooManyRequests},
+	}
+	for _, step := range steps {
+		if recorder := login(step.password); recorder.Code != step.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got status code %d", step.name, step.expectedStatusCode, recorder.Code)
+		}
+	}
+
+	// An admin unlocks the user, along with the address which tried it.
+	if err := storage.Lockouts.Unlock(lockout.UserKey("username")); err != nil {
+		t.Fatal(err)
+	}
+	if recorder := login("password"); recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d after the unlock, got status code %d", http.StatusOK, recorder.Code)
+	}
+}
+
+func TestWebDAVLockout(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: pwd}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	if err := storage.Settings.Save(&settings.Settings{
+		Key:     []byte("key"),
+		Lockout: &settings.Lockout{Attempts: 2, Duration: "1h"},
+	}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	storage.Users = &customFSUser{Store: storage.Users, fs: afero.NewMemMapFs()}
+
+	handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+	propfind := func(password string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PROPFIND", "/dav/", http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Depth", "0")
+		req.SetBasicAuth("username", password)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	steps := []struct {
+		name               string
+		password           string
+		expectedStatusCode int
+	}{
+		{"first failure", "wrong", http.StatusUnauthorized},
+		{"second failure", "wrong", http.StatusUnauthorized},
+		{"locked out, even with the password", "password", http.StatusTooManyRequests},
+	}
+	for _, step := range steps {
+		if recorder := propfind(step.password); recorder.Code != step.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got status code %d", step.name, step.expectedStatusCode, recorder.Code)
+		}
+	}
+
+	if err := storage.Lockouts.Reset(lockout.UserKey("username"), lockout.AddrKey("10.0.0.1")); err != nil {
+		t.Fatal(err)
+	}
+	if recorder := propfind("password"); recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status code %d after the unlock, got status code %d", http.StatusMultiStatus, recorder.Code)
+	}
+}
+
+func TestLockoutSettings(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		lockout          *settings.Lockout
+		expectedAttempts uint
+	}{
+		"saved before the lockout": {
+			expectedAttempts: settings.DefaultLockoutAttempts,
+		},
+		"disabled": {
+			lockout: &settings.Lockout{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := newTestStorage(t)
+			if err := storage.Settings.Save(&settings.Settings{Key: []byte("key"), Lockout: tc.lockout}); err != nil {
+				t.Fatalf("failed to save settings: %v", err)
+			}
+
+			set, err := storage.Settings.Get()
+			if err != nil {
+				t.Fatalf("failed to get settings: %v", err)
+			}
+			if policy := lockoutPolicy(&data{settings: set}); policy.Attempts != tc.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tc.expectedAttempts, policy.Attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package fbhttp
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	fbAuth "github.com/filebrowser/filebrowser/v2/auth"
+)
+
+func withOIDCAuth(fn func(w http.ResponseWriter, r *http.Request, d *data, auther *fbAuth.OIDCAuth) (int, error)) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if d.settings.AuthMethod != fbAuth.MethodOIDCAuth {
+			return http.StatusNotFound, nil
+		}
+
+		auther, err := d.store.Auth.Get(d.settings.AuthMethod)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		return fn(w, r, d, auther.(*fbAuth.OIDCAuth))
+	}
+}
+
+// oidcLoginHandler redirects the user to the provider.
+var oidcLoginHandler = withOIDCAuth(func(w http.ResponseWriter, r *http.Request, d *data, auther *fbAuth.OIDCAuth) (int, error) {
+	loginURL, cookie, err := auther.LoginURL(r, d.server)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, loginURL, http.StatusFound)
+	return 0, nil
+})
+
+// oidcCallbackHandler logs in the user coming back from the provider. The
+// token is set in the auth cookie, from which the frontend picks it up.
+func oidcCallbackHandler(tokenExpireTime time.Duration) handleFunc {
+	return withOIDCAuth(func(w http.ResponseWriter, r *http.Request, d *data, auther *fbAuth.OIDCAuth) (int, error) {
+		http.SetCookie(w, &http.Cookie{
+			Name:   fbAuth.OIDCStateCookie,
+			Path:   d.server.BaseURL + fbAuth.OIDCCallbackPath,
+			MaxAge: -1,
+		})
+
+		user, err := auther.Auth(r, d.store.Users, d.settings, d.server)
+		switch {
+		case errors.Is(err, os.ErrPermission):
+			return http.StatusForbidden, err
+		case err != nil:
+			return http.StatusInternalServerError, err
+		}
+
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		signed, err := signToken(r, d, user, tokenExpireTime)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     "auth",
+			Value:    signed,
+			Path:     "/",
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, d.server.BaseURL+"/", http.StatusFound)
+		return 0, nil
+	})
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/rules"
 )
 
 /*
@@ -36,10 +37,10 @@ type FileCache interface {
 
 func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, resizePreview bool) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if !d.user.Perm.Download {
+		vars := mux.Vars(r)
+		if !d.CheckPerm("/"+vars["path"], rules.PermDownload) {
 			return http.StatusAccepted, nil
 		}
-		vars := mux.Vars(r)
 
 		previewSize, err := ParsePreviewSize(vars["size"])
 		if err != nil {
@@ -49,7 +50,7 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       "/" + vars["path"],
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm("/"+vars["path"], rules.PermModify),
 			Expand:     true,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -2,20 +2,27 @@ package fbhttp
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/afero"
 	"golang.org/x/crypto/bcrypt"
 
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/share"
 )
 
-var withHashFile = func(fn handleFunc) handleFunc {
+// withHashFile serves a shared file, and records the access to it. The
+// downloads count towards the maximum number of downloads of the link.
+func withHashFile(download bool, fn handleFunc) handleFunc {
 	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		id, ifPath := ifPathWithName(r)
 		link, err := d.store.Share.GetByHash(id)
@@ -23,6 +30,10 @@ var withHashFile = func(fn handleFunc) handleFunc {
 			return errToStatus(err), err
 		}
 
+		if link.Exhausted() {
+			return http.StatusGone, fberrors.ErrShareExhausted
+		}
+
 		status, err := authenticateShareRequest(r, link)
 		if status != 0 || err != nil {
 			return status, err
@@ -33,11 +44,25 @@ var withHashFile = func(fn handleFunc) handleFunc {
 			return errToStatus(err), err
 		}
 
-		if !user.Perm.Share || !user.Perm.Download {
-			return http.StatusForbidden, nil
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
 		}
 
 		d.user = user
+		for _, p := range append([]string{link.Path}, link.Paths...) {
+			if p != link.Path {
+				p = path.Join(link.Path, p)
+			}
+			if !d.canShare(p) {
+				return http.StatusForbidden, nil
+			}
+		}
+
+		// The content of the write-only shares is hidden from the visitors.
+		writeOnly := link.Upload != nil && link.Upload.WriteOnly
+		if writeOnly && (download || ifPath != "/") {
+			return http.StatusForbidden, nil
+		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
@@ -67,11 +92,14 @@ var withHashFile = func(fn handleFunc) handleFunc {
 		// set fs root to the shared file/folder
 		d.user.Fs = afero.NewBasePathFs(d.user.Fs, basePath)
 
+		// From now on, the paths are relative to the share.
+		d.link = link
+
 		file, err = files.NewFileInfo(&files.FileOptions{
 			Fs:      d.user.Fs,
 			Path:    filePath,
 			Modify:  d.user.Perm.Modify,
-			Expand:  true,
+			Expand:  !writeOnly,
 			Checker: d,
 			Token:   link.Token,
 		})
@@ -83,10 +111,102 @@ var withHashFile = func(fn handleFunc) handleFunc {
 			// extract name from the last directory in the path
 			name := filepath.Base(strings.TrimRight(link.Path, string(filepath.Separator)))
 			file.Name = name
+			if writeOnly {
+				file.Listing = &files.Listing{Items: []*files.FileInfo{}}
+			}
+		}
+
+		// The requests of the media players for the next parts of a
+		// file aren't other downloads, as long as the visitor
+		// downloaded its beginning.
+		ip, name := d.clientIP(r), path.Join(link.Path, ifPath)
+		d.link, err = d.store.Share.Update(link.Hash, func(l *share.Link) error {
+			counted := download && (file.IsDir || coversStart(r.Header.Get("Range"), file.Size) || !l.Downloaded(ip, name))
+			return l.Record(ip, name, counted)
+		})
+		if err != nil {
+			return errToStatus(err), err
 		}
 
 		d.raw = file
-		return fn(w, r, d)
+		return fn(d.throttle(w, r, "share:"+link.Hash, d.settings.Limits.ShareBandwidth), r, d)
+	}
+}
+
+// coversStart reports whether a Range header, for a file of the size,
+// is for the whole file or for parts including its beginning, like the
+// suffix ranges of the whole file. The invalid ones are taken as the
+// whole file.
+func coversStart(rng string, size int64) bool {
+	spec, ok := strings.CutPrefix(rng, "bytes=")
+	if !ok {
+		return true
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		first, last, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return true
+		}
+
+		if first == "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n >= size {
+				return true
+			}
+			continue
+		}
+
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withShareUpload lets the visitors of a share upload into the shared
+// directory, as its owner. The path of the request is made relative to
+// the scope of the owner, so that their rules and quota apply.
+func withShareUpload(fn handleFunc) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		id, ifPath := ifPathWithName(r)
+		link, err := d.store.Share.GetByHash(id)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if link.Upload == nil {
+			return http.StatusForbidden, nil
+		}
+
+		status, err := authenticateShareRequest(r, link)
+		if status != 0 || err != nil {
+			return status, err
+		}
+
+		user, err := d.store.Users.Get(d.server.Root, link.UserID)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		d.user = user
+		d.link = link
+		d.Share = link.Hash
+		if !d.CheckPerm(link.Path, rules.PermShare) || !d.CheckPerm(link.Path, rules.PermCreate) {
+			return http.StatusForbidden, nil
+		}
+
+		// ifPath is clean and absolute, so it can't leave the share.
+		r.URL.Path = path.Join(link.Path, ifPath)
+		r.URL.RawPath = ""
+
+		return fn(d.throttle(w, r, "share:"+link.Hash, d.settings.Limits.ShareBandwidth), r, d)
 	}
 }
 
@@ -105,27 +225,60 @@ func ifPathWithName(r *http.Request) (id, filePath string) {
 	}
 }
 
-var publicShareHandler = withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+// publicFile is a shared file, along with what its visitors can do.
+type publicFile struct {
+	*files.FileInfo
+	Upload *share.Upload      `json:"upload,omitempty"`
+	Perm   *share.Permissions `json:"perm,omitempty"`
+}
+
+var publicShareHandler = withHashFile(false, func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	file := d.raw.(*files.FileInfo)
 
 	if file.IsDir {
 		file.Sorting = files.Sorting{By: "name", Asc: false}
 		file.ApplySort()
-		return renderJSON(w, r, file)
 	}
 
-	return renderJSON(w, r, file)
+	return renderJSON(w, r, publicFile{FileInfo: file, Upload: d.link.Upload, Perm: d.link.Perm})
 })
 
-var publicDlHandler = withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+var publicDlHandler = withHashFile(true, func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	file := d.raw.(*files.FileInfo)
 	if !file.IsDir {
+		// The files are downloaded even when they're shown inline.
+		if !d.link.CanDownload() {
+			return http.StatusForbidden, nil
+		}
 		return rawFileHandler(w, r, file)
 	}
 
+	if !d.link.CanArchive() {
+		return http.StatusForbidden, nil
+	}
 	return rawDirHandler(w, r, d, file)
 })
 
+// publicPreviewHandler serves the shared images resized, so that the
+// visitors of the links to preview only can see them without
+// downloading them.
+func publicPreviewHandler(imgSvc ImgService, fileCache FileCache) handleFunc {
+	return withHashFile(false, func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		file := d.raw.(*files.FileInfo)
+		if file.IsDir || file.Type != "image" {
+			return http.StatusNotImplemented, fmt.Errorf("can't create preview for %s type", file.Type)
+		}
+
+		// The formats which can't be resized would be served as they are.
+		format, err := imgSvc.FormatFromExtension(file.Extension)
+		if err != nil || format == img.FormatGif {
+			return http.StatusNotImplemented, fmt.Errorf("can't create preview for %s", file.Name)
+		}
+
+		return handleImagePreview(w, r, imgSvc, fileCache, file, PreviewSizeBig, true, true)
+	})
+}
+
 func authenticateShareRequest(r *http.Request, l *share.Link) (int, error) {
 	if l.PasswordHash == "" {
 		return 0, nil
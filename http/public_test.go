@@ -1,17 +1,27 @@
 package fbhttp
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"slices"
+	"sort"
 	"testing"
 
 	"github.com/asdine/storm/v3"
 	"github.com/spf13/afero"
 
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+	"github.com/filebrowser/filebrowser/v2/img"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/storage"
 	"github.com/filebrowser/filebrowser/v2/storage/bolt"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
@@ -91,22 +101,7 @@ func TestPublicShareHandlerAuthentication(t *testing.T) {
 			t.Run(fmt.Sprintf("%s: %s", handlerName, name), func(t *testing.T) {
 				t.Parallel()
 
-				dbPath := filepath.Join(t.TempDir(), "db")
-				db, err := storm.Open(dbPath)
-				if err != nil {
-					t.Fatalf("failed to open db: %v", err)
-				}
-
-				t.Cleanup(func() {
-					if err := db.Close(); err != nil {
-						t.Errorf("failed to close db: %v", err)
-					}
-				})
-
-				storage, err := bolt.NewStorage(db)
-				if err != nil {
-					t.Fatalf("failed to get storage: %v", err)
-				}
+				storage := newTestStorage(t)
 				if err := storage.Share.Save(tc.share); err != nil {
 					t.Fatalf("failed to save share: %v", err)
 				}
@@ -120,9 +115,6 @@ func TestPublicShareHandlerAuthentication(t *testing.T) {
 				}); err != nil {
 					t.Fatalf("failed to save user: %v", err)
 				}
-				if err := storage.Settings.Save(&settings.Settings{Key: []byte("key")}); err != nil {
-					t.Fatalf("failed to save settings: %v", err)
-				}
 
 				storage.Users = &customFSUser{
 					Store: storage.Users,
@@ -143,6 +135,364 @@ func TestPublicShareHandlerAuthentication(t *testing.T) {
 	}
 }
 
+func TestPublicUpload(t *testing.T) {
+	t.Parallel()
+
+	cache := newMemoryUploadCache()
+	t.Cleanup(cache.Close)
+
+	testCases := map[string]struct {
+		upload             *share.Upload
+		handler            handleFunc
+		method             string
+		path               string
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		"Upload": {
+			upload:             &share.Upload{},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/new.pdf",
+			expectedStatusCode: http.StatusCreated,
+			expectedLocation:   "/api/public/tus/h/new.pdf",
+		},
+		"Upload to a share without uploads, 403": {
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/new.pdf",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"Upload overriding a file, 409": {
+			upload:             &share.Upload{},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/file.txt?override=true",
+			expectedStatusCode: http.StatusConflict,
+		},
+		"Upload leaving the share, kept in it": {
+			upload:             &share.Upload{},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/../secret.txt",
+			expectedStatusCode: http.StatusCreated,
+			expectedLocation:   "/api/public/tus/h/secret.txt",
+		},
+		"Upload of a type not allowed, 415": {
+			upload:             &share.Upload{Types: []string{".pdf"}},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/new.exe",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		"Upload too large, 413": {
+			upload:             &share.Upload{MaxSize: 2},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/new.pdf",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		"Upload past the limit, 403": {
+			upload:             &share.Upload{MaxFiles: 1, Count: 1},
+			handler:            withShareUpload(tusPost(cache)),
+			path:               "h/new.pdf",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"Listing of a write-only share": {
+			upload:             &share.Upload{WriteOnly: true},
+			handler:            publicShareHandler,
+			method:             http.MethodGet,
+			path:               "h",
+			expectedStatusCode: http.StatusOK,
+		},
+		"Download from a write-only share, 403": {
+			upload:             &share.Upload{WriteOnly: true},
+			handler:            publicDlHandler,
+			method:             http.MethodGet,
+			path:               "h/file.txt",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := newTestStorage(t)
+			if err := storage.Share.Save(&share.Link{Hash: "h", Path: "/drop", UserID: 1, Upload: tc.upload}); err != nil {
+				t.Fatalf("failed to save share: %v", err)
+			}
+			perm := users.Permissions{Share: true, Download: true, Create: true, Modify: true}
+			if err := storage.Users.Save(&users.User{Username: "username", Password: "pw", Perm: perm}); err != nil {
+				t.Fatalf("failed to save user: %v", err)
+			}
+			if err := storage.Settings.Save(&settings.Settings{Key: []byte("key"), FileMode: 0o640, DirMode: 0o750}); err != nil {
+				t.Fatalf("failed to save settings: %v", err)
+			}
+
+			fs := afero.NewMemMapFs()
+			for _, name := range []string{"/drop/file.txt", "/secret.txt"} {
+				if err := afero.WriteFile(fs, name, []byte("content"), 0o640); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			}
+			storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, tc.path, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.Header.Set("Upload-Length", "3")
+
+			recorder := httptest.NewRecorder()
+			handle(tc.handler, "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+			if location := recorder.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("expected location %q, got %q", tc.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestPublicDownloadLimit(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	if err := storage.Share.Save(&share.Link{Hash: "h", Path: "/file.txt", UserID: 1, MaxDownloads: 2}); err != nil {
+		t.Fatalf("failed to save share: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: "pw", Perm: users.Permissions{Share: true, Download: true}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/file.txt", []byte("content"), 0o640); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	steps := []struct {
+		handler            handleFunc
+		remoteAddr         string
+		rng                string
+		expectedStatusCode int
+	}{
+		{publicShareHandler, "10.0.0.1:1234", "", http.StatusOK},
+		{publicDlHandler, "10.0.0.1:1234", "", http.StatusOK},
+		{publicDlHandler, "10.0.0.1:1234", "bytes=3-", http.StatusPartialContent},
+		{publicDlHandler, "10.0.0.2:1234", "bytes=3-", http.StatusPartialContent},
+		{publicDlHandler, "10.0.0.1:1234", "", http.StatusGone},
+		{publicShareHandler, "10.0.0.1:1234", "", http.StatusGone},
+	}
+
+	for i, step := range steps {
+		req := newHTTPRequest(t, func(r *http.Request) {
+			r.RemoteAddr = step.remoteAddr
+			r.Header.Set("Range", step.rng)
+		})
+		recorder := httptest.NewRecorder()
+		handle(step.handler, "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+
+		if recorder.Code != step.expectedStatusCode {
+			t.Errorf("step %d: expected status code %d, got status code %d", i, step.expectedStatusCode, recorder.Code)
+		}
+	}
+
+	link, err := storage.Share.GetByHash("h")
+	if err != nil {
+		t.Fatalf("failed to get share: %v", err)
+	}
+	if link.Downloads != 2 || len(link.Accesses) != 4 || !link.Accesses[1].Download || link.Accesses[2].Download || !link.Accesses[3].Download {
+		t.Errorf("got %d downloads and accesses %+v", link.Downloads, link.Accesses)
+	}
+}
+
+func TestPublicPreview(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	for _, link := range []*share.Link{
+		{Hash: "image", Path: "/image.png", UserID: 1, Perm: &share.Permissions{}},
+		{Hash: "text", Path: "/file.txt", UserID: 1, Perm: &share.Permissions{}},
+	} {
+		if err := storage.Share.Save(link); err != nil {
+			t.Fatalf("failed to save share: %v", err)
+		}
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: "pw", Perm: users.Permissions{Share: true, Download: true}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 2000, 10))); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/image.png", buf.Bytes(), 0o640)
+	_ = afero.WriteFile(fs, "/file.txt", []byte("content"), 0o640)
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	preview := publicPreviewHandler(img.New(1), diskcache.NewNoOp())
+	testCases := map[string]struct {
+		handler            handleFunc
+		target             string
+		expectedStatusCode int
+	}{
+		"resized image": {
+			handler:            preview,
+			target:             "image",
+			expectedStatusCode: http.StatusOK,
+		},
+		"other files": {
+			handler:            preview,
+			target:             "text",
+			expectedStatusCode: http.StatusNotImplemented,
+		},
+		"inline download": {
+			handler:            publicDlHandler,
+			target:             "image?inline=true",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodGet, tc.target, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			handle(tc.handler, "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatusCode {
+				t.Fatalf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+			if recorder.Code != http.StatusOK {
+				return
+			}
+
+			config, _, err := image.DecodeConfig(recorder.Body)
+			if err != nil {
+				t.Fatalf("failed to decode the preview: %v", err)
+			}
+			if config.Width > 1080 {
+				t.Errorf("expected the preview to be resized, got a width of %d", config.Width)
+			}
+		})
+	}
+}
+
+func TestCoversStart(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rng      string
+		expected bool
+	}{
+		"no range":                  {"", true},
+		"from the start":            {"bytes=0-", true},
+		"from the middle":           {"bytes=3-", false},
+		"suffix of the whole file":  {"bytes=-100", true},
+		"suffix of a part":          {"bytes=-3", false},
+		"several with the start":    {"bytes=3-4, 0-1", true},
+		"several without the start": {"bytes=3-4, 5-6", false},
+		"invalid":                   {"bytes=a-b", true},
+		"other unit":                {"items=3-", true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := coversStart(tc.rng, 7); got != tc.expected {
+				t.Errorf("coversStart(%q) = %v; want %v", tc.rng, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPublicMultipleFiles(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	if err := storage.Share.Save(&share.Link{Hash: "h", Path: "/docs", Paths: []string{"/a.txt", "/sub/b.txt"}, UserID: 1}); err != nil {
+		t.Fatalf("failed to save share: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: "pw", Perm: users.Permissions{Share: true, Download: true}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"/docs/a.txt", "/docs/c.txt", "/docs/sub/b.txt", "/docs/sub/d.txt"} {
+		if err := afero.WriteFile(fs, name, []byte("content"), 0o640); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	serve := func(handler handleFunc, target string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, target, http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		recorder := httptest.NewRecorder()
+		handle(handler, "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	testCases := map[string]struct {
+		target             string
+		expectedStatusCode int
+	}{
+		"Shared file":              {"h/a.txt", http.StatusOK},
+		"File in shared directory": {"h/sub/b.txt", http.StatusOK},
+		"File not shared, 403":     {"h/c.txt", http.StatusForbidden},
+		"Sibling not shared, 403":  {"h/sub/d.txt", http.StatusForbidden},
+	}
+	for name, tc := range testCases {
+		if code := serve(publicDlHandler, tc.target).Code; code != tc.expectedStatusCode {
+			t.Errorf("%s: expected status code %d, got status code %d", name, tc.expectedStatusCode, code)
+		}
+	}
+
+	recorder := serve(publicShareHandler, "h")
+	var listing struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&listing); err != nil {
+		t.Fatalf("failed to decode listing: %v", err)
+	}
+	var names []string
+	for _, item := range listing.Items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	if !slices.Equal(names, []string{"a.txt", "sub"}) {
+		t.Errorf("got listing %v, want a.txt and sub", names)
+	}
+
+	body := serve(publicDlHandler, "h").Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	names = nil
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() {
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	if !slices.Equal(names, []string{"a.txt", "sub/b.txt"}) {
+		t.Errorf("got archive %v, want a.txt and sub/b.txt", names)
+	}
+}
+
 func newHTTPRequest(t *testing.T, requestModifiers ...func(*http.Request)) *http.Request {
 	t.Helper()
 	r, err := http.NewRequest(http.MethodGet, "h", http.NoBody)
@@ -169,3 +519,28 @@ func (cu *customFSUser) Get(baseScope string, id interface{}) (*users.User, erro
 
 	return user, nil
 }
+
+// newTestStorage returns a storage in a temporary database, with the
+// default settings.
+func newTestStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close db: %v", err)
+		}
+	})
+
+	st, err := bolt.NewStorage(db)
+	if err != nil {
+		t.Fatalf("failed to get storage: %v", err)
+	}
+	if err := st.Settings.Save(&settings.Settings{Key: []byte("key")}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	return st
+}
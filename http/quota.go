@@ -4,6 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/hostinger"
+	"github.com/filebrowser/filebrowser/v2/quota"
 )
 
 type quotaData struct {
@@ -16,6 +21,25 @@ type quotaData struct {
 }
 
 var quotaGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	// The quota enforced by File Browser takes precedence over the file.
+	if d.user.Quota.IsSet() {
+		usage, err := d.store.Quotas.Usage(d.user)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return renderJSON(w, r, map[string]map[string]uint64{
+			"inodes": {
+				"quota": d.user.Quota.Inodes,
+				"usage": uint64(max(usage.Inodes, 0)),
+			},
+			"space": {
+				"quota": d.user.Quota.Space,
+				"usage": uint64(max(usage.Space, 0)),
+			},
+		})
+	}
+
 	content, err := os.ReadFile(d.user.QuotaFile)
 	if err != nil {
 		return errToStatus(err), err
@@ -40,3 +64,70 @@ var quotaGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *d
 
 	return renderJSON(w, r, res)
 })
+
+// reserveQuota reserves in the quota of the user what writing size
+// bytes to p needs, the file replacing the existing one if any. The
+// reservation must be released once the file is written.
+func reserveQuota(d *data, p string, size int64) (*quota.Reservation, error) {
+	var freed, inodes int64 = 0, 1
+	if info, err := d.user.Fs.Stat(p); err == nil {
+		freed, inodes = info.Size(), 0
+	}
+
+	return d.store.Quotas.Reserve(d.user, p, max(size, 0), inodes, freed)
+}
+
+// reserveQuotaCopy reserves in the quota of the user what a copy of src
+// to dst needs.
+func reserveQuotaCopy(d *data, src, dst string) (*quota.Reservation, error) {
+	if !d.user.Quota.IsSet() {
+		return nil, nil
+	}
+
+	space, inodes, err := fileutils.DiskUsage(d.user.Fs, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.store.Quotas.Reserve(d.user, dst, space, inodes, 0)
+}
+
+// tracked wraps fn so that the usage of the user is updated
+// with the changes it makes to p.
+func (d *data) tracked(p string, fn func() error) func() error {
+	return func() error {
+		return d.store.Quotas.Track(d.user, p, fn)
+	}
+}
+
+// quotaLimits returns what's left of the quota of the user, along with
+// the writes in progress, to bound the files written to p by the archive
+// jobs.
+func quotaLimits(d *data, p string) (hostinger.Limits, error) {
+	if d.store.Quotas == nil || !d.user.Quota.IsSet() {
+		return hostinger.Limits{}, nil
+	}
+	if mount, _ := d.user.MountOf(p); mount != nil {
+		return hostinger.Limits{}, nil
+	}
+
+	usage, err := d.store.Quotas.Usage(d.user)
+	if err != nil {
+		return hostinger.Limits{}, err
+	}
+	reserved := d.store.Quotas.Reserved(d.user)
+
+	var limits hostinger.Limits
+	if d.user.Quota.Space > 0 {
+		limits.Space = int64(d.user.Quota.Space) - usage.Space - reserved.Space //nolint:gosec
+	}
+	if d.user.Quota.Inodes > 0 {
+		limits.Inodes = int64(d.user.Quota.Inodes) - usage.Inodes - reserved.Inodes //nolint:gosec
+	}
+	// A zero limit would be no limit.
+	if (d.user.Quota.Space > 0 && limits.Space <= 0) || (d.user.Quota.Inodes > 0 && limits.Inodes <= 0) {
+		return hostinger.Limits{}, fberrors.ErrQuotaExceeded
+	}
+
+	return limits, nil
+}
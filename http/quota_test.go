@@ -0,0 +1,100 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestQuotaWebDAV(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/a.txt", []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	space, inodes, err := fileutils.DiskUsage(fs, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{
+		Username: "username",
+		Password: pwd,
+		Perm:     users.Permissions{Create: true, Modify: true, Delete: true},
+		Quota:    users.Quota{Space: uint64(space) + 15, Inodes: uint64(inodes) + 2},
+	}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+
+	// A write in progress holds its space until it's done, so that the
+	// concurrent uploads can't go past the quota together.
+	user, err := storage.Users.Get("", "username")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := storage.Quotas.Reserve(user, "/f.txt", 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPut, "/dav/b.txt", strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("failed to construct request: %v", err)
+	}
+	req.SetBasicAuth("username", "password")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusInsufficientStorage {
+		t.Fatalf("expected status code %d while the space is reserved, got status code %d", http.StatusInsufficientStorage, recorder.Code)
+	}
+	res.Release()
+
+	// The steps depend on each other, so they run in order.
+	steps := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}{
+		{"PUT within the quota", http.MethodPut, "/dav/b.txt", "0123456789", http.StatusCreated},
+		{"PUT exceeding the space quota, 507", http.MethodPut, "/dav/c.txt", "0123456789", http.StatusInsufficientStorage},
+		{"PUT replacing a file counts the difference", http.MethodPut, "/dav/b.txt", "012345678901", http.StatusCreated},
+		{"DELETE frees the space", http.MethodDelete, "/dav/b.txt", "", http.StatusNoContent},
+		{"PUT after freeing space", http.MethodPut, "/dav/c.txt", "0123456789", http.StatusCreated},
+		{"MKCOL within the inodes quota", "MKCOL", "/dav/d", "", http.StatusCreated},
+		{"MKCOL exceeding the inodes quota, 507", "MKCOL", "/dav/e", "", http.StatusInsufficientStorage},
+	}
+
+	for _, step := range steps {
+		req, err := http.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		if err != nil {
+			t.Fatalf("%s: failed to construct request: %v", step.name, err)
+		}
+		req.SetBasicAuth("username", "password")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != step.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got status code %d: %s", step.name, step.expectedStatusCode, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/fileutils"
 	"github.com/filebrowser/filebrowser/v2/hostinger"
+	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
 
@@ -82,14 +83,14 @@ func setContentDisposition(w http.ResponseWriter, r *http.Request, file *files.F
 }
 
 var rawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	if !d.user.Perm.Download {
+	if !d.CheckPerm(r.URL.Path, rules.PermDownload) {
 		return http.StatusAccepted, nil
 	}
 
 	file, err := files.NewFileInfo(&files.FileOptions{
 		Fs:         d.user.Fs,
 		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
+		Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 		Expand:     false,
 		ReadHeader: d.server.TypeDetectionByHeader,
 		Checker:    d,
@@ -110,8 +111,10 @@ var rawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data)
 	return rawDirHandler(w, r, d, file)
 })
 
+// getFiles returns the files to archive under path, leaving out the ones
+// the user can't see or download.
 func getFiles(d *data, path, commonPath string) ([]archives.FileInfo, error) {
-	if !d.Check(path) {
+	if !d.Check(path) || !d.CheckPerm(path, rules.PermDownload) {
 		return nil, nil
 	}
 
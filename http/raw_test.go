@@ -4,9 +4,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"testing"
 
+	"github.com/spf13/afero"
+
 	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 func TestSetContentDisposition(t *testing.T) {
@@ -73,3 +79,39 @@ func TestSetContentDisposition(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFilesChecksDownload(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"/docs/a.txt", "/docs/private/b.txt", "/docs/c.key"} {
+		if err := afero.WriteFile(fs, name, []byte("content"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	d := &data{
+		settings: &settings.Settings{},
+		user: &users.User{
+			Fs:   fs,
+			Perm: users.Permissions{Download: true},
+			Rules: []rules.Rule{
+				{Path: "/docs/private", Revoke: []rules.Perm{rules.PermDownload}},
+				{Regex: true, Regexp: &rules.Regexp{Raw: `\.key$`}, Revoke: []rules.Perm{rules.PermDownload}},
+			},
+		},
+	}
+
+	archiveFiles, err := getFiles(d, "/docs", "/")
+	if err != nil {
+		t.Fatalf("failed to get the files: %v", err)
+	}
+
+	var names []string
+	for _, f := range archiveFiles {
+		names = append(names, f.NameInArchive)
+	}
+	if expected := []string{"docs", "docs/a.txt"}; !slices.Equal(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
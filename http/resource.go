@@ -23,17 +23,21 @@ import (
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/fileutils"
 	"github.com/filebrowser/filebrowser/v2/hostinger"
+	"github.com/filebrowser/filebrowser/v2/jobs"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/trash"
+	"github.com/filebrowser/filebrowser/v2/versions"
 )
 
 var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	file, err := files.NewFileInfo(&files.FileOptions{
 		Fs:         d.user.Fs,
 		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
+		Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 		Expand:     true,
 		ReadHeader: d.server.TypeDetectionByHeader,
 		Checker:    d,
-		Content:    d.user.Perm.Download,
+		Content:    d.CheckPerm(r.URL.Path, rules.PermDownload),
 	})
 
 	// if the path does not exist and its the trash dir - create it
@@ -47,7 +51,7 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 			file, err = files.NewFileInfo(&files.FileOptions{
 				Fs:         d.user.Fs,
 				Path:       r.URL.Path,
-				Modify:     d.user.Perm.Modify,
+				Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 				Expand:     true,
 				ReadHeader: d.server.TypeDetectionByHeader,
 				Checker:    d,
@@ -109,7 +113,7 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		})
 		return renderJSON(w, r, file)
 	} else if encoding == "true" {
-		if !d.user.Perm.Download {
+		if !d.CheckPerm(r.URL.Path, rules.PermDownload) {
 			return http.StatusAccepted, nil
 		}
 		if file.Type != "text" {
@@ -148,16 +152,16 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 	return renderJSON(w, r, file)
 })
 
-func resourceDeleteHandler(fileCache FileCache) handleFunc {
+func resourceDeleteHandler(fileCache FileCache, trashManager *trash.Manager) handleFunc {
 	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if r.URL.Path == "/" || !d.user.Perm.Delete {
+		if r.URL.Path == "/" || !d.checkPermTree(r.URL.Path, rules.PermDelete) {
 			return http.StatusForbidden, nil
 		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -166,7 +170,7 @@ func resourceDeleteHandler(fileCache FileCache) handleFunc {
 			return errToStatus(err), err
 		}
 
-		err = d.store.Share.DeleteWithPathPrefix(file.Path)
+		err = d.store.Share.DeleteWithPathPrefix(file.Path, d.user.ID)
 		if err != nil {
 			log.Printf("WARNING: Error(s) occurred while deleting associated shares with file: %s", err)
 		}
@@ -179,29 +183,27 @@ func resourceDeleteHandler(fileCache FileCache) handleFunc {
 
 		skipTrash := r.URL.Query().Get("skip_trash") == hostinger.QueryTrue
 
+		var dst string
 		if d.user.TrashDir == "" || skipTrash {
-			err = d.RunHook(func() error {
-				return d.user.Fs.RemoveAll(r.URL.Path)
-			}, "delete", r.URL.Path, "", d.user)
+			err = d.RunHook(d.tracked(r.URL.Path, func() error {
+				if err := d.user.Fs.RemoveAll(r.URL.Path); err != nil {
+					return err
+				}
+				return versions.Delete(d.user, r.URL.Path)
+			}), "delete", r.URL.Path, "", d.user)
 		} else {
-			src := r.URL.Path
-			dst := d.user.TrashDir
-
-			if !d.Check(src) || !d.Check(dst) {
+			if !d.Check(r.URL.Path) || !d.Check(d.user.TrashDir) {
 				return http.StatusForbidden, nil
 			}
 
-			src = path.Clean("/" + src)
-			dst = path.Clean("/" + dst)
-
-			err = d.user.Fs.MkdirAll(dst, d.settings.DirMode)
-			if err != nil {
-				return errToStatus(err), err
+			var item *trash.Item
+			item, err = trashManager.Move(d.user, r.URL.Path, d.settings.FileMode, d.settings.DirMode)
+			if err == nil {
+				dst = item.TrashPath
 			}
-
-			dst = path.Join(dst, file.Name)
-			err = fileutils.MoveFile(d.user.Fs, src, dst, d.settings.FileMode, d.settings.DirMode)
+			d.audit(r, "trash", r.URL.Path, dst, "", err)
 		}
+		updateIndex(d, r.URL.Path, dst)
 
 		if err != nil {
 			return errToStatus(err), err
@@ -211,32 +213,46 @@ func resourceDeleteHandler(fileCache FileCache) handleFunc {
 	})
 }
 
-func resourcePostHandler(fileCache FileCache) handleFunc {
+func resourcePostHandler(fileCache FileCache, jobManager *jobs.Manager) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if !d.user.Perm.Create || !d.Check(r.URL.Path) {
+		if !d.CheckPerm(r.URL.Path, rules.PermCreate) || !d.Check(r.URL.Path) {
 			return http.StatusForbidden, nil
 		}
 
 		// Directories creation on POST.
 		if strings.HasSuffix(r.URL.Path, "/") {
-			err := d.user.Fs.MkdirAll(r.URL.Path, d.settings.DirMode)
+			res, err := reserveQuota(d, r.URL.Path, 0)
+			if err != nil {
+				return errToStatus(err), err
+			}
+			defer res.Release()
+
+			err = d.tracked(r.URL.Path, func() error {
+				return d.user.Fs.MkdirAll(r.URL.Path, d.settings.DirMode)
+			})()
+			updateIndex(d, r.URL.Path)
+			d.audit(r, "mkdir", r.URL.Path, "", "", err)
 			return errToStatus(err), err
 		}
 
 		// Archive creation on POST.
 		if strings.HasSuffix(r.URL.Path, "/archive") {
-			if !d.user.Perm.Create {
+			if !d.CheckPerm(r.URL.Path, rules.PermCreate) {
 				return http.StatusForbidden, nil
 			}
 
-			err := archiveHandler(r, d)
-			return errToStatus(err), err
+			job, fn, err := archiveHandler(r, d)
+			if err != nil {
+				return errToStatus(err), err
+			}
+
+			return runJob(w, r, d, jobManager, job, fn)
 		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -247,7 +263,7 @@ func resourcePostHandler(fileCache FileCache) handleFunc {
 			}
 
 			// Permission for overwriting the file
-			if !d.user.Perm.Modify {
+			if !d.CheckPerm(r.URL.Path, rules.PermModify) {
 				return http.StatusForbidden, nil
 			}
 
@@ -257,8 +273,19 @@ func resourcePostHandler(fileCache FileCache) handleFunc {
 			}
 		}
 
-		err = d.RunHook(func() error {
-			info, writeErr := writeFile(d.user.Fs, r.URL.Path, r.Body, d.settings.FileMode, d.settings.DirMode)
+		res, err := reserveQuota(d, r.URL.Path, r.ContentLength)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		defer res.Release()
+
+		err = d.RunHook(d.tracked(r.URL.Path, func() error {
+			if err := versions.Save(d.user, r.URL.Path, d.settings.FileMode, d.settings.DirMode); err != nil {
+				return err
+			}
+
+			body := res.Reader(r.Body)
+			info, writeErr := writeFile(d.user.Fs, r.URL.Path, body, d.settings.FileMode, d.settings.DirMode)
 			if writeErr != nil {
 				return writeErr
 			}
@@ -266,18 +293,21 @@ func resourcePostHandler(fileCache FileCache) handleFunc {
 			etag := fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
 			w.Header().Set("ETag", etag)
 			return nil
-		}, "upload", r.URL.Path, "", d.user)
+		}), "upload", r.URL.Path, "", d.user)
 
 		if err != nil {
-			_ = d.user.Fs.RemoveAll(r.URL.Path)
+			_ = d.tracked(r.URL.Path, func() error {
+				return d.user.Fs.RemoveAll(r.URL.Path)
+			})()
 		}
+		updateIndex(d, r.URL.Path)
 
 		return errToStatus(err), err
 	})
 }
 
 var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	if !d.user.Perm.Modify || !d.Check(r.URL.Path) {
+	if !d.CheckPerm(r.URL.Path, rules.PermModify) || !d.Check(r.URL.Path) {
 		return http.StatusForbidden, nil
 	}
 
@@ -294,8 +324,19 @@ var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		return http.StatusNotFound, nil
 	}
 
-	err = d.RunHook(func() error {
-		info, writeErr := writeFile(d.user.Fs, r.URL.Path, r.Body, d.settings.FileMode, d.settings.DirMode)
+	res, err := reserveQuota(d, r.URL.Path, r.ContentLength)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	defer res.Release()
+
+	err = d.RunHook(d.tracked(r.URL.Path, func() error {
+		if err := versions.Save(d.user, r.URL.Path, d.settings.FileMode, d.settings.DirMode); err != nil {
+			return err
+		}
+
+		body := res.Reader(r.Body)
+		info, writeErr := writeFile(d.user.Fs, r.URL.Path, body, d.settings.FileMode, d.settings.DirMode)
 		if writeErr != nil {
 			return writeErr
 		}
@@ -303,13 +344,14 @@ var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		etag := fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
 		w.Header().Set("ETag", etag)
 		return nil
-	}, "save", r.URL.Path, "", d.user)
+	}), "save", r.URL.Path, "", d.user)
+	updateIndex(d, r.URL.Path)
 
 	return errToStatus(err), err
 })
 
-func resourcePatchHandler(fileCache FileCache) handleFunc {
-	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+func resourcePatchHandler(fileCache FileCache, jobManager *jobs.Manager) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		src := r.URL.Path
 		dst := r.URL.Query().Get("destination")
 		action := r.URL.Query().Get("action")
@@ -317,8 +359,12 @@ func resourcePatchHandler(fileCache FileCache) handleFunc {
 		unarchive := action == "unarchive"
 		overrideArch := false
 		if action == "chmod" {
-			err := chmodActionHandler(r, d)
-			return errToStatus(err), err
+			job, fn, err := chmodActionHandler(r, d)
+			if err != nil {
+				return errToStatus(err), err
+			}
+
+			return runJob(w, r, d, jobManager, job, fn)
 		}
 		// Hostinger specific end
 		dst, err := url.QueryUnescape(dst)
@@ -355,21 +401,45 @@ func resourcePatchHandler(fileCache FileCache) handleFunc {
 				dst = addVersionSuffix(dst, d.user.Fs)
 			}
 
-			if override && !d.user.Perm.Modify {
+			if override && !d.CheckPerm(dst, rules.PermModify) {
 				return http.StatusForbidden, nil
 			}
 		}
 
-		err = d.RunHook(func() error {
-			if unarchive {
-				if !d.user.Perm.Create {
-					return fberrors.ErrPermissionDenied
-				}
+		if unarchive {
+			if !d.CheckPerm(src, rules.PermDownload) || !d.CheckPerm(dst, rules.PermCreate) {
+				return http.StatusForbidden, nil
+			}
 
-				return hostinger.Unarchive(r.Context(), src, dst, d.user.Fs, overrideArch, d.settings.DirMode)
+			limits, err := quotaLimits(d, dst)
+			if err != nil {
+				return errToStatus(err), err
+			}
+			// The size of the archive is the least that its content needs,
+			// and it's kept reserved until the job is done.
+			res, err := reserveQuotaCopy(d, src, dst)
+			if err != nil {
+				return errToStatus(err), err
 			}
+
+			job := &jobs.Job{Action: action, Source: src, Destination: dst}
+			status, err := runJob(w, r, d, jobManager, job, func(ctx context.Context, p *jobs.Progress) error {
+				defer res.Release()
+				defer updateIndex(d, dst)
+				return d.RunHook(d.tracked(dst, func() error {
+					return hostinger.Unarchive(ctx, src, dst, d.user.Fs, overrideArch, d.settings.DirMode, limits, p)
+				}), action, src, dst, d.user)
+			})
+			if err != nil {
+				res.Release()
+			}
+			return status, err
+		}
+
+		err = d.RunHook(func() error {
 			return patchAction(r.Context(), action, src, dst, d, fileCache)
 		}, action, src, dst, d.user)
+		updateIndex(d, src, dst)
 
 		return errToStatus(err), err
 	})
@@ -474,13 +544,22 @@ func delThumbs(ctx context.Context, fileCache FileCache, file *files.FileInfo) e
 func patchAction(ctx context.Context, action, src, dst string, d *data, fileCache FileCache) error {
 	switch action {
 	case "copy":
-		if !d.user.Perm.Create {
+		// The copies could be downloaded, so the files must be too.
+		if !d.checkPermTree(src, rules.PermDownload) || !d.CheckPerm(dst, rules.PermCreate) {
 			return fberrors.ErrPermissionDenied
 		}
 
-		return fileutils.CopyScoped(d.user.Fs, src, dst, d.settings.FileMode, d.settings.DirMode, d.server.Root)
+		res, err := reserveQuotaCopy(d, src, dst)
+		if err != nil {
+			return err
+		}
+		defer res.Release()
+
+		return d.tracked(dst, func() error {
+			return fileutils.CopyScoped(d.user.Fs, src, dst, d.settings.FileMode, d.settings.DirMode, d.server.Root)
+		})()
 	case "rename":
-		if !d.user.Perm.Rename {
+		if !d.checkPermTree(src, rules.PermRename) || !d.CheckPerm(dst, rules.PermRename) {
 			return fberrors.ErrPermissionDenied
 		}
 		src = path.Clean("/" + src)
@@ -489,7 +568,7 @@ func patchAction(ctx context.Context, action, src, dst string, d *data, fileCach
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       src,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(src, rules.PermModify),
 			Expand:     false,
 			ReadHeader: false,
 			Checker:    d,
@@ -504,7 +583,12 @@ func patchAction(ctx context.Context, action, src, dst string, d *data, fileCach
 			return err
 		}
 
-		return fileutils.MoveFile(d.user.Fs, src, dst, d.settings.FileMode, d.settings.DirMode)
+		if err := fileutils.MoveFile(d.user.Fs, src, dst, d.settings.FileMode, d.settings.DirMode); err != nil {
+			return err
+		}
+
+		moveMetadata(d, src, dst)
+		return nil
 	default:
 		return fmt.Errorf("unsupported action %s: %w", action, fberrors.ErrInvalidRequestParams)
 	}
@@ -521,7 +605,7 @@ var diskUsage = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (
 	file, err := files.NewFileInfo(&files.FileOptions{
 		Fs:         d.user.Fs,
 		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
+		Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 		Expand:     false,
 		ReadHeader: false,
 		Checker:    d,
@@ -548,60 +632,90 @@ var diskUsage = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (
 	})
 })
 
-func archiveHandler(r *http.Request, d *data) error {
+func archiveHandler(r *http.Request, d *data) (*jobs.Job, jobs.Func, error) {
 	dir, err := files.NewFileInfo(&files.FileOptions{
 		Fs:         d.user.Fs,
 		Path:       strings.TrimSuffix(r.URL.Path, "/archive"),
-		Modify:     d.user.Perm.Modify,
+		Modify:     d.CheckPerm(strings.TrimSuffix(r.URL.Path, "/archive"), rules.PermModify),
 		Expand:     false,
 		ReadHeader: false,
 		Checker:    d,
 	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	algo := r.URL.Query().Get("algo")
 
 	archive, err := hostinger.GetFilenameFromQuery(r, dir)
 	if err != nil {
-		return fberrors.ErrInvalidRequestParams
+		return nil, nil, fberrors.ErrInvalidRequestParams
 	}
 
 	filenames, err := parseQueryFiles(r, dir, d.user)
 	if err != nil {
-		return fberrors.ErrInvalidRequestParams
+		return nil, nil, fberrors.ErrInvalidRequestParams
+	}
+
+	// The archive could be downloaded, so its files must be too.
+	for _, name := range filenames {
+		if !d.Check(name) || !d.checkPermTree(name, rules.PermDownload) {
+			return nil, nil, fberrors.ErrPermissionDenied
+		}
+	}
+
+	limits, err := quotaLimits(d, archive)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return hostinger.Archive(r.Context(), d.user.Fs, archive, algo, filenames, d.settings.DirMode)
+	job := &jobs.Job{Action: "archive", Source: dir.Path, Destination: archive}
+	return job, func(ctx context.Context, p *jobs.Progress) error {
+		// The limits bound the archive, and its inode is reserved
+		// while it's written.
+		res, err := reserveQuota(d, archive, 0)
+		if err != nil {
+			return err
+		}
+		defer res.Release()
+
+		defer updateIndex(d, archive)
+		err = d.tracked(archive, func() error {
+			return hostinger.Archive(ctx, d.user.Fs, archive, algo, filenames, d.settings.DirMode, limits, p)
+		})()
+		d.audit(r, "archive", dir.Path, archive, "", err)
+		return err
+	}, nil
 }
 
-func chmodActionHandler(r *http.Request, d *data) error {
+func chmodActionHandler(r *http.Request, d *data) (*jobs.Job, jobs.Func, error) {
 	target := r.URL.Path
 	perms := r.URL.Query().Get("permissions")
 	recursive := r.URL.Query().Get("recursive") == hostinger.QueryTrue
 	recursionType := r.URL.Query().Get("type")
 
-	if !d.user.Perm.Modify {
-		return fberrors.ErrPermissionDenied
+	if !d.CheckPerm(target, rules.PermModify) {
+		return nil, nil, fberrors.ErrPermissionDenied
 	}
 
 	if !d.Check(target) || target == "/" {
-		return fberrors.ErrPermissionDenied
+		return nil, nil, fberrors.ErrPermissionDenied
 	}
 
 	mode, err := strconv.ParseUint(perms, 10, 32)
 	if err != nil {
-		return fberrors.ErrInvalidRequestParams
+		return nil, nil, fberrors.ErrInvalidRequestParams
 	}
 
 	permMode := normalizeFileMode(mode)
 
 	info, err := d.user.Fs.Stat(target)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
+	job := &jobs.Job{Action: "chmod", Source: target}
+
 	if recursive && info.IsDir() {
 		var recFilter func(i os.FileInfo) bool
 
@@ -620,17 +734,32 @@ func chmodActionHandler(r *http.Request, d *data) error {
 			}
 		}
 
-		return afero.Walk(d.user.Fs, target, func(name string, info os.FileInfo, err error) error {
-			if err == nil {
-				if recFilter(info) {
-					err = d.user.Fs.Chmod(name, os.FileMode(permMode))
+		return job, func(ctx context.Context, p *jobs.Progress) error {
+			defer updateIndex(d, target)
+			err := afero.Walk(d.user.Fs, target, func(name string, info os.FileInfo, err error) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
 				}
-			}
+				if err == nil {
+					if recFilter(info) {
+						err = d.user.Fs.Chmod(name, os.FileMode(permMode))
+						p.AddFiles(1)
+					}
+				}
+				return err
+			})
+			d.audit(r, "chmod", target, "", perms, err)
 			return err
-		})
+		}, nil
 	}
 
-	return d.user.Fs.Chmod(target, os.FileMode(permMode))
+	return job, func(_ context.Context, p *jobs.Progress) error {
+		defer updateIndex(d, target)
+		p.AddFiles(1)
+		err := d.user.Fs.Chmod(target, os.FileMode(permMode))
+		d.audit(r, "chmod", target, "", perms, err)
+		return err
+	}, nil
 }
 
 func normalizeFileMode(m uint64) uint32 {
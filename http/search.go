@@ -58,13 +58,24 @@ var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *dat
 	}()
 	query := r.URL.Query().Get("query")
 
-	err := search.Search(ctx, d.user.Fs, r.URL.Path, query, d, func(path string, f os.FileInfo) error {
-		select {
-		case <-ctx.Done():
-		case response <- map[string]interface{}{
+	// The index only covers the local filesystem of the scope.
+	index := d.store.Index
+	if !d.user.Backend.IsLocal() || len(d.user.Mounts) > 0 {
+		index = nil
+	}
+
+	err := index.Search(ctx, d.user.FullPath("/"), d.user.Fs, r.URL.Path, query, d, func(path string, f os.FileInfo, matches []search.ContentMatch) error {
+		info := map[string]interface{}{
 			"dir":  f.IsDir(),
 			"path": path,
-		}:
+		}
+		if len(matches) > 0 {
+			info["matches"] = matches
+		}
+
+		select {
+		case <-ctx.Done():
+		case response <- info:
 		}
 		return context.Cause(ctx)
 	})
@@ -75,8 +86,22 @@ var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *dat
 	}
 	// ignore cancellation errors from user aborts
 	if err != nil && !errors.Is(err, context.Canceled) {
-		return http.StatusInternalServerError, err
+		return errToStatus(err), err
 	}
 
 	return 0, nil
 })
+
+// updateIndex refreshes the search index entries of the given paths, and
+// of everything under them, after they were created, modified or removed.
+func updateIndex(d *data, paths ...string) {
+	if d.store.Index == nil || !d.user.Backend.IsLocal() {
+		return
+	}
+
+	for _, p := range paths {
+		if p != "" {
+			d.store.Index.Update(d.user.FullPath(p))
+		}
+	}
+}
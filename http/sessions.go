@@ -0,0 +1,67 @@
+package fbhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// logoutHandler revokes the session of the request, so that its token
+// can't be used anymore.
+var logoutHandler = withAuthenticatedUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if d.session == nil {
+		return http.StatusBadRequest, nil
+	}
+
+	err := d.store.Sessions.Delete(d.session.ID)
+	d.audit(r, "logout", "", "", d.user.Username, err)
+	if err != nil && !errors.Is(err, fberrors.ErrNotExist) {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+})
+
+var userSessionsGetHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	list, err := d.store.Sessions.FindByUserID(d.raw.(uint))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, list)
+})
+
+var userSessionsDeleteHandler = withSelfOrAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	err := d.store.Sessions.DeleteByUserID(d.raw.(uint))
+	d.audit(r, "sessions_revoke", "", "", strconv.FormatUint(uint64(d.raw.(uint)), 10), err)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+})
+
+var userSessionDeleteHandler = withSelfOrAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	session, err := d.store.Sessions.Get(mux.Vars(r)["sid"])
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	// The ID of the user is checked, so that it can't be used to revoke
+	// the sessions of the others.
+	if session.UserID != d.raw.(uint) {
+		return http.StatusNotFound, nil
+	}
+
+	err = d.store.Sessions.Delete(session.ID)
+	d.audit(r, "session_revoke", "", "", strconv.FormatUint(uint64(session.UserID), 10), err)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
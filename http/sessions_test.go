@@ -0,0 +1,98 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	st := newTestStorage(t)
+	// Another admin lets the users of the cases be demoted or deleted,
+	// since the only admin can't be.
+	if err := st.Users.Save(&users.User{Username: "admin", Password: "pw", Perm: users.Permissions{Admin: true}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	set, err := st.Settings.Get()
+	if err != nil {
+		t.Fatalf("failed to get settings: %v", err)
+	}
+
+	revokeAll := func(st *storage.Storage, user *users.User) error {
+		return st.Sessions.DeleteByUserID(user.ID)
+	}
+
+	testCases := map[string]struct {
+		change             func(st *storage.Storage, user *users.User) error
+		expectedStatusCode int
+	}{
+		"Active session": {
+			change:             func(*storage.Storage, *users.User) error { return nil },
+			expectedStatusCode: http.StatusOK,
+		},
+		"Revoked session, 401": {
+			change:             revokeAll,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"Deleted user, 401": {
+			change: func(st *storage.Storage, user *users.User) error {
+				return st.Users.Delete(user.ID)
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"Demoted user, 401": {
+			change: func(st *storage.Storage, user *users.User) error {
+				user.Perm.Admin = false
+				return st.Users.Update(user, "Perm")
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"Promoted user": {
+			change: func(st *storage.Storage, user *users.User) error {
+				user.Perm.Share = true
+				return st.Users.Update(user, "Perm")
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			user := &users.User{Username: name, Password: "pw", Perm: users.Permissions{Admin: true}}
+			if err := st.Users.Save(user); err != nil {
+				t.Fatalf("failed to save user: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			signed, err := signToken(req, &data{store: st, settings: set, server: &settings.Server{}}, user, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to sign token: %v", err)
+			}
+			req.Header.Set("X-Auth", signed)
+
+			if err := tc.change(st, user); err != nil {
+				t.Fatalf("failed to change user: %v", err)
+			}
+
+			handler := withUser(func(_ http.ResponseWriter, _ *http.Request, _ *data) (int, error) {
+				return http.StatusOK, nil
+			})
+			recorder := httptest.NewRecorder()
+			handle(handler, "", st, &settings.Server{}).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+		})
+	}
+}
@@ -13,12 +13,15 @@ type settingsData struct {
 	HideLoginButton       bool                  `json:"hideLoginButton"`
 	CreateUserDir         bool                  `json:"createUserDir"`
 	MinimumPasswordLength uint                  `json:"minimumPasswordLength"`
+	TwoFactorRequired     bool                  `json:"twoFactorRequired"`
 	UserHomeBasePath      string                `json:"userHomeBasePath"`
 	Defaults              settings.UserDefaults `json:"defaults"`
 	AuthMethod            settings.AuthMethod   `json:"authMethod"`
 	Rules                 []rules.Rule          `json:"rules"`
 	Branding              settings.Branding     `json:"branding"`
 	Tus                   settings.Tus          `json:"tus"`
+	Limits                settings.Limits       `json:"limits"`
+	Lockout               *settings.Lockout     `json:"lockout"`
 	Shell                 []string              `json:"shell"`
 	Commands              map[string][]string   `json:"commands"`
 }
@@ -29,12 +32,15 @@ var settingsGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request,
 		HideLoginButton:       d.settings.HideLoginButton,
 		CreateUserDir:         d.settings.CreateUserDir,
 		MinimumPasswordLength: d.settings.MinimumPasswordLength,
+		TwoFactorRequired:     d.settings.TwoFactorRequired,
 		UserHomeBasePath:      d.settings.UserHomeBasePath,
 		Defaults:              d.settings.Defaults,
 		AuthMethod:            d.settings.AuthMethod,
 		Rules:                 d.settings.Rules,
 		Branding:              d.settings.Branding,
 		Tus:                   d.settings.Tus,
+		Limits:                d.settings.Limits,
+		Lockout:               d.settings.Lockout,
 		Shell:                 d.settings.Shell,
 		Commands:              d.settings.Commands,
 	}
@@ -52,15 +58,21 @@ var settingsPutHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request,
 	d.settings.Signup = req.Signup
 	d.settings.CreateUserDir = req.CreateUserDir
 	d.settings.MinimumPasswordLength = req.MinimumPasswordLength
+	d.settings.TwoFactorRequired = req.TwoFactorRequired
 	d.settings.UserHomeBasePath = req.UserHomeBasePath
 	d.settings.Defaults = req.Defaults
 	d.settings.Rules = req.Rules
 	d.settings.Branding = req.Branding
 	d.settings.Tus = req.Tus
+	d.settings.Limits = req.Limits
+	if req.Lockout != nil {
+		d.settings.Lockout = req.Lockout
+	}
 	d.settings.Shell = req.Shell
 	d.settings.Commands = req.Commands
 	d.settings.HideLoginButton = req.HideLoginButton
 
 	err = d.store.Settings.Save(d.settings)
+	d.audit(r, "settings_update", "", "", "", err)
 	return errToStatus(err), err
 })
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,20 +18,27 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/versions"
 )
 
-func withPermShare(fn handleFunc) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if !d.user.Perm.Share || !d.user.Perm.Download {
-			return http.StatusForbidden, nil
-		}
+// canShare reports whether the user can share p, since the permission
+// rules may allow sharing some paths only.
+func (d *data) canShare(p string) bool {
+	return d.CheckPerm(p, rules.PermShare) && d.CheckPerm(p, rules.PermDownload)
+}
 
-		return fn(w, r, d)
-	})
+// canManageLink reports whether the user can edit or delete a link. The
+// admins can manage the links of the other users.
+func (d *data) canManageLink(link *share.Link) bool {
+	if link.UserID != d.user.ID {
+		return d.user.Perm.Admin
+	}
+	return d.canShare(link.Path)
 }
 
-var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+var shareListHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	var (
 		s   []*share.Link
 		err error
@@ -46,6 +56,10 @@ var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		return http.StatusInternalServerError, err
 	}
 
+	s = slices.DeleteFunc(s, func(link *share.Link) bool {
+		return !d.canManageLink(link)
+	})
+
 	sort.Slice(s, func(i, j int) bool {
 		if s[i].UserID != s[j].UserID {
 			return s[i].UserID < s[j].UserID
@@ -56,7 +70,11 @@ var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 	return renderJSON(w, r, s)
 })
 
-var shareGetsHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+var shareGetsHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.canShare(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
 	s, err := d.store.Share.Gets(r.URL.Path, d.user.ID)
 	if errors.Is(err, fberrors.ErrNotExist) {
 		return renderJSON(w, r, []*share.Link{})
@@ -69,7 +87,7 @@ var shareGetsHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 	return renderJSON(w, r, s)
 })
 
-var shareDeleteHandler = withPermShare(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+var shareDeleteHandler = withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	hash := strings.TrimSuffix(r.URL.Path, "/")
 	hash = strings.TrimPrefix(hash, "/")
 
@@ -82,15 +100,16 @@ var shareDeleteHandler = withPermShare(func(_ http.ResponseWriter, r *http.Reque
 		return errToStatus(err), err
 	}
 
-	if link.UserID != d.user.ID && !d.user.Perm.Admin {
+	if !d.canManageLink(link) {
 		return http.StatusForbidden, nil
 	}
 
 	err = d.store.Share.Delete(hash)
+	d.audit(r, "share_delete", link.Path, "", hash, err)
 	return errToStatus(err), err
 })
 
-var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+var sharePostHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	var s *share.Link
 	var body share.CreateBody
 	if r.Body != nil {
@@ -100,35 +119,58 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		defer r.Body.Close()
 	}
 
-	bytes := make([]byte, 6)
-	_, err := rand.Read(bytes)
+	sharePath, paths, err := sharedPaths(d, r.URL.Path, body.Paths)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return errToStatus(err), err
 	}
 
-	str := base64.URLEncoding.EncodeToString(bytes)
+	for _, p := range append([]string{sharePath}, paths...) {
+		if p != sharePath {
+			p = path.Join(sharePath, p)
+		}
+		if !d.canShare(p) {
+			return http.StatusForbidden, nil
+		}
+	}
 
-	var expire int64 = 0
+	// The uploads need the permission to create files in a directory.
+	if body.Upload != nil {
+		if len(paths) != 0 {
+			return http.StatusBadRequest, fberrors.ErrInvalidRequestParams
+		}
+		if !d.CheckPerm(sharePath, rules.PermCreate) {
+			return http.StatusForbidden, nil
+		}
 
-	if body.Expires != "" {
-		num, err := strconv.Atoi(body.Expires)
+		info, err := d.user.Fs.Stat(sharePath)
 		if err != nil {
-			return http.StatusInternalServerError, err
+			return errToStatus(err), err
 		}
+		if !info.IsDir() {
+			return http.StatusBadRequest, fberrors.ErrInvalidRequestParams
+		}
+
+		body.Upload.Clean()
+	}
 
-		var add time.Duration
-		switch body.Unit {
-		case "seconds":
-			add = time.Second * time.Duration(num)
-		case "minutes":
-			add = time.Minute * time.Duration(num)
-		case "days":
-			add = time.Hour * 24 * time.Duration(num)
-		default:
-			add = time.Hour * time.Duration(num)
+	str := body.Slug
+	if str != "" {
+		if err := share.ValidateSlug(str); err != nil {
+			return http.StatusBadRequest, err
 		}
+	} else {
+		bytes := make([]byte, 6)
+		_, err = rand.Read(bytes)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		str = base64.URLEncoding.EncodeToString(bytes)
+	}
 
-		expire = time.Now().Add(add).Unix()
+	expire, err := getShareExpire(body.Expires, body.Unit)
+	if err != nil {
+		return http.StatusBadRequest, err
 	}
 
 	hash, status, err := getSharePasswordHash(body)
@@ -136,31 +178,141 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		return status, err
 	}
 
-	var token string
-	if len(hash) > 0 {
-		tokenBuffer := make([]byte, 96)
-		if _, err := rand.Read(tokenBuffer); err != nil {
-			return http.StatusInternalServerError, err
-		}
-		token = base64.URLEncoding.EncodeToString(tokenBuffer)
+	token, err := getShareToken(hash)
+	if err != nil {
+		return http.StatusInternalServerError, err
 	}
 
 	s = &share.Link{
-		Path:         r.URL.Path,
+		Path:         sharePath,
+		Paths:        paths,
 		Hash:         str,
 		Expire:       expire,
 		UserID:       d.user.ID,
 		PasswordHash: string(hash),
 		Token:        token,
+		Upload:       body.Upload,
+		MaxDownloads: body.MaxDownloads,
+		Perm:         body.Perm,
 	}
 
-	if err := d.store.Share.Save(s); err != nil {
-		return http.StatusInternalServerError, err
+	// The random hashes are checked like the slugs, although they're
+	// unlikely to be taken.
+	err = d.store.Share.Create(s)
+	d.audit(r, "share_create", s.Path, "", s.Hash, err)
+	if err != nil {
+		return errToStatus(err), err
 	}
 
 	return renderJSON(w, r, s)
 })
 
+var sharePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	hash := strings.Trim(r.URL.Path, "/")
+	if hash == "" || r.Body == nil {
+		return http.StatusBadRequest, nil
+	}
+
+	var body share.UpdateBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err)
+	}
+
+	link, err := d.store.Share.GetByHash(hash)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if !d.canManageLink(link) {
+		return http.StatusForbidden, nil
+	}
+
+	// The password is hashed before the link is locked, since it's slow.
+	var passwordHash []byte
+	var token string
+	if body.Password != nil {
+		var status int
+		passwordHash, status, err = getSharePasswordHash(share.CreateBody{Password: *body.Password})
+		if err != nil {
+			return status, err
+		}
+		if token, err = getShareToken(passwordHash); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	var expire int64
+	if body.Expires != nil {
+		if expire, err = getShareExpire(*body.Expires, body.Unit); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
+	updated, err := d.store.Share.Update(hash, func(l *share.Link) error {
+		if body.Password != nil {
+			l.PasswordHash = string(passwordHash)
+			l.Token = token
+		}
+		if body.Expires != nil {
+			l.Expire = expire
+		}
+		if body.MaxDownloads != nil {
+			l.MaxDownloads = *body.MaxDownloads
+		}
+		if body.Perm != nil {
+			l.Perm = body.Perm
+		}
+		return nil
+	})
+	d.audit(r, "share_update", link.Path, "", hash, err)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, updated)
+})
+
+// getShareExpire returns the time at which a link expires, after a
+// number of units of time, or 0 if it doesn't.
+func getShareExpire(expires, unit string) (int64, error) {
+	if expires == "" {
+		return 0, nil
+	}
+
+	num, err := strconv.Atoi(expires)
+	if err != nil {
+		return 0, err
+	}
+
+	var add time.Duration
+	switch unit {
+	case "seconds":
+		add = time.Second * time.Duration(num)
+	case "minutes":
+		add = time.Minute * time.Duration(num)
+	case "days":
+		add = time.Hour * 24 * time.Duration(num)
+	default:
+		add = time.Hour * time.Duration(num)
+	}
+
+	return time.Now().Add(add).Unix(), nil
+}
+
+// getShareToken returns the token of the download links of a link with
+// a password, which lets them be opened without it.
+func getShareToken(passwordHash []byte) (string, error) {
+	if len(passwordHash) == 0 {
+		return "", nil
+	}
+
+	tokenBuffer := make([]byte, 96)
+	if _, err := rand.Read(tokenBuffer); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(tokenBuffer), nil
+}
+
 func getSharePasswordHash(body share.CreateBody) (data []byte, statuscode int, err error) {
 	if body.Password == "" {
 		return nil, 0, nil
@@ -173,3 +325,47 @@ func getSharePasswordHash(body share.CreateBody) (data []byte, statuscode int, e
 
 	return hash, 0, nil
 }
+
+// sharedPaths returns the path of a share and, if several files are
+// shared together, their paths relative to it, which is then their
+// common directory.
+func sharedPaths(d *data, urlPath string, list []string) (string, []string, error) {
+	if len(list) == 0 {
+		return urlPath, nil, nil
+	}
+
+	var clean []string
+	for _, p := range list {
+		p = slashClean(p)
+		if slices.Contains(clean, p) {
+			continue
+		}
+		if p == "/" || !d.Check(p) {
+			return "", nil, fberrors.ErrInvalidRequestParams
+		}
+		if _, err := d.user.Fs.Stat(p); err != nil {
+			return "", nil, err
+		}
+
+		clean = append(clean, p)
+	}
+
+	if len(clean) == 1 {
+		return clean[0], nil, nil
+	}
+
+	base, paths := share.CommonDir(clean)
+	return base, paths, nil
+}
+
+// moveMetadata updates what is kept by path, like the share links and
+// the versions, after src was renamed or moved to dst. The files were
+// already moved, so the errors are only logged.
+func moveMetadata(d *data, src, dst string) {
+	if err := d.store.Share.UpdatePathPrefix(src, dst, d.user.ID); err != nil {
+		log.Printf("WARNING: Error(s) occurred while moving associated shares with file: %s", err)
+	}
+	if err := versions.Move(d.user, src, dst, d.settings.DirMode); err != nil {
+		log.Printf("WARNING: Error occurred while moving the versions of %s: %v", src, err)
+	}
+}
@@ -0,0 +1,297 @@
+package fbhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func newShareStorage(t *testing.T) (*storage.Storage, string) {
+	t.Helper()
+
+	return newShareStorageFor(t, &users.User{Username: "username", Password: "pw", Perm: users.Permissions{Share: true, Download: true, Rename: true}})
+}
+
+func newShareStorageFor(t *testing.T, user *users.User) (*storage.Storage, string) {
+	t.Helper()
+
+	st := newTestStorage(t)
+	if err := st.Users.Save(user); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	set, err := st.Settings.Get()
+	if err != nil {
+		t.Fatalf("failed to get settings: %v", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"/docs/a.txt", "/docs/b.txt", "/private/c.txt"} {
+		if err := afero.WriteFile(fs, name, []byte("content"), 0o640); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	st.Users = &customFSUser{Store: st.Users, fs: fs}
+
+	req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to construct request: %v", err)
+	}
+	signed, err := signToken(req, &data{store: st, settings: set, server: &settings.Server{}}, user, DefaultTokenExpirationTime)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return st, signed
+}
+
+func TestShareSlugs(t *testing.T) {
+	t.Parallel()
+
+	st, signed := newShareStorage(t)
+	if err := st.Share.Save(&share.Link{Hash: "taken", Path: "/docs/b.txt", UserID: 1}); err != nil {
+		t.Fatalf("failed to save share: %v", err)
+	}
+
+	testCases := map[string]struct {
+		body               string
+		expectedStatusCode int
+	}{
+		"Random hash":       {`{}`, http.StatusOK},
+		"Slug":              {`{"slug":"quarterly-report"}`, http.StatusOK},
+		"Short slug, 400":   {`{"slug":"ab"}`, http.StatusBadRequest},
+		"Slug path, 400":    {`{"slug":"a/b/c"}`, http.StatusBadRequest},
+		"Taken slug, 409":   {`{"slug":"taken"}`, http.StatusConflict},
+		"Preview only":      {`{"perm":{"download":false}}`, http.StatusOK},
+		"Invalid expiry":    {`{"expires":"soon"}`, http.StatusBadRequest},
+		"Several files":     {`{"paths":["/docs/a.txt","/docs/b.txt"]}`, http.StatusOK},
+		"Missing file, 404": {`{"paths":["/docs/a.txt","/docs/c.txt"]}`, http.StatusNotFound},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/docs/a.txt", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.Header.Set("X-Auth", signed)
+
+			recorder := httptest.NewRecorder()
+			handle(sharePostHandler, "", st, &settings.Server{}).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestSharePut(t *testing.T) {
+	t.Parallel()
+
+	st, signed := newShareStorage(t)
+	if err := st.Share.Save(&share.Link{Hash: "h", Path: "/docs", UserID: 1, Expire: 1 << 40}); err != nil {
+		t.Fatalf("failed to save share: %v", err)
+	}
+
+	serve := func(handler handleFunc, method, target, body string, header http.Header) int {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		recorder := httptest.NewRecorder()
+		handle(handler, "", st, &settings.Server{}).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	auth := http.Header{"X-Auth": {signed}}
+
+	body := `{"password":"secret","expires":"","perm":{"download":true,"archive":false}}`
+	if code := serve(sharePutHandler, http.MethodPut, "/h", body, auth); code != http.StatusOK {
+		t.Fatalf("expected status code 200, got status code %d", code)
+	}
+
+	link, err := st.Share.GetByHash("h")
+	if err != nil {
+		t.Fatalf("failed to get share: %v", err)
+	}
+	if link.PasswordHash == "" || link.Token == "" || link.Expire != 0 || link.Path != "/docs" {
+		t.Fatalf("the link wasn't updated: %+v", link)
+	}
+
+	password := http.Header{"X-Share-Password": {"secret"}}
+	steps := []struct {
+		target             string
+		header             http.Header
+		expectedStatusCode int
+	}{
+		{"h/a.txt", nil, http.StatusUnauthorized},
+		{"h/a.txt", password, http.StatusOK},
+		{"h", password, http.StatusForbidden},
+	}
+	for _, step := range steps {
+		if code := serve(publicDlHandler, http.MethodGet, step.target, "", step.header); code != step.expectedStatusCode {
+			t.Errorf("%s: expected status code %d, got status code %d", step.target, step.expectedStatusCode, code)
+		}
+	}
+
+	if code := serve(sharePutHandler, http.MethodPut, "/h", `{"perm":{"download":false}}`, auth); code != http.StatusOK {
+		t.Fatalf("expected status code 200, got status code %d", code)
+	}
+	if code := serve(publicDlHandler, http.MethodGet, "h/a.txt", "", password); code != http.StatusForbidden {
+		t.Errorf("expected status code 403 for a download, got status code %d", code)
+	}
+	if code := serve(publicDlHandler, http.MethodGet, "h/a.txt?inline=true", "", password); code != http.StatusForbidden {
+		t.Errorf("expected status code 403 for an inline download, got status code %d", code)
+	}
+}
+
+func TestShareMove(t *testing.T) {
+	t.Parallel()
+
+	st, signed := newShareStorage(t)
+	for _, link := range []*share.Link{
+		{Hash: "file", Path: "/docs/a.txt"},
+		{Hash: "dir", Path: "/docs"},
+		{Hash: "several", Path: "/docs", Paths: []string{"/a.txt", "/b.txt"}},
+		{Hash: "sibling", Path: "/docsx"},
+		{Hash: "other", Path: "/docs/a.txt", UserID: 2},
+	} {
+		if link.UserID == 0 {
+			link.UserID = 1
+		}
+		if err := st.Share.Save(link); err != nil {
+			t.Fatalf("failed to save share: %v", err)
+		}
+	}
+
+	steps := []struct {
+		src, dst string
+		expected map[string]string
+	}{
+		{"/docs/a.txt", "/docs/c.txt", map[string]string{
+			"file":    "/docs/c.txt",
+			"dir":     "/docs",
+			"several": "/docs [/c.txt /b.txt]",
+			"sibling": "/docsx",
+			"other":   "/docs/a.txt",
+		}},
+		{"/docs", "/old", map[string]string{
+			"file":    "/old/c.txt",
+			"dir":     "/old",
+			"several": "/old [/c.txt /b.txt]",
+			"sibling": "/docsx",
+			"other":   "/docs/a.txt",
+		}},
+		{"/old/b.txt", "/b.txt", map[string]string{
+			"file":    "/old/c.txt",
+			"dir":     "/old",
+			"several": "/ [/old/c.txt /b.txt]",
+			"sibling": "/docsx",
+			"other":   "/docs/a.txt",
+		}},
+	}
+
+	handler := handle(resourcePatchHandler(diskcache.NewNoOp(), nil), "", st, &settings.Server{})
+	for _, step := range steps {
+		req, err := http.NewRequest(http.MethodPatch, step.src+"?action=rename&destination="+step.dst, http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		req.Header.Set("X-Auth", signed)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected status code 200, got status code %d", step.src, recorder.Code)
+		}
+
+		for hash, expected := range step.expected {
+			link, err := st.Share.GetByHash(hash)
+			if err != nil {
+				t.Fatalf("failed to get share: %v", err)
+			}
+
+			got := link.Path
+			if len(link.Paths) > 0 {
+				got = fmt.Sprintf("%s %v", link.Path, link.Paths)
+			}
+			if got != expected {
+				t.Errorf("%s: expected %s to be %q, got %q", step.src, hash, expected, got)
+			}
+		}
+	}
+}
+
+func TestShareRuleGrants(t *testing.T) {
+	t.Parallel()
+
+	st, signed := newShareStorageFor(t, &users.User{
+		Username: "username",
+		Password: "pw",
+		Perm:     users.Permissions{Download: true},
+		Rules:    []rules.Rule{{Path: "/docs", Grant: []rules.Perm{rules.PermShare}}},
+	})
+	for _, link := range []*share.Link{
+		{Hash: "docs", Path: "/docs/a.txt", UserID: 1},
+		{Hash: "private", Path: "/private/c.txt", UserID: 1},
+	} {
+		if err := st.Share.Save(link); err != nil {
+			t.Fatalf("failed to save share: %v", err)
+		}
+	}
+
+	serve := func(handler handleFunc, method, target, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to construct request: %v", err)
+		}
+		req.Header.Set("X-Auth", signed)
+
+		recorder := httptest.NewRecorder()
+		handle(handler, "", st, &settings.Server{}).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := serve(shareListHandler, http.MethodGet, "/", "")
+	var list []*share.Link
+	if err := json.NewDecoder(recorder.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode the links: %v", err)
+	}
+	if len(list) != 1 || list[0].Hash != "docs" {
+		t.Errorf("expected only the link of /docs/a.txt, got %v", list)
+	}
+
+	steps := []struct {
+		name               string
+		handler            handleFunc
+		method             string
+		target             string
+		expectedStatusCode int
+	}{
+		{"get the links of a shareable path", shareGetsHandler, http.MethodGet, "/docs/a.txt", http.StatusOK},
+		{"get the links of another path", shareGetsHandler, http.MethodGet, "/private/c.txt", http.StatusForbidden},
+		{"update a link of a shareable path", sharePutHandler, http.MethodPut, "/docs", http.StatusOK},
+		{"update a link of another path", sharePutHandler, http.MethodPut, "/private", http.StatusForbidden},
+		{"delete a link of a shareable path", shareDeleteHandler, http.MethodDelete, "/docs", http.StatusOK},
+		{"delete a link of another path", shareDeleteHandler, http.MethodDelete, "/private", http.StatusForbidden},
+	}
+	for _, step := range steps {
+		if code := serve(step.handler, step.method, step.target, "{}").Code; code != step.expectedStatusCode {
+			t.Errorf("%s: expected status code %d, got status code %d", step.name, step.expectedStatusCode, code)
+		}
+	}
+}
@@ -54,6 +54,7 @@ func handleWithStaticData(w http.ResponseWriter, _ *http.Request, d *data, fSys
 		"EnableExec":            d.server.EnableExec,
 		"TusSettings":           d.settings.Tus,
 		"HideLoginButton":       d.settings.HideLoginButton,
+		"TwoFactorRequired":     d.settings.TwoFactorRequired && d.settings.AuthMethod == auth.MethodJSONAuth,
 	}
 
 	if d.settings.Branding.Files != "" {
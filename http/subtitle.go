@@ -8,17 +8,18 @@ import (
 	"github.com/asticode/go-astisub"
 
 	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
 )
 
 var subtitleHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	if !d.user.Perm.Download {
+	if !d.CheckPerm(r.URL.Path, rules.PermDownload) {
 		return http.StatusAccepted, nil
 	}
 
 	file, err := files.NewFileInfo(&files.FileOptions{
 		Fs:         d.user.Fs,
 		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
+		Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 		Expand:     false,
 		ReadHeader: d.server.TypeDetectionByHeader,
 		Checker:    d,
@@ -0,0 +1,75 @@
+package fbhttp
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/filebrowser/filebrowser/v2/settings"
+)
+
+// withRateLimit limits the requests of each client to the rate given by
+// the settings. The requests over it get a 429 response.
+func withRateLimit(name string, rate func(*settings.Limits) uint, fn handleFunc) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		ok, retry := d.store.Limiter.Allow(name+":"+d.clientIP(r), rate(&d.settings.Limits))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			return http.StatusTooManyRequests, nil
+		}
+
+		return fn(w, r, d)
+	}
+}
+
+// throttleUser limits the bandwidth of a request of the current user.
+func (d *data) throttleUser(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	return d.throttle(w, r, "user:"+strconv.FormatUint(uint64(d.user.ID), 10), d.settings.Limits.UserBandwidth)
+}
+
+// throttle limits the bandwidth of the body of a request and of its
+// response to the one of a key, shared by its concurrent requests.
+func (d *data) throttle(w http.ResponseWriter, r *http.Request, key string, bytesPerSecond uint64) http.ResponseWriter {
+	if d.store.Limiter == nil || bytesPerSecond == 0 {
+		return w
+	}
+
+	if r.Body != nil {
+		r.Body = &throttledBody{
+			Reader: d.store.Limiter.Reader(r.Context(), key, bytesPerSecond, r.Body),
+			Closer: r.Body,
+		}
+	}
+
+	return &throttledWriter{
+		ResponseWriter: w,
+		w:              d.store.Limiter.Writer(r.Context(), key, bytesPerSecond, w),
+	}
+}
+
+type throttledBody struct {
+	io.Reader
+	io.Closer
+}
+
+type throttledWriter struct {
+	http.ResponseWriter
+	w io.Writer
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (t *throttledWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// Flush sends the data written so far, like the streamed search results.
+func (t *throttledWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,82 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	if err := storage.Settings.Save(&settings.Settings{
+		Key:    []byte("key"),
+		Limits: settings.Limits{PublicRate: 2},
+	}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+
+	ok := func(_ http.ResponseWriter, _ *http.Request, _ *data) (int, error) {
+		return http.StatusOK, nil
+	}
+	handler := handle(withRateLimit("public", func(l *settings.Limits) uint { return l.PublicRate }, ok), "", storage, &settings.Server{})
+
+	testCases := []struct {
+		remoteAddr         string
+		forwardedFor       string
+		expectedStatusCode int
+	}{
+		{"10.0.0.1:1234", "", http.StatusOK},
+		{"10.0.0.1:1234", "", http.StatusOK},
+		{"10.0.0.1:1234", "", http.StatusTooManyRequests},
+		{"10.0.0.1:1234", "203.0.113.3", http.StatusTooManyRequests},
+		{"10.0.0.2:1234", "", http.StatusOK},
+	}
+
+	for i, tc := range testCases {
+		req := newHTTPRequest(t, func(r *http.Request) {
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+		})
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != tc.expectedStatusCode {
+			t.Fatalf("request %d: expected status code %d, got status code %d", i, tc.expectedStatusCode, recorder.Code)
+		}
+		if tc.expectedStatusCode == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") != "30" {
+			t.Fatalf("request %d: got Retry-After %q, want 30", i, recorder.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestThrottledWriterFlush(t *testing.T) {
+	t.Parallel()
+
+	d := &data{
+		store:    newTestStorage(t),
+		settings: &settings.Settings{Limits: settings.Limits{UserBandwidth: 1 << 20}},
+		user:     &users.User{ID: 1},
+	}
+	recorder := httptest.NewRecorder()
+	w := d.throttleUser(recorder, newHTTPRequest(t))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("expected the throttled writer to be a flusher")
+	}
+	if _, err := w.Write([]byte("result")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	flusher.Flush()
+
+	if !recorder.Flushed || recorder.Body.String() != "result" {
+		t.Errorf("expected the result to be flushed, got %q", recorder.Body)
+	}
+}
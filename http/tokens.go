@@ -0,0 +1,158 @@
+package fbhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/filebrowser/filebrowser/v2/auth"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+type modifyTokenRequest struct {
+	modifyRequest
+	Data *tokens.Token `json:"data"`
+}
+
+// createdToken is a new token, along with its secret, which is only
+// shown once.
+type createdToken struct {
+	*tokens.Token
+	Secret string `json:"token"`
+}
+
+// bearerToken returns the token of the Authorization header.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// apiToken returns the personal API token of a request, if it has one.
+func apiToken(r *http.Request) string {
+	for _, token := range []string{bearerToken(r), r.Header.Get("X-Auth")} {
+		if tokens.IsToken(token) {
+			return token
+		}
+	}
+	return ""
+}
+
+// authenticateToken authenticates the user of a personal API token,
+// whose permissions are restricted to the scope of the token.
+func authenticateToken(d *data, secret string) (int, error) {
+	token, err := d.store.Tokens.Authenticate(secret)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return http.StatusUnauthorized, nil
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	d.user, err = d.store.Users.Get(d.server.Root, token.UserID)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return http.StatusUnauthorized, nil
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := d.store.Groups.Apply(d.user); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	token.Restrict(&d.user.Perm)
+	d.token = token
+	return 0, nil
+}
+
+var tokensGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	list, err := d.store.Tokens.FindByUserID(d.user.ID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	for _, token := range list {
+		token.Hash = ""
+	}
+
+	return renderJSON(w, r, list)
+})
+
+var tokenPostHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	// A token can't create tokens, which could outlive or outscope it.
+	if d.token != nil {
+		return http.StatusForbidden, nil
+	}
+
+	if r.Body == nil {
+		return http.StatusBadRequest, fberrors.ErrEmptyRequest
+	}
+
+	req := &modifyTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.What != "token" || req.Data == nil {
+		return http.StatusBadRequest, fberrors.ErrInvalidDataType
+	}
+
+	if d.settings.AuthMethod == auth.MethodJSONAuth && !users.CheckPwd(req.CurrentPassword, d.user.Password) {
+		return http.StatusBadRequest, fberrors.ErrCurrentPasswordIncorrect
+	}
+
+	token := &tokens.Token{
+		UserID:  d.user.ID,
+		Name:    req.Data.Name,
+		Perm:    req.Data.Perm,
+		Expires: req.Data.Expires,
+	}
+	secret, err := d.store.Tokens.Create(token)
+	d.audit(r, "token_create", "", "", token.Name, err)
+	if errors.Is(err, fberrors.ErrEmptyTokenName) {
+		return http.StatusBadRequest, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	token.Hash = ""
+	return renderJSON(w, r, createdToken{Token: token, Secret: secret})
+})
+
+var tokenDeleteHandler = withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	token, err := d.store.Tokens.Get(uint(id))
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	// The admins can revoke the tokens of the other users.
+	if token.UserID != d.user.ID && !d.user.Perm.Admin {
+		return http.StatusForbidden, nil
+	}
+
+	err = d.store.Tokens.Delete(token.ID)
+	d.audit(r, "token_delete", "", "", token.Name, err)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
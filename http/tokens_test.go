@@ -0,0 +1,75 @@
+package fbhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestAPITokens(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	user := &users.User{Username: "username", Password: "pw", Perm: users.Permissions{Delete: true, Download: true}}
+	if err := storage.Users.Save(user); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	create := func(token *tokens.Token) string {
+		token.UserID = user.ID
+		secret, err := storage.Tokens.Create(token)
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		return secret
+	}
+	full := create(&tokens.Token{Name: "full"})
+	scoped := create(&tokens.Token{Name: "scoped", Perm: &users.Permissions{Download: true}})
+	expired := create(&tokens.Token{Name: "expired", Expires: time.Now().Add(-time.Minute).Unix()})
+
+	canDelete := withUser(func(_ http.ResponseWriter, _ *http.Request, d *data) (int, error) {
+		if !d.CheckPerm("/file", rules.PermDelete) {
+			return http.StatusForbidden, nil
+		}
+		return http.StatusOK, nil
+	})
+
+	testCases := map[string]struct {
+		handler            handleFunc
+		header             string
+		value              string
+		expectedStatusCode int
+	}{
+		"Bearer token":                     {canDelete, "Authorization", "Bearer " + full, http.StatusOK},
+		"X-Auth token":                     {canDelete, "X-Auth", full, http.StatusOK},
+		"Scoped token, 403":                {canDelete, "Authorization", "Bearer " + scoped, http.StatusForbidden},
+		"Expired token, 401":               {canDelete, "Authorization", "Bearer " + expired, http.StatusUnauthorized},
+		"Unknown token, 401":               {canDelete, "Authorization", "Bearer " + tokens.Prefix + "unknown", http.StatusUnauthorized},
+		"Token exchanged for session, 403": {renewHandler(time.Hour), "Authorization", "Bearer " + full, http.StatusForbidden},
+		"Token creating a token, 403":      {tokenPostHandler, "Authorization", "Bearer " + full, http.StatusForbidden},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"what":"token","data":{"name":"new"}}`))
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.Header.Set(tc.header, tc.value)
+
+			recorder := httptest.NewRecorder()
+			handle(tc.handler, "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+		})
+	}
+}
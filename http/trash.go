@@ -0,0 +1,92 @@
+package fbhttp
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/trash"
+)
+
+func withTrashItem(manager *trash.Manager, fn func(w http.ResponseWriter, r *http.Request, d *data, item *trash.Item) (int, error)) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		item, err := manager.Get(d.user, mux.Vars(r)["id"])
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if !d.Check(item.TrashPath) {
+			return http.StatusForbidden, nil
+		}
+
+		return fn(w, r, d, item)
+	})
+}
+
+func trashListHandler(manager *trash.Manager) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		list, err := manager.List(d.user)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		return renderJSON(w, r, list)
+	})
+}
+
+func trashRestoreHandler(manager *trash.Manager) handleFunc {
+	return withTrashItem(manager, func(_ http.ResponseWriter, _ *http.Request, d *data, item *trash.Item) (int, error) {
+		if !d.CheckPerm(item.Path, rules.PermCreate) || !d.Check(item.Path) {
+			return http.StatusForbidden, nil
+		}
+
+		err := d.RunHook(func() error {
+			return manager.Restore(d.user, item, d.settings.FileMode, d.settings.DirMode)
+		}, "rename", item.TrashPath, item.Path, d.user)
+		updateIndex(d, item.TrashPath, item.Path)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return http.StatusNoContent, nil
+	})
+}
+
+func trashDeleteHandler(manager *trash.Manager) handleFunc {
+	return withTrashItem(manager, func(_ http.ResponseWriter, _ *http.Request, d *data, item *trash.Item) (int, error) {
+		if !d.CheckPerm(item.Path, rules.PermDelete) {
+			return http.StatusForbidden, nil
+		}
+
+		err := d.RunHook(d.tracked(item.TrashPath, func() error {
+			return manager.Delete(d.user, item)
+		}), "delete", item.TrashPath, "", d.user)
+		updateIndex(d, item.TrashPath)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return http.StatusNoContent, nil
+	})
+}
+
+func trashEmptyHandler(manager *trash.Manager) handleFunc {
+	return withUser(func(_ http.ResponseWriter, _ *http.Request, d *data) (int, error) {
+		if d.user.TrashDir == "" || !d.Check(d.user.TrashDir) {
+			return http.StatusForbidden, nil
+		}
+
+		err := d.RunHook(d.tracked(d.user.TrashDir, func() error {
+			return manager.Empty(d.user, func(p string) bool {
+				return d.CheckPerm(p, rules.PermDelete)
+			})
+		}), "delete", d.user.TrashDir, "", d.user)
+		updateIndex(d, d.user.TrashDir)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return http.StatusNoContent, nil
+	})
+}
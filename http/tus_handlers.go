@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -13,7 +15,11 @@ import (
 
 	"github.com/spf13/afero"
 
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/versions"
 )
 
 // keepUploadActive periodically touches the cache entry to prevent eviction during transfer
@@ -40,14 +46,19 @@ func keepUploadActive(cache UploadCache, filePath string) func() {
 }
 
 func tusPostHandler(cache UploadCache) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if !d.user.Perm.Create || !d.Check(r.URL.Path) {
+	return withUser(tusPost(cache))
+}
+
+// tusPost creates an upload, either by a user or by a visitor of a share.
+func tusPost(cache UploadCache) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.CheckPerm(r.URL.Path, rules.PermCreate) || !d.Check(r.URL.Path) {
 			return http.StatusForbidden, nil
 		}
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -72,20 +83,59 @@ func tusPostHandler(cache UploadCache) handleFunc {
 				return http.StatusBadRequest, fmt.Errorf("cannot upload to a directory %s", file.RealPath())
 			}
 
-			// Existing files will remain untouched unless explicitly instructed to override
-			if r.URL.Query().Get("override") != "true" {
+			// Existing files will remain untouched unless explicitly instructed to override.
+			// The visitors of a share can't override the files of its owner.
+			if r.URL.Query().Get("override") != "true" || d.link != nil {
 				return http.StatusConflict, nil
 			}
 
 			// Permission for overwriting the file
-			if !d.user.Perm.Modify {
+			if !d.CheckPerm(r.URL.Path, rules.PermModify) {
 				return http.StatusForbidden, nil
 			}
 
+			if err := versions.Save(d.user, r.URL.Path, d.settings.FileMode, d.settings.DirMode); err != nil {
+				return errToStatus(err), err
+			}
+
 			fileFlags |= os.O_TRUNC
 		}
 
-		openFile, err := d.user.Fs.OpenFile(r.URL.Path, fileFlags, d.settings.FileMode)
+		uploadLength, err := getUploadLength(r)
+		if err != nil || uploadLength < 0 {
+			return http.StatusBadRequest, fmt.Errorf("invalid upload length: %w", err)
+		}
+
+		// The chunks reserve their own space as they're written.
+		res, err := reserveQuota(d, r.URL.Path, uploadLength)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		defer res.Release()
+
+		// The upload is counted right away, so that the concurrent ones
+		// can't go past the limits of the share.
+		if d.link != nil {
+			_, err := d.store.Share.Update(d.link.Hash, func(l *share.Link) error {
+				if l.Upload == nil {
+					return fberrors.ErrPermissionDenied
+				}
+				if err := l.Upload.Allows(r.URL.Path, uploadLength); err != nil {
+					return err
+				}
+				l.Upload.Count++
+				return nil
+			})
+			if err != nil {
+				return errToStatus(err), err
+			}
+		}
+
+		var openFile afero.File
+		err = d.tracked(r.URL.Path, func() error {
+			openFile, err = d.user.Fs.OpenFile(r.URL.Path, fileFlags, d.settings.FileMode)
+			return err
+		})()
 		if err != nil {
 			return errToStatus(err), err
 		}
@@ -94,7 +144,7 @@ func tusPostHandler(cache UploadCache) handleFunc {
 		file, err = files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: false,
 			Checker:    d,
@@ -104,35 +154,36 @@ func tusPostHandler(cache UploadCache) handleFunc {
 			return errToStatus(err), err
 		}
 
-		uploadLength, err := getUploadLength(r)
-		if err != nil || uploadLength < 0 {
-			return http.StatusBadRequest, fmt.Errorf("invalid upload length: %w", err)
-		}
-
 		// Enables the user to utilize the PATCH endpoint for uploading file data
 		cache.Register(file.RealPath(), uploadLength)
+		updateIndex(d, r.URL.Path)
 
 		basePath := "/" + strings.Trim(strings.TrimSpace(d.server.BaseURL), "/")
 		if basePath == "/" {
 			basePath = ""
 		}
 
-		w.Header().Set("Location", basePath+"/api/tus"+r.URL.EscapedPath())
+		w.Header().Set("Location", basePath+tusLocation(r, d))
 		return http.StatusCreated, nil
-	})
+	}
 }
 
 func tusHeadHandler(cache UploadCache) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(tusHead(cache))
+}
+
+// tusHead gets the offset of an upload.
+func tusHead(cache UploadCache) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		w.Header().Set("Cache-Control", "no-store")
-		if !d.user.Perm.Create || !d.Check(r.URL.Path) {
+		if !d.CheckPerm(r.URL.Path, rules.PermCreate) || !d.Check(r.URL.Path) {
 			return http.StatusForbidden, nil
 		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -150,12 +201,17 @@ func tusHeadHandler(cache UploadCache) handleFunc {
 		w.Header().Set("Upload-Length", strconv.FormatInt(uploadLength, 10))
 
 		return http.StatusOK, nil
-	})
+	}
 }
 
 func tusPatchHandler(cache UploadCache) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if !d.user.Perm.Create || !d.Check(r.URL.Path) {
+	return withUser(tusPatch(cache))
+}
+
+// tusPatch appends a chunk to an upload.
+func tusPatch(cache UploadCache) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.CheckPerm(r.URL.Path, rules.PermCreate) || !d.Check(r.URL.Path) {
 			return http.StatusForbidden, nil
 		}
 		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
@@ -170,7 +226,7 @@ func tusPatchHandler(cache UploadCache) handleFunc {
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -214,8 +270,28 @@ func tusPatchHandler(cache UploadCache) handleFunc {
 			return http.StatusInternalServerError, fmt.Errorf("could not seek file: %w", err)
 		}
 
+		res, err := d.store.Quotas.Reserve(d.user, r.URL.Path, max(r.ContentLength, 0), 0, 0)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		defer res.Release()
+
 		defer r.Body.Close()
-		bytesWritten, err := io.Copy(openFile, r.Body)
+		var body io.Reader = r.Body
+		if d.link != nil {
+			// The visitors of a share can't write past the length they
+			// declared, which was checked against the limits of the share.
+			body = io.LimitReader(body, uploadLength-uploadOffset)
+		}
+
+		var bytesWritten int64
+		err = d.tracked(r.URL.Path, func() error {
+			bytesWritten, err = io.Copy(openFile, res.Reader(body))
+			return err
+		})()
+		if errors.Is(err, fberrors.ErrQuotaExceeded) {
+			return errToStatus(err), err
+		}
 		if err != nil {
 			return http.StatusInternalServerError, fmt.Errorf("could not write to file: %w", err)
 		}
@@ -231,23 +307,24 @@ func tusPatchHandler(cache UploadCache) handleFunc {
 
 		if newOffset >= uploadLength {
 			cache.Complete(file.RealPath())
+			updateIndex(d, r.URL.Path)
 			_ = d.RunHook(func() error { return nil }, "upload", r.URL.Path, "", d.user)
 		}
 
 		return http.StatusNoContent, nil
-	})
+	}
 }
 
 func tusDeleteHandler(cache UploadCache) handleFunc {
 	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if r.URL.Path == "/" || !d.user.Perm.Delete {
+		if r.URL.Path == "/" || !d.checkPermTree(r.URL.Path, rules.PermDelete) {
 			return http.StatusForbidden, nil
 		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
+			Modify:     d.CheckPerm(r.URL.Path, rules.PermModify),
 			Expand:     false,
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
@@ -261,7 +338,10 @@ func tusDeleteHandler(cache UploadCache) handleFunc {
 			return http.StatusNotFound, err
 		}
 
-		err = d.user.Fs.RemoveAll(r.URL.Path)
+		err = d.tracked(r.URL.Path, func() error {
+			return d.user.Fs.RemoveAll(r.URL.Path)
+		})()
+		updateIndex(d, r.URL.Path)
 		if err != nil {
 			return errToStatus(err), err
 		}
@@ -272,6 +352,18 @@ func tusDeleteHandler(cache UploadCache) handleFunc {
 	})
 }
 
+// tusLocation returns the URL of an upload, which is under the share
+// link for the visitors of a share.
+func tusLocation(r *http.Request, d *data) string {
+	if d.link == nil {
+		return "/api/tus" + r.URL.EscapedPath()
+	}
+
+	rel := strings.TrimPrefix(r.URL.Path, path.Clean(d.link.Path))
+	rel = "/" + strings.TrimPrefix(rel, "/")
+	return "/api/public/tus/" + d.link.Hash + (&url.URL{Path: rel}).EscapedPath()
+}
+
 func getUploadLength(r *http.Request) (int64, error) {
 	uploadOffset, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
 	if err != nil {
@@ -0,0 +1,220 @@
+package fbhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	fbAuth "github.com/filebrowser/filebrowser/v2/auth"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+type twoFactorBody struct {
+	Code string `json:"code"`
+}
+
+type twoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// twoFactorSetupRequired reports whether the user must set up the
+// two-factor authentication before using File Browser.
+func twoFactorSetupRequired(d *data) bool {
+	return d.settings.TwoFactorRequired &&
+		d.settings.AuthMethod == fbAuth.MethodJSONAuth &&
+		!d.user.TOTPEnabled
+}
+
+// checkTwoFactor verifies the second factor of a login, sent in the "otp"
+// field of the body along with the credentials. A 401 status with the
+// X-2FA-Required header asks the client to send it.
+func checkTwoFactor(w http.ResponseWriter, d *data, user *users.User, body []byte) (int, error) {
+	if d.settings.AuthMethod != fbAuth.MethodJSONAuth || !user.TOTPEnabled {
+		return 0, nil
+	}
+
+	var cred struct {
+		OTP string `json:"otp"`
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &cred); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
+	if cred.OTP == "" {
+		w.Header().Set("X-2FA-Required", "true")
+		return http.StatusUnauthorized, nil
+	}
+
+	ok, err := verifyTwoFactorCode(d, user, cred.OTP)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !ok {
+		return http.StatusForbidden, nil
+	}
+
+	return 0, nil
+}
+
+// verifyTwoFactorCode checks a TOTP or recovery code and saves the user
+// so that the code can't be used again.
+func verifyTwoFactorCode(d *data, user *users.User, code string) (bool, error) {
+	switch {
+	case user.CheckTOTP(code, time.Now()):
+		return true, d.store.Users.Update(user, "TOTPCounter")
+	case user.UseRecoveryCode(code):
+		return true, d.store.Users.Update(user, "RecoveryCodes")
+	default:
+		return false, nil
+	}
+}
+
+func withTwoFactorUser(fn handleFunc) handleFunc {
+	return withAuthenticatedUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if d.settings.AuthMethod != fbAuth.MethodJSONAuth {
+			return http.StatusNotFound, nil
+		}
+
+		return fn(w, r, d)
+	})
+}
+
+func getTwoFactorCode(r *http.Request) (string, error) {
+	if r.Body == nil {
+		return "", fberrors.ErrEmptyRequest
+	}
+
+	var body twoFactorBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	return body.Code, nil
+}
+
+// twoFactorSetupHandler generates a new secret, which is enabled
+// once a code generated from it is sent to twoFactorEnableHandler.
+var twoFactorSetupHandler = withTwoFactorUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if d.user.TOTPEnabled {
+		return http.StatusConflict, fberrors.ErrExist
+	}
+
+	secret, err := users.GenerateTOTPSecret()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	d.user.TOTPSecret = secret
+	if err := d.store.Users.Update(d.user, "TOTPSecret"); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	issuer := d.settings.Branding.Name
+	if issuer == "" {
+		issuer = "File Browser"
+	}
+
+	return renderJSON(w, r, &twoFactorSetup{
+		Secret: secret,
+		URI:    users.TOTPURI(issuer, d.user.Username, secret),
+	})
+})
+
+var twoFactorEnableHandler = withTwoFactorUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if d.user.TOTPEnabled {
+		return http.StatusConflict, fberrors.ErrExist
+	}
+
+	code, err := getTwoFactorCode(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if !d.user.CheckTOTP(code, time.Now()) {
+		return http.StatusForbidden, fberrors.ErrInvalidTwoFactorCode
+	}
+
+	codes, err := d.user.GenerateRecoveryCodes()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	d.user.TOTPEnabled = true
+	err = d.store.Users.Update(d.user, users.TwoFactorFields...)
+	d.audit(r, "2fa_enable", "", "", d.user.Username, err)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, &recoveryCodes{RecoveryCodes: codes})
+})
+
+var twoFactorRecoveryCodesHandler = withTwoFactorUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.TOTPEnabled {
+		return http.StatusNotFound, nil
+	}
+
+	code, err := getTwoFactorCode(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	ok, err := verifyTwoFactorCode(d, d.user, code)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !ok {
+		return http.StatusForbidden, fberrors.ErrInvalidTwoFactorCode
+	}
+
+	codes, err := d.user.GenerateRecoveryCodes()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := d.store.Users.Update(d.user, "RecoveryCodes"); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, &recoveryCodes{RecoveryCodes: codes})
+})
+
+var twoFactorDisableHandler = withTwoFactorUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.TOTPEnabled {
+		return http.StatusNotFound, nil
+	}
+
+	if d.settings.TwoFactorRequired {
+		return http.StatusForbidden, fberrors.ErrTwoFactorRequired
+	}
+
+	code, err := getTwoFactorCode(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	ok, err := verifyTwoFactorCode(d, d.user, code)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !ok {
+		return http.StatusForbidden, fberrors.ErrInvalidTwoFactorCode
+	}
+
+	d.user.ResetTwoFactor()
+	err = d.store.Users.Update(d.user, users.TwoFactorFields...)
+	d.audit(r, "2fa_disable", "", "", d.user.Username, err)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusNoContent, nil
+})
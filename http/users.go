@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,7 +21,7 @@ import (
 )
 
 var (
-	NonModifiableFieldsForNonAdmin = []string{"Username", "Scope", "LockPassword", "Perm", "Commands", "Rules"}
+	NonModifiableFieldsForNonAdmin = []string{"Username", "Scope", "LockPassword", "Perm", "Commands", "Rules", "Backend", "Mounts", "Groups", "Quota", "VersionsDir", "VersionsMaxCount", "VersionsMaxAge", "OIDCSubject"}
 )
 
 type modifyUserRequest struct {
@@ -54,6 +56,35 @@ func getUser(_ http.ResponseWriter, r *http.Request) (*modifyUserRequest, error)
 	return req, nil
 }
 
+// hideBackendSecrets removes the secret keys of the storage backends of
+// a user, which are never sent to the client.
+func hideBackendSecrets(u *users.User) {
+	u.Backend.S3.SecretKey = ""
+	for i := range u.Mounts {
+		u.Mounts[i].Backend.S3.SecretKey = ""
+	}
+}
+
+// keepBackendSecrets keeps the secret keys of the stored user which are
+// left empty in an update.
+func keepBackendSecrets(u, stored *users.User) {
+	if u.Backend.S3.SecretKey == "" {
+		u.Backend.S3.SecretKey = stored.Backend.S3.SecretKey
+	}
+
+	for i := range u.Mounts {
+		m := &u.Mounts[i]
+		if m.Backend.S3.SecretKey != "" {
+			continue
+		}
+		for _, sm := range stored.Mounts {
+			if sm.Path == path.Clean("/"+m.Path) {
+				m.Backend.S3.SecretKey = sm.Backend.S3.SecretKey
+			}
+		}
+	}
+}
+
 func withSelfOrAdmin(fn handleFunc) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		id, err := getUserID(r)
@@ -78,6 +109,9 @@ var usersGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *
 
 	for _, u := range users {
 		u.Password = ""
+		u.TOTPSecret = ""
+		u.RecoveryCodes = nil
+		hideBackendSecrets(u)
 	}
 
 	sort.Slice(users, func(i, j int) bool {
@@ -98,8 +132,14 @@ var userGetHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request
 	}
 
 	u.Password = ""
+	u.TOTPSecret = ""
+	u.RecoveryCodes = nil
+	hideBackendSecrets(u)
 	if !d.user.Perm.Admin {
 		u.Scope = ""
+		for i := range u.Mounts {
+			u.Mounts[i].Scope = ""
+		}
 	}
 	return renderJSON(w, r, u)
 })
@@ -124,6 +164,13 @@ var userDeleteHandler = withSelfOrAdmin(func(_ http.ResponseWriter, r *http.Requ
 	}
 
 	err := d.store.Users.Delete(d.raw.(uint))
+	if err == nil {
+		err = d.store.Tokens.DeleteByUserID(d.raw.(uint))
+	}
+	if err == nil {
+		err = d.store.Sessions.DeleteByUserID(d.raw.(uint))
+	}
+	d.audit(r, "user_delete", "", "", strconv.FormatUint(uint64(d.raw.(uint)), 10), err)
 	if err != nil {
 		return errToStatus(err), err
 	}
@@ -166,9 +213,11 @@ var userPostHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *
 		return http.StatusInternalServerError, err
 	}
 	req.Data.Scope = userHome
+	req.Data.ResetTwoFactor()
 	log.Printf("user: %s, home dir: [%s].", req.Data.Username, userHome)
 
 	err = d.store.Users.Save(req.Data)
+	d.audit(r, "user_create", "", "", req.Data.Username, err)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -192,6 +241,9 @@ var userPutHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request
 			"lockPassword": {},
 			"commands":     {},
 			"perm":         {},
+			"backend":      {},
+			"mounts":       {},
+			"groups":       {},
 		}
 
 		for _, field := range req.Which {
@@ -221,20 +273,31 @@ var userPutHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request
 			return http.StatusForbidden, nil
 		}
 
+		var suser *users.User
+		suser, err = d.store.Users.Get(d.server.Root, d.raw.(uint))
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
 		if req.Data.Password != "" {
 			req.Data.Password, err = users.ValidateAndHashPwd(req.Data.Password, d.settings.MinimumPasswordLength)
 			if err != nil {
 				return http.StatusBadRequest, err
 			}
 		} else {
-			var suser *users.User
-			suser, err = d.store.Users.Get(d.server.Root, d.raw.(uint))
-			if err != nil {
-				return http.StatusInternalServerError, err
-			}
 			req.Data.Password = suser.Password
 		}
 
+		keepBackendSecrets(req.Data, suser)
+
+		// The two-factor authentication is managed through its own endpoints.
+		req.Data.TOTPSecret = suser.TOTPSecret
+		req.Data.TOTPEnabled = suser.TOTPEnabled
+		req.Data.TOTPCounter = suser.TOTPCounter
+		req.Data.RecoveryCodes = suser.RecoveryCodes
+		// So is the link to the OpenID Connect account.
+		req.Data.OIDCSubject = suser.OIDCSubject
+
 		req.Which = []string{}
 	}
 
@@ -258,9 +321,22 @@ var userPutHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request
 				return http.StatusForbidden, nil
 			}
 		}
+
+		if slices.ContainsFunc(users.TwoFactorFields, func(f string) bool { return strings.EqualFold(f, v) }) {
+			return http.StatusForbidden, nil
+		}
+
+		if v == "Backend" || v == "Mounts" {
+			suser, err := d.store.Users.Get(d.server.Root, d.raw.(uint))
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			keepBackendSecrets(req.Data, suser)
+		}
 	}
 
 	err = d.store.Users.Update(req.Data, req.Which...)
+	d.audit(r, "user_update", "", "", strconv.FormatUint(uint64(req.Data.ID), 10), err)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
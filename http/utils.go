@@ -45,6 +45,22 @@ func errToStatus(err error) int {
 		return http.StatusForbidden
 	case errors.Is(err, imgErrors.ErrImageTooLarge):
 		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, libErrors.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, libErrors.ErrLockedOut):
+		return http.StatusTooManyRequests
+	case errors.Is(err, libErrors.ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, libErrors.ErrUploadTypeNotAllowed):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, libErrors.ErrUploadLimitReached):
+		return http.StatusForbidden
+	case errors.Is(err, libErrors.ErrShareExhausted):
+		return http.StatusGone
+	case errors.Is(err, libErrors.ErrInvalidSlug):
+		return http.StatusBadRequest
+	case errors.Is(err, libErrors.ErrTooManyJobs):
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
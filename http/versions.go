@@ -0,0 +1,156 @@
+package fbhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/versions"
+)
+
+// maxDiffSize is the maximum size of the files that can be diffed.
+const maxDiffSize = 2 * 1024 * 1024
+
+var versionsGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	query := r.URL.Query()
+	if id := query.Get("diff"); id != "" {
+		return versionDiffHandler(w, d, r.URL.Path, id, query.Get("to"))
+	}
+	if id := query.Get("id"); id != "" {
+		return versionDownloadHandler(w, r, d, id)
+	}
+
+	list, err := versions.List(d.user, r.URL.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, list)
+})
+
+func versionDownloadHandler(w http.ResponseWriter, r *http.Request, d *data, id string) (int, error) {
+	if !d.CheckPerm(r.URL.Path, rules.PermDownload) {
+		return http.StatusAccepted, nil
+	}
+
+	versionPath, err := versions.Path(d.user, r.URL.Path, id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	info, err := d.user.Fs.Stat(versionPath)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return rawFileHandler(w, r, &files.FileInfo{
+		Fs:      d.user.Fs,
+		Path:    versionPath,
+		Name:    path.Base(r.URL.Path),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+}
+
+// versionDiffHandler writes the unified diff between a version and
+// another one or, if to is empty, the current content of the file.
+func versionDiffHandler(w http.ResponseWriter, d *data, p, id, to string) (int, error) {
+	if !d.CheckPerm(p, rules.PermDownload) {
+		return http.StatusAccepted, nil
+	}
+
+	fromPath, err := versions.Path(d.user, p, id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	toPath, toName := p, path.Base(p)
+	if to != "" {
+		toPath, err = versions.Path(d.user, p, to)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		toName += "@" + to
+	}
+
+	from, err := readTextFile(d, fromPath)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	current, err := readTextFile(d, toPath)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(current),
+		FromFile: path.Base(p) + "@" + id,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = io.WriteString(w, diff)
+	return 0, err
+}
+
+// readTextFile reads a file that can be diffed.
+func readTextFile(d *data, p string) (string, error) {
+	fd, err := d.user.Fs.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	content, err := io.ReadAll(io.LimitReader(fd, maxDiffSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(content) > maxDiffSize {
+		return "", fmt.Errorf("%s is too big to be diffed: %w", path.Base(p), fberrors.ErrInvalidRequestParams)
+	}
+	if bytes.IndexByte(content, 0) != -1 || !utf8.Valid(content) {
+		return "", fmt.Errorf("%s is not a text file: %w", path.Base(p), fberrors.ErrInvalidRequestParams)
+	}
+
+	return string(content), nil
+}
+
+var versionRestoreHandler = withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.CheckPerm(r.URL.Path, rules.PermModify) || !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return http.StatusBadRequest, fberrors.ErrInvalidRequestParams
+	}
+
+	err := d.RunHook(func() error {
+		return versions.Restore(d.user, r.URL.Path, id, d.settings.FileMode, d.settings.DirMode)
+	}, "save", r.URL.Path, "", d.user)
+	updateIndex(d, r.URL.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusNoContent, nil
+})
@@ -0,0 +1,356 @@
+package fbhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/quota"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/users"
+	"github.com/filebrowser/filebrowser/v2/versions"
+)
+
+const webdavPrefix = "/dav"
+
+// webdavFs implements webdav.FileSystem on top of a user's afero.Fs,
+// hiding the paths that the rules checker doesn't allow.
+type webdavFs struct {
+	fs      afero.Fs
+	checker rules.Checker
+}
+
+func (w *webdavFs) Mkdir(_ context.Context, name string, perm os.FileMode) error {
+	if !w.checker.Check(name) {
+		return os.ErrPermission
+	}
+	return w.fs.Mkdir(name, perm)
+}
+
+func (w *webdavFs) OpenFile(_ context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if !w.checker.Check(name) {
+		return nil, os.ErrNotExist
+	}
+
+	file, err := w.fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webdavFile{File: file, name: name, checker: w.checker}, nil
+}
+
+func (w *webdavFs) RemoveAll(_ context.Context, name string) error {
+	if !w.checker.Check(name) {
+		return os.ErrPermission
+	}
+	return w.fs.RemoveAll(name)
+}
+
+func (w *webdavFs) Rename(_ context.Context, oldName, newName string) error {
+	if !w.checker.Check(oldName) || !w.checker.Check(newName) {
+		return os.ErrPermission
+	}
+	return w.fs.Rename(oldName, newName)
+}
+
+func (w *webdavFs) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	if !w.checker.Check(name) {
+		return nil, os.ErrNotExist
+	}
+	return w.fs.Stat(name)
+}
+
+// webdavFile filters the directory listings of an afero.File
+// through the rules checker.
+type webdavFile struct {
+	afero.File
+	name    string
+	checker rules.Checker
+}
+
+func (f *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		if f.checker.Check(path.Join(f.name, info.Name())) {
+			allowed = append(allowed, info)
+		}
+	}
+
+	return allowed, nil
+}
+
+// withDavUser authenticates a WebDAV request either with the regular JWT
+// or with basic auth credentials checked against the users store.
+func withDavUser(fn handleFunc) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			status, err := withUser(fn)(w, r, d)
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Basic realm="File Browser"`)
+			}
+			return status, err
+		}
+
+		// The clients send the password with every request, so they're
+		// locked out like the logins.
+		keys := lockoutKeys(d, r, username)
+		if status, err := reserveLockout(w, d, keys); status != 0 || err != nil {
+			return status, err
+		}
+
+		user, err := d.store.Users.Get(d.server.Root, username)
+		hash := users.DummyHash
+		if err == nil {
+			hash = user.Password
+		}
+
+		if !users.CheckPwd(password, hash) || err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="File Browser"`)
+			return http.StatusUnauthorized, nil
+		}
+
+		if err := releaseLockout(d, keys, user.Username); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		// A password alone isn't enough for the users that
+		// have, or must have, two-factor authentication.
+		d.user = user
+		if user.TOTPEnabled || twoFactorSetupRequired(d) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="File Browser"`)
+			return http.StatusUnauthorized, nil
+		}
+
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return fn(d.throttleUser(w, r), r, d)
+	}
+}
+
+func webdavHandler(lockSystem webdav.LockSystem) handleFunc {
+	return withDavUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		src := path.Clean("/" + strings.TrimPrefix(r.URL.Path, webdavPrefix))
+		if !d.Check(src) {
+			return http.StatusForbidden, nil
+		}
+
+		dst := ""
+		if hdr := r.Header.Get("Destination"); hdr != "" {
+			u, err := url.Parse(hdr)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			prefix := d.server.BaseURL + webdavPrefix
+			if !strings.HasPrefix(u.Path, prefix) {
+				return http.StatusBadGateway, nil
+			}
+			dst = path.Clean("/" + strings.TrimPrefix(u.Path, prefix))
+		}
+
+		evt, status, err := webdavCheckPermissions(r, d, src, dst)
+		if status != 0 || err != nil {
+			return status, err
+		}
+
+		if r.Method == http.MethodDelete {
+			err = d.store.Share.DeleteWithPathPrefix(src, d.user.ID)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+
+		// The uploads and copies must fit in the quota of the user.
+		var res *quota.Reservation
+		switch r.Method {
+		case http.MethodPut:
+			res, err = reserveQuota(d, src, r.ContentLength)
+		case "MKCOL":
+			res, err = reserveQuota(d, src, 0)
+		case "COPY":
+			res, err = reserveQuotaCopy(d, src, dst)
+		}
+		if err != nil {
+			return errToStatus(err), err
+		}
+		defer res.Release()
+		if r.Method == http.MethodPut {
+			r.Body = io.NopCloser(res.Reader(r.Body))
+		}
+
+		handler := &webdav.Handler{
+			Prefix:     d.server.BaseURL + webdavPrefix,
+			FileSystem: &webdavFs{fs: d.user.Fs, checker: d},
+			LockSystem: lockSystem,
+		}
+
+		// The base URL was stripped by the router but the WebDAV handler
+		// needs the full path to build the hrefs of its responses.
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = d.server.BaseURL + r.URL.Path
+		r2.URL.RawPath = ""
+
+		serve := func() error {
+			handler.ServeHTTP(w, r2)
+			return nil
+		}
+		switch r.Method {
+		case http.MethodPut, "MKCOL", http.MethodDelete:
+			serve = d.tracked(src, serve)
+		case "COPY":
+			serve = d.tracked(dst, serve)
+		}
+
+		if evt == "" {
+			_ = serve()
+			// Locking a path that doesn't exist creates an empty file.
+			if r.Method == "MKCOL" || r.Method == "LOCK" {
+				updateIndex(d, src)
+			}
+			if r.Method == "MKCOL" {
+				d.audit(r, "mkdir", src, "", "", nil)
+			}
+			return 0, nil
+		}
+
+		err = d.RunHook(func() error {
+			if evt == "save" {
+				if err := versions.Save(d.user, src, d.settings.FileMode, d.settings.DirMode); err != nil {
+					return err
+				}
+			}
+
+			if err := serve(); err != nil {
+				return err
+			}
+
+			if evt == "rename" {
+				if _, err := d.user.Fs.Stat(src); errors.Is(err, os.ErrNotExist) {
+					moveMetadata(d, src, dst)
+				}
+			}
+
+			if evt == "delete" {
+				if _, err := d.user.Fs.Stat(src); errors.Is(err, os.ErrNotExist) {
+					if err := versions.Delete(d.user, src); err != nil {
+						log.Printf("failed to delete the versions of %s: %v", src, err)
+					}
+				}
+			}
+			return nil
+		}, evt, src, dst, d.user)
+		updateIndex(d, src, dst)
+
+		return errToStatus(err), err
+	})
+}
+
+// webdavCheckPermissions verifies that the user is allowed to execute
+// the WebDAV method and returns the hook event that it maps to, if any.
+func webdavCheckPermissions(r *http.Request, d *data, src, dst string) (evt string, status int, err error) {
+	_, statErr := d.user.Fs.Stat(src)
+	exists := statErr == nil
+
+	// The files of the read-only mounts can't be changed.
+	switch r.Method {
+	case http.MethodPut, "MKCOL", http.MethodDelete, "MOVE", "PROPPATCH", "LOCK":
+		if d.user.ReadOnly(src) {
+			return "", http.StatusForbidden, nil
+		}
+	}
+	if dst != "" && d.user.ReadOnly(dst) {
+		return "", http.StatusForbidden, nil
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		if !d.CheckPerm(src, rules.PermDownload) {
+			return "", http.StatusForbidden, nil
+		}
+	case http.MethodPut:
+		if exists && !d.CheckPerm(src, rules.PermModify) {
+			return "", http.StatusForbidden, nil
+		}
+		if !exists && !d.CheckPerm(src, rules.PermCreate) {
+			return "", http.StatusForbidden, nil
+		}
+
+		evt = "upload"
+		if exists {
+			evt = "save"
+		}
+		return evt, 0, nil
+	case "MKCOL":
+		if !d.CheckPerm(src, rules.PermCreate) {
+			return "", http.StatusForbidden, nil
+		}
+	case http.MethodDelete:
+		if src == "/" || !d.checkPermTree(src, rules.PermDelete) {
+			return "", http.StatusForbidden, nil
+		}
+		return "delete", 0, nil
+	case "COPY", "MOVE":
+		if dst == "" {
+			return "", http.StatusBadRequest, nil
+		}
+		if !d.Check(dst) || src == "/" || dst == "/" {
+			return "", http.StatusForbidden, nil
+		}
+		if err := checkParent(src, dst); err != nil {
+			return "", http.StatusBadRequest, err
+		}
+
+		if _, err := d.user.Fs.Stat(dst); err == nil && !d.CheckPerm(dst, rules.PermModify) {
+			return "", http.StatusForbidden, nil
+		}
+
+		if r.Method == "COPY" {
+			if !d.checkPermTree(src, rules.PermDownload) || !d.CheckPerm(dst, rules.PermCreate) {
+				return "", http.StatusForbidden, nil
+			}
+			return "copy", 0, nil
+		}
+
+		if !d.checkPermTree(src, rules.PermRename) || !d.CheckPerm(dst, rules.PermRename) {
+			return "", http.StatusForbidden, nil
+		}
+		return "rename", 0, nil
+	case "PROPPATCH":
+		if !d.CheckPerm(src, rules.PermModify) {
+			return "", http.StatusForbidden, nil
+		}
+	case "LOCK":
+		if (exists && !d.CheckPerm(src, rules.PermModify)) || (!exists && !d.CheckPerm(src, rules.PermCreate)) {
+			return "", http.StatusForbidden, nil
+		}
+	case "UNLOCK", "PROPFIND", http.MethodOptions:
+	default:
+		return "", http.StatusMethodNotAllowed, nil
+	}
+
+	if statErr != nil && !errors.Is(statErr, os.ErrNotExist) {
+		return "", errToStatus(statErr), statErr
+	}
+
+	return "", 0, nil
+}
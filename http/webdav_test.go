@@ -0,0 +1,261 @@
+package fbhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestWebDAVHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method             string
+		path               string
+		password           string
+		perm               users.Permissions
+		expectedStatusCode int
+		expectedInBody     string
+		notExpectedInBody  string
+	}{
+		"PROPFIND with valid credentials": {
+			method:             "PROPFIND",
+			path:               "/dav/",
+			password:           "password",
+			expectedStatusCode: http.StatusMultiStatus,
+			expectedInBody:     "/dav/visible.txt",
+			notExpectedInBody:  "/dav/secret",
+		},
+		"PROPFIND with invalid credentials, 401": {
+			method:             "PROPFIND",
+			path:               "/dav/",
+			password:           "wrong-password",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"GET denied by rules, 403": {
+			method:             http.MethodGet,
+			path:               "/dav/secret/file.txt",
+			password:           "password",
+			perm:               users.Permissions{Download: true},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"GET with download permission": {
+			method:             http.MethodGet,
+			path:               "/dav/visible.txt",
+			password:           "password",
+			perm:               users.Permissions{Download: true},
+			expectedStatusCode: http.StatusOK,
+			expectedInBody:     "content",
+		},
+		"GET without download permission, 403": {
+			method:             http.MethodGet,
+			path:               "/dav/visible.txt",
+			password:           "password",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"MKCOL with create permission": {
+			method:             "MKCOL",
+			path:               "/dav/new",
+			password:           "password",
+			perm:               users.Permissions{Create: true},
+			expectedStatusCode: http.StatusCreated,
+		},
+		"MKCOL without create permission, 403": {
+			method:             "MKCOL",
+			path:               "/dav/new",
+			password:           "password",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"DELETE without delete permission, 403": {
+			method:             http.MethodDelete,
+			path:               "/dav/visible.txt",
+			password:           "password",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := newTestStorage(t)
+
+			pwd, err := users.HashPwd("password")
+			if err != nil {
+				t.Fatalf("failed to hash password: %v", err)
+			}
+			if err := storage.Users.Save(&users.User{
+				Username: "username",
+				Password: pwd,
+				Perm:     tc.perm,
+				Rules:    []rules.Rule{{Path: "/secret"}},
+			}); err != nil {
+				t.Fatalf("failed to save user: %v", err)
+			}
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "/visible.txt", []byte("content"), 0644)
+			_ = afero.WriteFile(fs, "/secret/file.txt", []byte("secret"), 0644)
+
+			storage.Users = &customFSUser{
+				Store: storage.Users,
+				fs:    afero.NewBasePathFs(fs, "/"),
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.SetBasicAuth("username", tc.password)
+
+			recorder := httptest.NewRecorder()
+			handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+			handler.ServeHTTP(recorder, req)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+			if result.StatusCode != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got status code %d", tc.expectedStatusCode, result.StatusCode)
+			}
+
+			body, err := io.ReadAll(result.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if tc.expectedInBody != "" && !strings.Contains(string(body), tc.expectedInBody) {
+				t.Errorf("expected body to contain %q, got %q", tc.expectedInBody, body)
+			}
+			if tc.notExpectedInBody != "" && strings.Contains(string(body), tc.notExpectedInBody) {
+				t.Errorf("expected body not to contain %q, got %q", tc.notExpectedInBody, body)
+			}
+		})
+	}
+}
+
+func TestWebDAVDeleteShares(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{Username: "username", Password: pwd, Perm: users.Permissions{Delete: true}}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	// The other user has a file with the same path in its own scope.
+	for _, link := range []*share.Link{
+		{Hash: "own", Path: "/visible.txt", UserID: 1},
+		{Hash: "other", Path: "/visible.txt", UserID: 2},
+	} {
+		if err := storage.Share.Save(link); err != nil {
+			t.Fatalf("failed to save share: %v", err)
+		}
+	}
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/visible.txt", []byte("content"), 0644)
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	req, err := http.NewRequest(http.MethodDelete, "/dav/visible.txt", http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to construct request: %v", err)
+	}
+	req.SetBasicAuth("username", "password")
+
+	recorder := httptest.NewRecorder()
+	handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{}).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got status code %d", http.StatusNoContent, recorder.Code)
+	}
+
+	if _, err := storage.Share.GetByHash("own"); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected the link of the user to be deleted, got %v", err)
+	}
+	if _, err := storage.Share.GetByHash("other"); err != nil {
+		t.Errorf("expected the link of the other user to be kept, got %v", err)
+	}
+}
+
+func TestWebDAVCopyChecksDownload(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorage(t)
+	pwd, err := users.HashPwd("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := storage.Users.Save(&users.User{
+		Username: "username",
+		Password: pwd,
+		Perm:     users.Permissions{Create: true, Download: true},
+		Rules:    []rules.Rule{{Path: "/docs/private", Revoke: []rules.Perm{rules.PermDownload}}},
+	}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"/docs/a.txt", "/docs/private/b.txt"} {
+		_ = afero.WriteFile(fs, name, []byte("content"), 0644)
+	}
+	storage.Users = &customFSUser{Store: storage.Users, fs: fs}
+
+	handler := handle(webdavHandler(webdav.NewMemLS()), "", storage, &settings.Server{})
+
+	testCases := map[string]struct {
+		src                string
+		dst                string
+		expectedStatusCode int
+	}{
+		"downloadable file": {
+			src:                "/docs/a.txt",
+			dst:                "/a.txt",
+			expectedStatusCode: http.StatusCreated,
+		},
+		"file without download": {
+			src:                "/docs/private/b.txt",
+			dst:                "/b.txt",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		"directory with a file without download": {
+			src:                "/docs",
+			dst:                "/copy",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest("COPY", "/dav"+tc.src, http.NoBody)
+			if err != nil {
+				t.Fatalf("failed to construct request: %v", err)
+			}
+			req.Header.Set("Destination", "/dav"+tc.dst)
+			req.SetBasicAuth("username", "password")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if recorder.Code != tc.expectedStatusCode {
+				t.Fatalf("expected status code %d, got status code %d", tc.expectedStatusCode, recorder.Code)
+			}
+
+			if _, err := fs.Stat(tc.dst); (err == nil) != (tc.expectedStatusCode == http.StatusCreated) {
+				t.Errorf("unexpected copy state of %s: %v", tc.dst, err)
+			}
+		})
+	}
+}
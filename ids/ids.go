@@ -0,0 +1,18 @@
+// Package ids generates the random IDs of the records which don't have
+// an incremental one, like the jobs and the trashed items.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// New returns a new random ID, which is safe to use in URLs.
+func New() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
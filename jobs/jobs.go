@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"sync/atomic"
+)
+
+// Status describes the state of a job.
+type Status string
+
+const (
+	StatusRunning  Status = "running"
+	StatusDone     Status = "done"
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+)
+
+// Job is a long running file operation executed in the background.
+type Job struct {
+	ID          string `json:"id" storm:"id"`
+	UserID      uint   `json:"userID" storm:"index"`
+	Action      string `json:"action"`
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Files       int64  `json:"files"`
+	Bytes       int64  `json:"bytes"`
+	Created     int64  `json:"created"`
+	Updated     int64  `json:"updated"`
+}
+
+// Finished reports whether the job is no longer running.
+func (j *Job) Finished() bool {
+	return j.Status != StatusRunning
+}
+
+// Progress keeps track of the work done by a running job. It is
+// safe for concurrent use.
+type Progress struct {
+	files atomic.Int64
+	bytes atomic.Int64
+}
+
+// AddFiles adds n to the number of processed files.
+func (p *Progress) AddFiles(n int64) {
+	p.files.Add(n)
+}
+
+// AddBytes adds n to the number of processed bytes.
+func (p *Progress) AddBytes(n int64) {
+	p.bytes.Add(n)
+}
+
+// Files returns the number of processed files.
+func (p *Progress) Files() int64 {
+	return p.files.Load()
+}
+
+// Bytes returns the number of processed bytes.
+func (p *Progress) Bytes() int64 {
+	return p.bytes.Load()
+}
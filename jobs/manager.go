@@ -0,0 +1,290 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/ids"
+)
+
+const (
+	// DefaultRetention is how long finished jobs are kept in the storage.
+	DefaultRetention = time.Hour * 24 * 7
+
+	// MaxRunningPerUser is how many jobs a user can run at the same time.
+	MaxRunningPerUser = 4
+
+	// progressInterval is how often the progress of running jobs is saved.
+	progressInterval = 2 * time.Second
+
+	// purgeInterval is how often the expired jobs are removed.
+	purgeInterval = time.Hour
+)
+
+// Func is the work executed by a job.
+type Func func(ctx context.Context, p *Progress) error
+
+type task struct {
+	job      *Job
+	progress *Progress
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Manager runs jobs in the background and keeps their state
+// up to date in the storage.
+type Manager struct {
+	ctx     context.Context
+	store   *Storage
+	running map[string]*task
+	mux     sync.RWMutex
+}
+
+// NewManager creates a jobs manager, whose jobs are canceled when ctx is
+// done. Jobs that were still running when the previous process stopped
+// are marked as failed.
+func NewManager(ctx context.Context, store *Storage) (*Manager, error) {
+	all, err := store.All()
+	if err != nil && !errors.Is(err, fberrors.ErrNotExist) {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, job := range all {
+		if job.Finished() {
+			continue
+		}
+
+		job.Status = StatusFailed
+		job.Error = "interrupted by a restart"
+		job.Updated = now
+		if err := store.Save(job); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Manager{
+		ctx:     ctx,
+		store:   store,
+		running: map[string]*task{},
+	}, nil
+}
+
+// Start saves the job and runs fn in the background. The job is updated
+// with its ID and initial state. It fails with fberrors.ErrTooManyJobs
+// if the user already runs MaxRunningPerUser jobs.
+func (m *Manager) Start(job *Job, fn Func) error {
+	id, err := ids.New()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	job.ID = id
+	job.Status = StatusRunning
+	job.Created = now
+	job.Updated = now
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	t := &task{
+		job:      job,
+		progress: &Progress{},
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	// The job is counted before it's saved, so that the concurrent
+	// requests can't all pass the limit.
+	m.mux.Lock()
+	count := 0
+	for _, other := range m.running {
+		if other.job.UserID == job.UserID {
+			count++
+		}
+	}
+	if count >= MaxRunningPerUser {
+		m.mux.Unlock()
+		cancel()
+		return fberrors.ErrTooManyJobs
+	}
+	m.running[id] = t
+	m.mux.Unlock()
+
+	if err := m.store.Save(job); err != nil {
+		m.mux.Lock()
+		delete(m.running, id)
+		m.mux.Unlock()
+		cancel()
+		return err
+	}
+
+	go m.run(ctx, t, fn)
+	return nil
+}
+
+// Purge removes the finished jobs not updated for longer than retention.
+func (m *Manager) Purge(retention time.Duration) error {
+	all, err := m.store.All()
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, job := range all {
+		if job.Finished() && time.Since(time.Unix(job.Updated, 0)) > retention {
+			errs = append(errs, m.store.Delete(job.ID))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// StartPurge periodically removes the finished jobs older than
+// retention, until ctx is done.
+func (m *Manager) StartPurge(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.Purge(retention); err != nil {
+				log.Printf("jobs: failed to purge: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *Manager) run(ctx context.Context, t *task, fn Func) {
+	defer close(t.done)
+
+	stop := make(chan struct{})
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := m.store.Save(m.snapshot(t)); err != nil {
+					log.Printf("jobs: failed to save progress of %s: %v", t.job.ID, err)
+				}
+			}
+		}
+	}()
+
+	err := fn(ctx, t.progress)
+	close(stop)
+	<-saved
+
+	job := m.snapshot(t)
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		job.Status = StatusCanceled
+	case err != nil:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	default:
+		job.Status = StatusDone
+	}
+	t.cancel()
+
+	if err := m.store.Save(job); err != nil {
+		log.Printf("jobs: failed to save %s: %v", job.ID, err)
+	}
+
+	m.mux.Lock()
+	delete(m.running, job.ID)
+	m.mux.Unlock()
+}
+
+// snapshot returns a copy of the task's job with the current progress.
+func (m *Manager) snapshot(t *task) *Job {
+	job := *t.job
+	job.Files = t.progress.Files()
+	job.Bytes = t.progress.Bytes()
+	job.Updated = time.Now().Unix()
+	return &job
+}
+
+// Get returns a job by its ID, including the live progress
+// when it's still running.
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mux.RLock()
+	t, ok := m.running[id]
+	m.mux.RUnlock()
+	if ok {
+		return m.snapshot(t), nil
+	}
+
+	return m.store.Get(id)
+}
+
+// FindByUserID returns the jobs of a user, most recent first.
+func (m *Manager) FindByUserID(id uint) ([]*Job, error) {
+	list, err := m.store.FindByUserID(id)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Job{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.mux.RLock()
+	for i, job := range list {
+		if t, ok := m.running[job.ID]; ok {
+			list[i] = m.snapshot(t)
+		}
+	}
+	m.mux.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created > list[j].Created
+	})
+
+	return list, nil
+}
+
+// Cancel cancels a running job and waits for it to stop.
+func (m *Manager) Cancel(id string) error {
+	m.mux.RLock()
+	t, ok := m.running[id]
+	m.mux.RUnlock()
+	if !ok {
+		return fberrors.ErrNotExist
+	}
+
+	t.cancel()
+	<-t.done
+	return nil
+}
+
+// Wait blocks until the job with the given ID is no longer running.
+func (m *Manager) Wait(id string) {
+	m.mux.RLock()
+	t, ok := m.running[id]
+	m.mux.RUnlock()
+	if ok {
+		<-t.done
+	}
+}
@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/storage/memory"
+)
+
+func newMemoryBackend() *memory.Backend[Job] {
+	return memory.NewBackend(
+		func(v *Job) string { return v.ID },
+		func(v *Job) uint { return v.UserID },
+	)
+}
+
+func TestManagerRunsJobs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		fn             Func
+		expectedStatus Status
+		expectedError  string
+	}{
+		"successful job": {
+			fn: func(_ context.Context, p *Progress) error {
+				p.AddFiles(2)
+				p.AddBytes(10)
+				return nil
+			},
+			expectedStatus: StatusDone,
+		},
+		"failed job": {
+			fn: func(_ context.Context, p *Progress) error {
+				p.AddFiles(2)
+				p.AddBytes(10)
+				return errors.New("boom")
+			},
+			expectedStatus: StatusFailed,
+			expectedError:  "boom",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			manager, err := NewManager(context.Background(), NewStorage(newMemoryBackend()))
+			if err != nil {
+				t.Fatalf("failed to create manager: %v", err)
+			}
+
+			job := &Job{UserID: 1, Action: "archive", Source: "/src"}
+			if err := manager.Start(job, tc.fn); err != nil {
+				t.Fatalf("failed to start job: %v", err)
+			}
+			manager.Wait(job.ID)
+
+			got, err := manager.Get(job.ID)
+			if err != nil {
+				t.Fatalf("failed to get job: %v", err)
+			}
+			if got.Status != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, got.Status)
+			}
+			if got.Error != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, got.Error)
+			}
+			if got.Files != 2 || got.Bytes != 10 {
+				t.Errorf("expected 2 files and 10 bytes, got %d files and %d bytes", got.Files, got.Bytes)
+			}
+		})
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	t.Parallel()
+
+	manager, err := NewManager(context.Background(), NewStorage(newMemoryBackend()))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	started := make(chan struct{})
+	job := &Job{UserID: 1, Action: "chmod", Source: "/src"}
+	err = manager.Start(job, func(ctx context.Context, _ *Progress) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("failed to start job: %v", err)
+	}
+
+	<-started
+	if err := manager.Cancel(job.ID); err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+
+	got, err := manager.Get(job.ID)
+	if err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+	if got.Status != StatusCanceled {
+		t.Errorf("expected status %q, got %q", StatusCanceled, got.Status)
+	}
+
+	if err := manager.Cancel(job.ID); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected ErrNotExist when canceling a finished job, got %v", err)
+	}
+}
+
+func TestNewManagerFailsInterruptedJobs(t *testing.T) {
+	t.Parallel()
+
+	back := newMemoryBackend()
+	_ = back.Save(&Job{ID: "running", UserID: 1, Status: StatusRunning})
+	_ = back.Save(&Job{ID: "done", UserID: 1, Status: StatusDone})
+
+	manager, err := NewManager(context.Background(), NewStorage(back))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	list, err := manager.FindByUserID(1)
+	if err != nil {
+		t.Fatalf("failed to list jobs: %v", err)
+	}
+
+	for _, job := range list {
+		if job.Status == StatusRunning {
+			t.Errorf("expected job %s to not be running after a restart", job.ID)
+		}
+	}
+}
+
+func TestManagerLimitsRunningJobs(t *testing.T) {
+	t.Parallel()
+
+	manager, err := NewManager(context.Background(), NewStorage(newMemoryBackend()))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	block := func(ctx context.Context, _ *Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	var started []string
+	for range MaxRunningPerUser {
+		job := &Job{UserID: 1, Action: "archive"}
+		if err := manager.Start(job, block); err != nil {
+			t.Fatalf("failed to start job: %v", err)
+		}
+		started = append(started, job.ID)
+	}
+
+	if err := manager.Start(&Job{UserID: 1, Action: "archive"}, block); !errors.Is(err, fberrors.ErrTooManyJobs) {
+		t.Errorf("expected ErrTooManyJobs over the limit, got %v", err)
+	}
+	if err := manager.Start(&Job{UserID: 2, Action: "archive"}, block); err != nil {
+		t.Errorf("expected the jobs of another user to start, got %v", err)
+	}
+
+	if err := manager.Cancel(started[0]); err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+	if err := manager.Start(&Job{UserID: 1, Action: "archive"}, block); err != nil {
+		t.Errorf("expected a job to start once another one stopped, got %v", err)
+	}
+}
+
+func TestManagerCancelsJobsOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager, err := NewManager(ctx, NewStorage(newMemoryBackend()))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	job := &Job{UserID: 1, Action: "chmod"}
+	err = manager.Start(job, func(ctx context.Context, _ *Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("failed to start job: %v", err)
+	}
+
+	cancel()
+	manager.Wait(job.ID)
+
+	got, err := manager.Get(job.ID)
+	if err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+	if got.Status != StatusCanceled {
+		t.Errorf("expected status %q, got %q", StatusCanceled, got.Status)
+	}
+}
+
+func TestManagerPurge(t *testing.T) {
+	t.Parallel()
+
+	back := newMemoryBackend()
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	_ = back.Save(&Job{ID: "old", UserID: 1, Status: StatusDone, Updated: old})
+	_ = back.Save(&Job{ID: "recent", UserID: 1, Status: StatusDone, Updated: time.Now().Unix()})
+
+	manager, err := NewManager(context.Background(), NewStorage(back))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := manager.Purge(time.Hour); err != nil {
+		t.Fatalf("failed to purge: %v", err)
+	}
+
+	if _, err := manager.Get("old"); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected the old job to be purged, got %v", err)
+	}
+	if _, err := manager.Get("recent"); err != nil {
+		t.Errorf("expected the recent job to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package jobs
+
+// StorageBackend is the interface to implement for a jobs storage.
+type StorageBackend interface {
+	All() ([]*Job, error)
+	Get(id string) (*Job, error)
+	FindByUserID(id uint) ([]*Job, error)
+	Save(j *Job) error
+	Delete(id string) error
+}
+
+// Storage is a jobs storage.
+type Storage struct {
+	back StorageBackend
+}
+
+// NewStorage creates a jobs storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// All wraps a StorageBackend.All.
+func (s *Storage) All() ([]*Job, error) {
+	return s.back.All()
+}
+
+// Get wraps a StorageBackend.Get.
+func (s *Storage) Get(id string) (*Job, error) {
+	return s.back.Get(id)
+}
+
+// FindByUserID wraps a StorageBackend.FindByUserID.
+func (s *Storage) FindByUserID(id uint) ([]*Job, error) {
+	return s.back.FindByUserID(id)
+}
+
+// Save wraps a StorageBackend.Save.
+func (s *Storage) Save(j *Job) error {
+	return s.back.Save(j)
+}
+
+// Delete wraps a StorageBackend.Delete.
+func (s *Storage) Delete(id string) error {
+	return s.back.Delete(id)
+}
@@ -0,0 +1,157 @@
+// Package lockout protects the logins against brute-force attacks by
+// tracking the failed attempts per username and per client address,
+// delaying the next attempts more and more, up to a lockout.
+package lockout
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// freeAttempts is the number of failed attempts without delay.
+	freeAttempts = 3
+	// baseDelay is the delay after the first delayed attempt, which
+	// doubles with each one.
+	baseDelay = time.Second
+)
+
+// Attempts are the recent failed attempts of a key.
+type Attempts struct {
+	Failures uint      `json:"failures"`
+	Last     time.Time `json:"last"`
+	// Linked are the keys of the attempts made along with these, like
+	// the addresses which tried a username.
+	Linked []string `json:"linked,omitempty"`
+}
+
+// Backend stores the failed attempts of the keys. They're forgotten
+// once ttl passed since the last one.
+type Backend interface {
+	Get(key string) (Attempts, error)
+	// Reserve records a failed attempt of each key at at, linked to the
+	// other keys, unless wait returns a positive duration for the
+	// attempts of one of them, in which case it returns the longest
+	// one. The attempts are checked and recorded atomically.
+	Reserve(keys []string, at time.Time, ttl time.Duration, wait func(Attempts) time.Duration) (time.Duration, error)
+	// Release removes a failed attempt of a key, if it has any.
+	Release(key string) error
+	Reset(key string) error
+}
+
+// Policy is how many failed attempts are allowed, and how long the
+// lockout which follows them lasts. Zero attempts disable the lockout.
+type Policy struct {
+	Attempts uint
+	Duration time.Duration
+}
+
+// Delay returns how long after the last of a number of failed attempts
+// the next one is allowed.
+func (p Policy) Delay(failures uint) time.Duration {
+	switch {
+	case p.Attempts == 0:
+		return 0
+	case failures >= p.Attempts:
+		return p.Duration
+	case failures < freeAttempts:
+		return 0
+	}
+
+	delay := baseDelay << min(failures-freeAttempts, 30)
+	return min(delay, p.Duration)
+}
+
+// Tracker tracks the failed attempts. A nil Tracker allows everything.
+type Tracker struct {
+	back Backend
+}
+
+// NewTracker creates a tracker storing the attempts in a backend.
+func NewTracker(back Backend) *Tracker {
+	return &Tracker{back: back}
+}
+
+// Reserve returns how long to wait before the next attempt of the keys.
+// When it's allowed right away, it returns zero and records it as a
+// failed one, so that the concurrent attempts can't all be allowed. The
+// attempt must then be released if it doesn't fail.
+func (t *Tracker) Reserve(p Policy, keys ...string) (time.Duration, error) {
+	if t == nil || p.Attempts == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	return t.back.Reserve(keys, now, p.Duration, func(attempts Attempts) time.Duration {
+		if attempts.Failures == 0 {
+			return 0
+		}
+
+		return attempts.Last.Add(p.Delay(attempts.Failures)).Sub(now)
+	})
+}
+
+// Release forgets an attempt of the keys reserved by Reserve, which
+// didn't fail.
+func (t *Tracker) Release(p Policy, keys ...string) error {
+	if t == nil || p.Attempts == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, t.back.Release(key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reset forgets the failed attempts of the keys, like after a
+// successful login or when an admin unlocks a user.
+func (t *Tracker) Reset(keys ...string) error {
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, t.back.Reset(key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Unlock forgets the failed attempts of a key and of the keys linked to
+// it, like when an admin unlocks a user, so that the addresses which
+// tried it aren't locked out anymore either.
+func (t *Tracker) Unlock(key string) error {
+	if t == nil {
+		return nil
+	}
+
+	attempts, err := t.back.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return t.Reset(append(attempts.Linked, key)...)
+}
+
+// Get returns the failed attempts of a key.
+func (t *Tracker) Get(key string) (Attempts, error) {
+	if t == nil {
+		return Attempts{}, nil
+	}
+
+	return t.back.Get(key)
+}
+
+// UserKey returns the key of the attempts of a username.
+func UserKey(username string) string {
+	return "user:" + username
+}
+
+// AddrKey returns the key of the attempts of a client address.
+func AddrKey(addr string) string {
+	return "addr:" + addr
+}
@@ -0,0 +1,146 @@
+package lockout
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{Attempts: 8, Duration: 10 * time.Second}
+	cases := map[uint]time.Duration{
+		0: 0,
+		2: 0,
+		3: time.Second,
+		4: 2 * time.Second,
+		6: 8 * time.Second,
+		7: 10 * time.Second,
+		8: 10 * time.Second,
+		9: 10 * time.Second,
+	}
+	for failures, want := range cases {
+		if got := policy.Delay(failures); got != want {
+			t.Errorf("Delay(%d) = %v; want %v", failures, got, want)
+		}
+	}
+
+	if got := (Policy{Duration: time.Hour}).Delay(100); got != 0 {
+		t.Errorf("a disabled policy delayed an attempt by %v", got)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(NewMemoryBackend())
+	policy := Policy{Attempts: 2, Duration: time.Hour}
+	user, addr := UserKey("john"), AddrKey("10.0.0.1")
+
+	// A released attempt doesn't count.
+	if wait, err := tracker.Reserve(policy, user, addr); err != nil || wait != 0 {
+		t.Fatalf("the attempt was delayed by %v: %v", wait, err)
+	}
+	if err := tracker.Release(policy, user, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if wait, err := tracker.Reserve(policy, user, addr); err != nil || wait != 0 {
+			t.Fatalf("attempt %d was delayed by %v: %v", i, wait, err)
+		}
+	}
+
+	wait, err := tracker.Reserve(policy, user, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait < 59*time.Minute {
+		t.Fatalf("got wait %v after the lockout, want about an hour", wait)
+	}
+	if attempts, _ := tracker.Get(user); attempts.Failures != 2 {
+		t.Fatalf("got %d failures, want the refused attempt not to count", attempts.Failures)
+	}
+
+	// Unlocking the user leaves the address locked out.
+	if err := tracker.Reset(user); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := tracker.Reserve(policy, user); wait != 0 {
+		t.Fatalf("the user is still locked out for %v", wait)
+	}
+	if wait, _ := tracker.Reserve(policy, user, addr); wait == 0 {
+		t.Fatal("the address was unlocked with the user")
+	}
+}
+
+func TestTrackerUnlock(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(NewMemoryBackend())
+	policy := Policy{Attempts: 2, Duration: time.Hour}
+	user, addr := UserKey("john"), AddrKey("10.0.0.1")
+
+	for i := 0; i < 2; i++ {
+		if _, err := tracker.Reserve(policy, user, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The addresses which tried the user are unlocked with it.
+	if err := tracker.Unlock(user); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := tracker.Reserve(policy, addr); wait != 0 {
+		t.Fatalf("the address is still locked out for %v", wait)
+	}
+}
+
+func TestTrackerConcurrentAttempts(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(NewMemoryBackend())
+	policy := Policy{Attempts: 2, Duration: time.Hour}
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, err := tracker.Reserve(policy, UserKey("john")); err == nil && wait == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 2 {
+		t.Fatalf("%d concurrent attempts were allowed, want 2", n)
+	}
+}
+
+func TestMemoryBackendExpiry(t *testing.T) {
+	t.Parallel()
+
+	back := NewMemoryBackend()
+	allow := func(Attempts) time.Duration { return 0 }
+	past := time.Now().Add(-time.Hour)
+	if _, err := back.Reserve([]string{"a"}, past, time.Minute, allow); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts, _ := back.Get("a"); attempts.Failures != 0 {
+		t.Fatalf("got %d failures after they expired", attempts.Failures)
+	}
+	if _, err := back.Reserve([]string{"a"}, time.Now(), time.Minute, allow); err != nil {
+		t.Fatal(err)
+	}
+	if attempts, _ := back.Get("a"); attempts.Failures != 1 {
+		t.Fatalf("got %d failures, want the expired ones to be forgotten", attempts.Failures)
+	}
+}
@@ -0,0 +1,109 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often the expired attempts are removed from
+// the memory.
+const pruneInterval = time.Minute
+
+type memoryAttempts struct {
+	Attempts
+	linked  map[string]struct{}
+	expires time.Time
+}
+
+// MemoryBackend keeps the attempts in memory, for single instance
+// deployments.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	attempts map[string]*memoryAttempts
+	pruned   time.Time
+}
+
+// NewMemoryBackend creates an in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{attempts: map[string]*memoryAttempts{}, pruned: time.Now()}
+}
+
+// Get implements Backend.
+func (m *MemoryBackend) Get(key string) (Attempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[key]
+	if !ok || time.Now().After(a.expires) {
+		return Attempts{}, nil
+	}
+
+	attempts := a.Attempts
+	for k := range a.linked {
+		attempts.Linked = append(attempts.Linked, k)
+	}
+
+	return attempts, nil
+}
+
+// Reserve implements Backend.
+func (m *MemoryBackend) Reserve(keys []string, at time.Time, ttl time.Duration, wait func(Attempts) time.Duration) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at.Sub(m.pruned) > pruneInterval {
+		for k, a := range m.attempts {
+			if at.After(a.expires) {
+				delete(m.attempts, k)
+			}
+		}
+		m.pruned = at
+	}
+
+	var longest time.Duration
+	for _, key := range keys {
+		if a, ok := m.attempts[key]; ok && !at.After(a.expires) {
+			longest = max(longest, wait(a.Attempts))
+		}
+	}
+	if longest > 0 {
+		return longest, nil
+	}
+
+	for _, key := range keys {
+		a, ok := m.attempts[key]
+		if !ok || at.After(a.expires) {
+			a = &memoryAttempts{linked: map[string]struct{}{}}
+			m.attempts[key] = a
+		}
+		a.Failures++
+		a.Last = at
+		a.expires = at.Add(ttl)
+		for _, other := range keys {
+			if other != key {
+				a.linked[other] = struct{}{}
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// Release implements Backend.
+func (m *MemoryBackend) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.attempts[key]; ok && a.Failures > 0 {
+		a.Failures--
+	}
+	return nil
+}
+
+// Reset implements Backend.
+func (m *MemoryBackend) Reset(key string) error {
+	m.mu.Lock()
+	delete(m.attempts, key)
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package quota enforces the disk space and inode quotas of the users.
+package quota
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/filebrowser/filebrowser/v2/backend"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// Usage is the disk space, in bytes, and the number of inodes
+// used by a user.
+type Usage struct {
+	Space  int64 `json:"space"`
+	Inodes int64 `json:"inodes"`
+}
+
+type usage struct {
+	Usage
+	scope string
+}
+
+// Tracker keeps the usage of the users with a quota. The usage of a
+// user is measured the first time it's needed, and then updated with
+// the changes made through Track. The writes in progress reserve their
+// space and inodes, so that the concurrent ones can't all pass the
+// quota. A nil Tracker enforces no quota.
+//
+// The quota only covers the scope of the user: the files of its mounts
+// are stored elsewhere, are often shared with other users, and can be
+// remote, so they're neither measured nor limited.
+type Tracker struct {
+	mu       sync.Mutex
+	usages   map[uint]*usage
+	reserved map[uint]*Usage
+}
+
+// NewTracker creates a quota tracker.
+func NewTracker() *Tracker {
+	return &Tracker{usages: map[uint]*usage{}, reserved: map[uint]*Usage{}}
+}
+
+// Usage returns the usage of a user, measuring it if needed.
+func (t *Tracker) Usage(u *users.User) (Usage, error) {
+	if t == nil {
+		return measure(u, "/")
+	}
+
+	t.mu.Lock()
+	if cur, ok := t.usages[u.ID]; ok && cur.scope == u.Scope {
+		defer t.mu.Unlock()
+		return cur.Usage, nil
+	}
+	t.mu.Unlock()
+
+	measured, err := measure(u, "/")
+	if err != nil {
+		return Usage{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usages[u.ID] = &usage{Usage: measured, scope: u.Scope}
+	return measured, nil
+}
+
+// Reserve sets aside the space and the inodes needed to write size
+// bytes and inodes to p, which frees freed bytes, like the size of the
+// overwritten file. It returns fberrors.ErrQuotaExceeded if they would
+// exceed the quota of the user, along with the writes in progress. The
+// reservation must be released once the write is done and tracked. It's
+// nil if there's no quota to enforce on p.
+func (t *Tracker) Reserve(u *users.User, p string, size, inodes, freed int64) (*Reservation, error) {
+	if t == nil || !u.Quota.IsSet() {
+		return nil, nil
+	}
+	if mount, _ := u.MountOf(p); mount != nil {
+		return nil, nil
+	}
+
+	cur, err := t.Usage(u)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Reservation{
+		t:     t,
+		user:  u,
+		usage: Usage{Space: max(size-freed, 0), Inodes: max(inodes, 0)},
+		freed: freed,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reserved := t.reserved[u.ID]
+	if reserved == nil {
+		reserved = &Usage{}
+	}
+
+	if exceeds(cur.Space+reserved.Space+size-freed, u.Quota.Space) ||
+		exceeds(cur.Inodes+reserved.Inodes+inodes, u.Quota.Inodes) {
+		return nil, fberrors.ErrQuotaExceeded
+	}
+
+	reserved.Space += res.usage.Space
+	reserved.Inodes += res.usage.Inodes
+	t.reserved[u.ID] = reserved
+	return res, nil
+}
+
+// Reserved returns the space and the inodes reserved by the writes in
+// progress of a user.
+func (t *Tracker) Reserved(u *users.User) Usage {
+	if t == nil {
+		return Usage{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if reserved, ok := t.reserved[u.ID]; ok {
+		return *reserved
+	}
+	return Usage{}
+}
+
+// Track runs fn, which changes p, and updates the usage of the user
+// with the difference between the usage of p before and after it.
+func (t *Tracker) Track(u *users.User, p string, fn func() error) error {
+	if !t.tracked(u) {
+		return fn()
+	}
+
+	before, err := measure(u, p)
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn()
+
+	after, err := measure(u, p)
+	if err != nil {
+		t.Reset(u)
+		return errors.Join(fnErr, err)
+	}
+
+	t.mu.Lock()
+	if cur, ok := t.usages[u.ID]; ok {
+		cur.Space += after.Space - before.Space
+		cur.Inodes += after.Inodes - before.Inodes
+	}
+	t.mu.Unlock()
+
+	return fnErr
+}
+
+// Reset forgets the usage of a user, so that it's measured again when
+// it's next needed. It's used after changes which can't be tracked.
+func (t *Tracker) Reset(u *users.User) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	delete(t.usages, u.ID)
+	t.mu.Unlock()
+}
+
+// Reservation is the space and the inodes set aside for a write.
+type Reservation struct {
+	t     *Tracker
+	user  *users.User
+	usage Usage
+	freed int64
+	once  sync.Once
+}
+
+// Release gives back the reserved space and inodes. It can be called
+// several times, and on a nil Reservation.
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+
+	r.once.Do(func() {
+		r.t.mu.Lock()
+		defer r.t.mu.Unlock()
+
+		reserved, ok := r.t.reserved[r.user.ID]
+		if !ok {
+			return
+		}
+		reserved.Space -= r.usage.Space
+		reserved.Inodes -= r.usage.Inodes
+		if reserved.Space <= 0 && reserved.Inodes <= 0 {
+			delete(r.t.reserved, r.user.ID)
+		}
+	})
+}
+
+// Reader returns a reader which fails with fberrors.ErrQuotaExceeded
+// once more bytes are read from body than the space left to the user,
+// plus the reserved and the freed bytes. The bodies whose length wasn't
+// known when reserving are bounded by it.
+func (r *Reservation) Reader(body io.Reader) io.Reader {
+	if r == nil || r.user.Quota.Space == 0 {
+		return body
+	}
+
+	cur, err := r.t.Usage(r.user)
+	if err != nil {
+		return body
+	}
+	reserved := r.t.Reserved(r.user)
+
+	left := int64(r.user.Quota.Space) - cur.Space - reserved.Space + r.usage.Space + r.freed //nolint:gosec
+	return &limitedReader{r: body, left: left}
+}
+
+func (t *Tracker) tracked(u *users.User) bool {
+	if t == nil || !u.Quota.IsSet() {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.usages[u.ID]
+	return ok
+}
+
+func exceeds(used int64, limit uint64) bool {
+	return limit > 0 && used > 0 && uint64(used) > limit
+}
+
+// measure returns the usage of p, which is zero if it doesn't exist,
+// leaving out the mounts of the user.
+func measure(u *users.User, p string) (Usage, error) {
+	afs := u.Fs
+	if mfs, ok := afs.(*backend.MountFs); ok {
+		if mount, _ := u.MountOf(p); mount != nil {
+			return Usage{}, nil
+		}
+		afs = mfs.Root()
+	}
+
+	space, inodes, err := fileutils.DiskUsage(afs, p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Usage{}, nil
+	}
+	return Usage{Space: space, Inodes: inodes}, err
+}
+
+type limitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, fberrors.ErrQuotaExceeded
+	}
+	return n, err
+}
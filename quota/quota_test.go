@@ -0,0 +1,211 @@
+package quota
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/backend"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func newTestUser(t *testing.T, quota users.Quota) *users.User {
+	t.Helper()
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/docs/a.txt", []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &users.User{ID: 1, Fs: fs, Quota: quota}
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+	user := newTestUser(t, users.Quota{Space: 1000})
+
+	initial, err := tracker.Usage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Quota.Space = uint64(initial.Space) + 15
+
+	res, err := tracker.Reserve(user, "/docs/b.txt", 15, 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error within the quota: %v", err)
+	}
+	res.Release()
+	if _, err := tracker.Reserve(user, "/docs/b.txt", 16, 1, 0); !errors.Is(err, fberrors.ErrQuotaExceeded) {
+		t.Fatalf("got error %v, want the quota to be exceeded", err)
+	}
+
+	err = tracker.Track(user, "/docs/b.txt", func() error {
+		return afero.WriteFile(user.Fs, "/docs/b.txt", []byte("01234"), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ := tracker.Usage(user); usage.Space != initial.Space+5 || usage.Inodes != initial.Inodes+1 {
+		t.Fatalf("got usage %+v after writing, want %+v plus 5 bytes and 1 inode", usage, initial)
+	}
+
+	err = tracker.Track(user, "/docs", func() error {
+		return user.Fs.RemoveAll("/docs")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ := tracker.Usage(user); usage.Inodes != 1 {
+		t.Fatalf("got usage %+v after removing everything but the root", usage)
+	}
+}
+
+func TestTrackerReader(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+	user := newTestUser(t, users.Quota{})
+
+	// Without a quota, nothing is reserved and the reader is left as is.
+	res, err := tracker.Reserve(user, "/b.txt", 5, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := strings.NewReader("content")
+	if got := res.Reader(r); got != r {
+		t.Fatal("the reader of a user without a quota was wrapped")
+	}
+
+	initial, err := tracker.Usage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		size     int
+		reserved int64
+		freed    int64
+		wantErr  bool
+	}{
+		"fits":                    {size: 5},
+		"too big":                 {size: 6, wantErr: true},
+		"fits in the reservation": {size: 5, reserved: 5},
+		"fits in the freed space": {size: 8, reserved: 8, freed: 3},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			u := *user
+			u.Quota.Space = uint64(initial.Space) + 5
+			// Every subtest has its own tracker, so that their
+			// reservations don't add up.
+			res, err := NewTracker().Reserve(&u, "/b.txt", tc.reserved, 0, tc.freed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Release()
+
+			_, err = io.ReadAll(res.Reader(strings.NewReader(strings.Repeat("x", tc.size))))
+			if tc.wantErr != errors.Is(err, fberrors.ErrQuotaExceeded) {
+				t.Fatalf("got error %v, want the quota exceeded: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	t.Parallel()
+
+	var tracker *Tracker
+	user := newTestUser(t, users.Quota{Space: 1})
+
+	res, err := tracker.Reserve(user, "/", 100, 100, 0)
+	if err != nil {
+		t.Fatalf("a nil tracker enforced a quota: %v", err)
+	}
+	res.Release()
+	called := false
+	if err := tracker.Track(user, "/", func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("a nil tracker didn't run the change: %v", err)
+	}
+}
+
+func TestTrackerReserve(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+	user := newTestUser(t, users.Quota{Inodes: 1000})
+
+	initial, err := tracker.Usage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Quota.Inodes = uint64(initial.Inodes) + 2
+
+	// The writes in progress hold their inodes until they're released,
+	// so that the concurrent ones can't all pass the quota.
+	first, err := tracker.Reserve(user, "/b.txt", 0, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.Reserve(user, "/c.txt", 0, 1, 0); !errors.Is(err, fberrors.ErrQuotaExceeded) {
+		t.Fatalf("got error %v while the quota is reserved, want the quota to be exceeded", err)
+	}
+
+	first.Release()
+	first.Release()
+	if got := tracker.Reserved(user); got != (Usage{}) {
+		t.Fatalf("got %+v reserved after the release, want nothing", got)
+	}
+	second, err := tracker.Reserve(user, "/c.txt", 0, 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error after the release: %v", err)
+	}
+	second.Release()
+}
+
+func TestTrackerMounts(t *testing.T) {
+	t.Parallel()
+
+	shared := afero.NewMemMapFs()
+	if err := afero.WriteFile(shared, "/big.bin", make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	user := newTestUser(t, users.Quota{Space: 100})
+	mfs := backend.NewMountFs(user.Fs)
+	mfs.Mount("/team", shared, false)
+	user.Fs = mfs
+	user.Mounts = []users.Mount{{Path: "/team"}}
+
+	tracker := NewTracker()
+	usage, err := tracker.Usage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Space >= 1000 {
+		t.Fatalf("got usage %+v, want the mount to be left out", usage)
+	}
+
+	// The writes to a mount aren't limited by the quota of the user.
+	res, err := tracker.Reserve(user, "/team/other.bin", 1000, 1, 0)
+	if err != nil || res != nil {
+		t.Fatalf("got reservation %v and error %v in a mount, want none", res, err)
+	}
+	err = tracker.Track(user, "/team/other.bin", func() error {
+		return afero.WriteFile(user.Fs, "/team/other.bin", make([]byte, 1000), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := tracker.Usage(user); after != usage {
+		t.Fatalf("got usage %+v after writing to the mount, want %+v", after, usage)
+	}
+}
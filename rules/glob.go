@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the files from which glob rules
+// can be imported.
+const IgnoreFile = ".fbignore"
+
+// Glob is a gitignore-style pattern. Like in a .gitignore file, a
+// pattern without a slash, other than a trailing one, matches at any
+// depth, "**" matches any number of directories, and a trailing slash
+// matches directories only. As the rules only see paths, the last
+// element of a path is always considered a directory.
+type Glob struct {
+	Raw    string `json:"raw"`
+	regexp *regexp.Regexp
+}
+
+// NewGlob compiles a glob pattern.
+func NewGlob(raw string) (*Glob, error) {
+	re, err := globRegexp(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Glob{Raw: raw, regexp: re}, nil
+}
+
+// UnmarshalJSON compiles the pattern as soon as it's loaded.
+func (g *Glob) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	glob, err := NewGlob(raw.Raw)
+	if err != nil {
+		return err
+	}
+
+	*g = *glob
+	return nil
+}
+
+// Match checks if a path, relative to the directory of the pattern,
+// or any of its parent directories matches the pattern.
+func (g *Glob) Match(p string) bool {
+	if g.regexp == nil {
+		re, err := globRegexp(g.Raw)
+		if err != nil {
+			return false
+		}
+		g.regexp = re
+	}
+
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return false
+	}
+
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && g.regexp.MatchString(p[:i]) {
+			return true
+		}
+	}
+
+	return g.regexp.MatchString(p)
+}
+
+// globRegexp translates a glob pattern into a regular expression
+// which matches the relative paths, without leading slash.
+func globRegexp(raw string) (*regexp.Regexp, error) {
+	pattern := strings.TrimSuffix(raw, "/")
+	if pattern == "" || pattern == "/" {
+		return nil, fmt.Errorf("empty glob pattern %q", raw)
+	}
+
+	// A pattern with a slash is relative to its directory, while
+	// one without matches at any depth.
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end <= 0 {
+				return nil, fmt.Errorf("unterminated character class in glob pattern %q", raw)
+			}
+			class := pattern[i+1 : i+1+end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// NewGlobRule returns the rule of a glob pattern relative to a
+// directory. Like in a .gitignore file, a pattern starting with an
+// exclamation mark is negated: the rule allows what it would
+// otherwise disallow, and the other way around.
+func NewGlobRule(pattern, dir string, allow bool) (Rule, error) {
+	if strings.HasPrefix(pattern, "!") {
+		pattern = pattern[1:]
+		allow = !allow
+	}
+
+	glob, err := NewGlob(pattern)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{
+		Allow: allow,
+		Path:  path.Clean("/" + dir),
+		Glob:  glob,
+	}, nil
+}
+
+// ParseIgnore reads the patterns of an ignore file which is in a
+// directory, and returns their rules. The blank lines and the ones
+// starting with a hash are skipped.
+func ParseIgnore(r io.Reader, dir string) ([]Rule, error) {
+	var rulez []Rule
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimRight(scanner.Text(), " \t\r")
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		// Escaped hashes and exclamation marks are literal.
+		if strings.HasPrefix(pattern, `\#`) || strings.HasPrefix(pattern, `\!`) {
+			pattern = pattern[1:]
+			glob, err := NewGlob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			rulez = append(rulez, Rule{Path: path.Clean("/" + dir), Glob: glob})
+			continue
+		}
+
+		rule, err := NewGlobRule(pattern, dir, false)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rulez = append(rulez, rule)
+	}
+
+	return rulez, scanner.Err()
+}
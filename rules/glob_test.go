@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]map[string]bool{
+		"*.env": {
+			"a.env":           true,
+			"dir/a.env":       true,
+			"dir/a.env/x.txt": true,
+			"a.envx":          false,
+		},
+		"**/*.env": {
+			"a.env":     true,
+			"a/b/c.env": true,
+			"a/b/c.txt": false,
+		},
+		"node_modules/": {
+			"node_modules":          true,
+			"app/node_modules/x.js": true,
+			"node_modules_old/x.js": false,
+		},
+		"/build": {
+			"build":       true,
+			"build/a.o":   true,
+			"src/build":   false,
+			"buildx/a.go": false,
+		},
+		"docs/*.md": {
+			"docs/a.md":     true,
+			"docs/sub/a.md": false,
+			"x/docs/a.md":   false,
+		},
+		"a/**/b": {
+			"a/b":     true,
+			"a/x/y/b": true,
+			"a/x/c":   false,
+		},
+		"logs/**": {
+			"logs":       false,
+			"logs/a.log": true,
+		},
+		"file?.[ch]": {
+			"file1.c":  true,
+			"file2.h":  true,
+			"file1.go": false,
+			"file12.c": false,
+		},
+		"[!a]*.txt": {
+			"b.txt": true,
+			"a.txt": false,
+		},
+		`\*.txt`: {
+			"*.txt": true,
+			"a.txt": false,
+		},
+	}
+
+	for pattern, paths := range cases {
+		t.Run(pattern, func(t *testing.T) {
+			t.Parallel()
+
+			glob, err := NewGlob(pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for p, want := range paths {
+				if got := glob.Match(p); got != want {
+					t.Errorf("Glob(%q).Match(%q) = %v; want %v", pattern, p, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGlobInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"", "/", "[abc"} {
+		if _, err := NewGlob(pattern); err == nil {
+			t.Errorf("NewGlob(%q) didn't fail", pattern)
+		}
+	}
+}
+
+func TestGlobRuleMatches(t *testing.T) {
+	t.Parallel()
+
+	rule, err := NewGlobRule("*.env", "/www", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pattern is compiled when the rule is loaded.
+	data, err := json.Marshal(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Rule
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Glob == nil || loaded.Glob.regexp == nil {
+		t.Fatalf("the glob of %s wasn't compiled", data)
+	}
+
+	cases := map[string]bool{
+		"/www/.env":         true,
+		"/www/app/prod.env": true,
+		"/prod.env":         false,
+		"/wwwx/prod.env":    false,
+		"/www/app.go":       false,
+	}
+	for p, want := range cases {
+		if got := loaded.Matches(p); got != want {
+			t.Errorf("Matches(%q) = %v; want %v", p, got, want)
+		}
+	}
+}
+
+func TestParseIgnore(t *testing.T) {
+	t.Parallel()
+
+	rulez, err := ParseIgnore(strings.NewReader(`# dependencies
+node_modules/
+
+*.env
+!example.env
+\#notes
+`), "/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		raw   string
+		allow bool
+	}{
+		{"node_modules/", false},
+		{"*.env", false},
+		{"example.env", true},
+		{"#notes", false},
+	}
+	if len(rulez) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(rulez), len(want))
+	}
+	for i, w := range want {
+		if rulez[i].Glob.Raw != w.raw || rulez[i].Allow != w.allow || rulez[i].Path != "/app" {
+			t.Errorf("rule %d: got %q (allow %v, path %s), want %q (allow %v)", i, rulez[i].Glob.Raw, rulez[i].Allow, rulez[i].Path, w.raw, w.allow)
+		}
+	}
+
+	if _, err := ParseIgnore(strings.NewReader("ok\n[bad\n"), "/"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got error %v, want an error on line 2", err)
+	}
+}
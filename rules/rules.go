@@ -3,6 +3,7 @@ package rules
 import (
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -11,12 +12,70 @@ type Checker interface {
 	Check(path string) bool
 }
 
-// Rule is a allow/disallow rule.
+// PermChecker is a Checker which also checks the permissions of the
+// user on each path.
+type PermChecker interface {
+	Checker
+	CheckPerm(path string, perm Perm) bool
+}
+
+// Perm is a permission which can be granted or revoked by a rule.
+type Perm string
+
+const (
+	PermCreate   Perm = "create"
+	PermRename   Perm = "rename"
+	PermModify   Perm = "modify"
+	PermDelete   Perm = "delete"
+	PermShare    Perm = "share"
+	PermDownload Perm = "download"
+)
+
+// Perms are all the permissions which rules can grant or revoke.
+var Perms = []Perm{PermCreate, PermRename, PermModify, PermDelete, PermShare, PermDownload}
+
+// Rule is a allow/disallow rule. A rule which grants or revokes
+// permissions is a permission rule: it doesn't change whether the
+// paths are visible, and Allow is ignored. For a glob rule, Path is
+// the directory to which the pattern is relative.
 type Rule struct {
 	Regex  bool    `json:"regex"`
 	Allow  bool    `json:"allow"`
 	Path   string  `json:"path"`
 	Regexp *Regexp `json:"regexp"`
+	Glob   *Glob   `json:"glob,omitempty"`
+	Grant  []Perm  `json:"grant,omitempty"`
+	Revoke []Perm  `json:"revoke,omitempty"`
+}
+
+// IsPerm reports whether the rule is a permission rule.
+func (r *Rule) IsPerm() bool {
+	return len(r.Grant) > 0 || len(r.Revoke) > 0
+}
+
+// ApplyPerm returns whether a permission is granted after the rule,
+// given whether it was before. The rule must match the path. A rule
+// which both grants and revokes a permission revokes it.
+func (r *Rule) ApplyPerm(perm Perm, granted bool) bool {
+	switch {
+	case slices.Contains(r.Revoke, perm):
+		return false
+	case slices.Contains(r.Grant, perm):
+		return true
+	default:
+		return granted
+	}
+}
+
+// Under reports whether the rule may match paths under dir, but not
+// dir itself. Regex and glob rules always may.
+func (r *Rule) Under(dir string) bool {
+	if r.Regex || r.Glob != nil {
+		return true
+	}
+
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	return strings.HasPrefix(r.Path, prefix) && r.Path != prefix
 }
 
 // MatchHidden matches paths with a basename
@@ -31,6 +90,11 @@ func (r *Rule) Matches(path string) bool {
 		return r.Regexp.MatchString(path)
 	}
 
+	if r.Glob != nil {
+		rel, ok := strings.CutPrefix(path, strings.TrimSuffix(r.Path, "/")+"/")
+		return ok && r.Glob.Match(rel)
+	}
+
 	if path == r.Path {
 		return true
 	}
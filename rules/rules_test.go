@@ -52,3 +52,47 @@ func TestMatchHidden(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleApplyPerm(t *testing.T) {
+	t.Parallel()
+
+	rule := &Rule{Path: "/logs", Grant: []Perm{PermDownload}, Revoke: []Perm{PermModify, PermDelete}}
+	cases := map[Perm]map[bool]bool{
+		PermDownload: {false: true, true: true},
+		PermModify:   {false: false, true: false},
+		PermDelete:   {false: false, true: false},
+		PermShare:    {false: false, true: true},
+	}
+
+	for perm, want := range cases {
+		for granted, wantGranted := range want {
+			if got := rule.ApplyPerm(perm, granted); got != wantGranted {
+				t.Errorf("ApplyPerm(%s, %v) = %v; want %v", perm, granted, got, wantGranted)
+			}
+		}
+	}
+}
+
+func TestRuleUnder(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		rule Rule
+		dir  string
+		want bool
+	}{
+		{Rule{Path: "/www/logs"}, "/www", true},
+		{Rule{Path: "/www/logs"}, "/www/", true},
+		{Rule{Path: "/www/logs"}, "/", true},
+		{Rule{Path: "/www"}, "/www", false},
+		{Rule{Path: "/www"}, "/www/logs", false},
+		{Rule{Path: "/wwwx/logs"}, "/www", false},
+		{Rule{Regex: true, Regexp: &Regexp{Raw: `\.log$`}}, "/www", true},
+	}
+
+	for _, tc := range cases {
+		if got := tc.rule.Under(tc.dir); got != tc.want {
+			t.Errorf("Rule{Path: %q}.Under(%q) = %v; want %v", tc.rule.Path, tc.dir, got, tc.want)
+		}
+	}
+}
@@ -11,14 +11,28 @@ import (
 	"github.com/filebrowser/filebrowser/v2/users"
 )
 
+// Observer is notified of every event that went through RunHook, with
+// the paths relative to the user's scope and the resulting error.
+type Observer func(evt, path, dst string, user *users.User, err error)
+
 // Runner is a commands runner.
 type Runner struct {
 	Enabled bool
 	*settings.Settings
+	Observer Observer
+	// Share is the hash of the share link the events came through, like
+	// the uploads of the visitors of a share, if any.
+	Share string
 }
 
 // RunHook runs the hooks for the before and after event.
-func (r *Runner) RunHook(fn func() error, evt, path, dst string, user *users.User) error {
+func (r *Runner) RunHook(fn func() error, evt, path, dst string, user *users.User) (err error) {
+	if r.Observer != nil {
+		defer func(path, dst string) {
+			r.Observer(evt, path, dst, user, err)
+		}(path, dst)
+	}
+
 	path = user.FullPath(path)
 	dst = user.FullPath(dst)
 
@@ -33,7 +47,7 @@ func (r *Runner) RunHook(fn func() error, evt, path, dst string, user *users.Use
 		}
 	}
 
-	err := fn()
+	err = fn()
 	if err != nil {
 		return err
 	}
@@ -77,6 +91,8 @@ func (r *Runner) exec(raw, evt, path, dst string, user *users.User) error {
 			return user.Username
 		case "DESTINATION":
 			return dst
+		case "SHARE":
+			return r.Share
 		default:
 			return os.Getenv(key)
 		}
@@ -95,6 +111,7 @@ func (r *Runner) exec(raw, evt, path, dst string, user *users.User) error {
 	cmd.Env = append(cmd.Env, fmt.Sprintf("TRIGGER=%s", evt))
 	cmd.Env = append(cmd.Env, fmt.Sprintf("USERNAME=%s", user.Username))
 	cmd.Env = append(cmd.Env, fmt.Sprintf("DESTINATION=%s", dst))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("SHARE=%s", r.Share))
 
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -1,49 +1,288 @@
 package search
 
 import (
+	"fmt"
 	"mime"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 )
 
 var (
-	typeRegexp = regexp.MustCompile(`type:(\w+)`)
+	contentRegexp = regexp.MustCompile(`content:("[^"]*"|\S+)`)
+	sizeRegexp    = regexp.MustCompile(`^(<=|>=|<|>|=)?(\d+(?:\.\d+)?)([a-z]*)$`)
+	ageRegexp     = regexp.MustCompile(`^(<=|>=|<|>|=)?(\d+)([a-z]+)$`)
+	dateRegexp    = regexp.MustCompile(`^(<=|>=|<|>|=)?(\d{4}-\d{2}-\d{2})$`)
 )
 
-type condition func(path string) bool
+// orOperator joins two query tokens into a group that matches
+// when any of them does.
+const orOperator = "OR"
+
+var sizeUnits = map[string]float64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+	"t":  1 << 40,
+	"tb": 1 << 40,
+}
+
+var ageUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": time.Hour * 24,
+	"w": time.Hour * 24 * 7,
+	"y": time.Hour * 24 * 365,
+}
+
+// condition reports whether a file matches. f may be nil when
+// the file couldn't be read.
+type condition func(path string, f os.FileInfo) bool
 
 func extensionCondition(extension string) condition {
-	return func(path string) bool {
+	return func(path string, _ os.FileInfo) bool {
 		return filepath.Ext(path) == "."+extension
 	}
 }
 
-func imageCondition(path string) bool {
+func imageCondition(path string, _ os.FileInfo) bool {
 	extension := filepath.Ext(path)
 	mimetype := mime.TypeByExtension(extension)
 
 	return strings.HasPrefix(mimetype, "image")
 }
 
-func audioCondition(path string) bool {
+func audioCondition(path string, _ os.FileInfo) bool {
 	extension := filepath.Ext(path)
 	mimetype := mime.TypeByExtension(extension)
 
 	return strings.HasPrefix(mimetype, "audio")
 }
 
-func videoCondition(path string) bool {
+func videoCondition(path string, _ os.FileInfo) bool {
 	extension := filepath.Ext(path)
 	mimetype := mime.TypeByExtension(extension)
 
 	return strings.HasPrefix(mimetype, "video")
 }
 
-func parseSearch(value string) *searchOptions {
+func typeCondition(value string) condition {
+	switch value {
+	case "image":
+		return imageCondition
+	case "audio", "music":
+		return audioCondition
+	case "video":
+		return videoCondition
+	default:
+		return extensionCondition(value)
+	}
+}
+
+// extCondition matches the extension regardless of its case.
+func extCondition(extension string) condition {
+	extension = "." + strings.ToLower(strings.TrimPrefix(extension, "."))
+	return func(path string, _ os.FileInfo) bool {
+		return strings.ToLower(filepath.Ext(path)) == extension
+	}
+}
+
+// nameCondition matches the files whose name contains term.
+func nameCondition(term string, caseSensitive bool) condition {
+	if !caseSensitive {
+		term = strings.ToLower(term)
+	}
+	return func(p string, _ os.FileInfo) bool {
+		name := path.Base(p)
+		if !caseSensitive {
+			name = strings.ToLower(name)
+		}
+		return strings.Contains(name, term)
+	}
+}
+
+// pathCondition matches the files whose path contains value. Directories
+// have a trailing slash, so "logs/" matches a logs directory and
+// everything inside of it.
+func pathCondition(value string, caseSensitive bool) condition {
+	if !caseSensitive {
+		value = strings.ToLower(value)
+	}
+	return func(p string, f os.FileInfo) bool {
+		if f != nil && f.IsDir() {
+			p += "/"
+		}
+		if !caseSensitive {
+			p = strings.ToLower(p)
+		}
+		return strings.Contains(p, value)
+	}
+}
+
+// sizeCondition parses values such as ">10M" or "<=1.5GB". Directories
+// never match as their size isn't the size of their content.
+func sizeCondition(value string) (condition, error) {
+	m := sizeRegexp.FindStringSubmatch(strings.ToLower(value))
+	if m == nil {
+		return nil, fmt.Errorf("invalid size %q: %w", value, fberrors.ErrInvalidRequestParams)
+	}
+
+	unit, ok := sizeUnits[m[3]]
+	if !ok {
+		return nil, fmt.Errorf("invalid size unit %q: %w", m[3], fberrors.ErrInvalidRequestParams)
+	}
+
+	n, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q: %w", value, fberrors.ErrInvalidRequestParams)
+	}
+	size := int64(n * unit)
+
+	return func(_ string, f os.FileInfo) bool {
+		if f == nil || f.IsDir() {
+			return false
+		}
+		return compare(m[1], f.Size(), size)
+	}, nil
+}
+
+// modifiedCondition parses either an age, such as "<7d" for the files
+// modified in the last seven days, or a date, such as ">2024-01-31" for
+// the files modified after that day.
+func modifiedCondition(value string, now time.Time) (condition, error) {
+	value = strings.ToLower(value)
+
+	if m := dateRegexp.FindStringSubmatch(value); m != nil {
+		date, err := time.ParseInLocation(time.DateOnly, m[2], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", m[2], fberrors.ErrInvalidRequestParams)
+		}
+
+		return func(_ string, f os.FileInfo) bool {
+			if f == nil {
+				return false
+			}
+			// Compare whole days, so that "=2024-01-31" matches the
+			// files modified at any time of that day.
+			day := f.ModTime().In(time.Local)
+			day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+			return compare(m[1], day.Unix(), date.Unix())
+		}, nil
+	}
+
+	m := ageRegexp.FindStringSubmatch(value)
+	if m == nil {
+		return nil, fmt.Errorf("invalid modification time %q: %w", value, fberrors.ErrInvalidRequestParams)
+	}
+
+	unit, ok := ageUnits[m[3]]
+	if !ok {
+		return nil, fmt.Errorf("invalid time unit %q: %w", m[3], fberrors.ErrInvalidRequestParams)
+	}
+
+	n, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modification time %q: %w", value, fberrors.ErrInvalidRequestParams)
+	}
+	age := time.Duration(n) * unit
+
+	return func(_ string, f os.FileInfo) bool {
+		if f == nil {
+			return false
+		}
+		return compare(m[1], int64(now.Sub(f.ModTime())), int64(age))
+	}, nil
+}
+
+func compare(op string, a, b int64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	default:
+		return a == b
+	}
+}
+
+func not(c condition) condition {
+	return func(path string, f os.FileInfo) bool {
+		return !c(path, f)
+	}
+}
+
+// splitQuery splits the query on spaces, keeping quoted strings together.
+func splitQuery(value string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+
+	for _, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case r == ' ' && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens
+}
+
+// parseToken parses a single query token. It returns a nil condition
+// for the plain name terms.
+func parseToken(token string, caseSensitive bool, now time.Time) (condition, error) {
+	key, value, found := strings.Cut(token, ":")
+	if !found || value == "" {
+		return nil, nil
+	}
+
+	switch key {
+	case "type":
+		return typeCondition(value), nil
+	case "ext":
+		return extCondition(value), nil
+	case "path":
+		return pathCondition(strings.Trim(value, `"`), caseSensitive), nil
+	case "size":
+		return sizeCondition(value)
+	case "modified":
+		return modifiedCondition(value, now)
+	default:
+		return nil, nil
+	}
+}
+
+func parseSearch(value string) (*searchOptions, error) {
 	opts := &searchOptions{
 		CaseSensitive: strings.Contains(value, "case:sensitive"),
 		Conditions:    []condition{},
+		Filters:       [][]condition{},
 		Terms:         []string{},
 	}
 
@@ -52,51 +291,78 @@ func parseSearch(value string) *searchOptions {
 	value = strings.ReplaceAll(value, "case:sensitive", "")
 	value = strings.TrimSpace(value)
 
-	types := typeRegexp.FindAllStringSubmatch(value, -1)
-	for _, t := range types {
-		if len(t) == 1 {
-			continue
+	// The content operator is extracted before anything else so that its
+	// expression isn't split like the other tokens.
+	if m := contentRegexp.FindStringSubmatch(value); m != nil {
+		matcher, err := parseContent(m[1], opts.CaseSensitive)
+		if err != nil {
+			return nil, err
 		}
+		opts.Content = matcher
+		value = contentRegexp.ReplaceAllString(value, "")
+	}
 
-		switch t[1] {
-		case "image":
-			opts.Conditions = append(opts.Conditions, imageCondition)
-		case "audio", "music":
-			opts.Conditions = append(opts.Conditions, audioCondition)
-		case "video":
-			opts.Conditions = append(opts.Conditions, videoCondition)
-		default:
-			opts.Conditions = append(opts.Conditions, extensionCondition(t[1]))
+	now := time.Now()
+	tokens := splitQuery(strings.TrimSpace(value))
+
+	for i := 0; i < len(tokens); i++ {
+		// Gather the tokens joined by OR into a single group.
+		group := []string{tokens[i]}
+		for i+2 < len(tokens) && tokens[i+1] == orOperator {
+			group = append(group, tokens[i+2])
+			i += 2
 		}
-	}
 
-	if len(types) > 0 {
-		// Remove the fields from the search value.
-		value = typeRegexp.ReplaceAllString(value, "")
-	}
+		var conditions []condition
+		for _, token := range group {
+			negate := len(token) > 1 && token[0] == '-'
+			if negate {
+				token = token[1:]
+			}
 
-	// If it's case insensitive, put everything in lowercase.
-	if !opts.CaseSensitive {
-		value = strings.ToLower(value)
-	}
+			c, err := parseToken(token, opts.CaseSensitive, now)
+			if err != nil {
+				return nil, err
+			}
 
-	// Remove the spaces from the search value.
-	value = strings.TrimSpace(value)
+			// Plain terms keep matching any of them, as well as the type
+			// conditions, unless they're negated or part of a group.
+			if len(group) == 1 && !negate {
+				switch {
+				case c == nil:
+					opts.Terms = append(opts.Terms, unquote(token, opts.CaseSensitive))
+					continue
+				case strings.HasPrefix(token, "type:"):
+					opts.Conditions = append(opts.Conditions, c)
+					continue
+				}
+			}
+
+			if c == nil {
+				c = nameCondition(unquote(token, opts.CaseSensitive), opts.CaseSensitive)
+			}
+			if negate {
+				c = not(c)
+			}
+			conditions = append(conditions, c)
+		}
 
-	if value == "" {
-		return opts
+		if len(conditions) > 0 {
+			opts.Filters = append(opts.Filters, conditions)
+		}
 	}
 
-	// if the value starts with " and finishes what that character, we will
-	// only search for that term
-	if value[0] == '"' && value[len(value)-1] == '"' {
-		unique := strings.TrimPrefix(value, "\"")
-		unique = strings.TrimSuffix(unique, "\"")
+	return opts, nil
+}
 
-		opts.Terms = []string{unique}
-		return opts
+// unquote removes the quotes around a term and, if the search is case
+// insensitive, puts it in lowercase.
+func unquote(term string, caseSensitive bool) string {
+	if len(term) > 1 && term[0] == '"' && term[len(term)-1] == '"' {
+		term = term[1 : len(term)-1]
 	}
-
-	opts.Terms = strings.Split(value, " ")
-	return opts
+	if !caseSensitive {
+		term = strings.ToLower(term)
+	}
+	return term
 }
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+const (
+	// MaxContentSize is the maximum size of the files whose
+	// content is scanned by the content operator.
+	MaxContentSize = 10 * 1024 * 1024 // 10 MB
+
+	maxContentMatches = 20
+	maxSnippetLength  = 200
+	maxLineLength     = 1024 * 1024
+)
+
+// ContentMatch is a line of a file that matches a content query.
+type ContentMatch struct {
+	Line    int    `json:"line"`
+	Snippet string `json:"snippet"`
+}
+
+type contentMatcher func(line string) bool
+
+// parseContent parses the value of the content operator, which is
+// either a plain term, a quoted term or a /regular expression/.
+func parseContent(value string, caseSensitive bool) (contentMatcher, error) {
+	if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+
+	if value == "" {
+		return nil, fmt.Errorf("empty content query: %w", fberrors.ErrInvalidRequestParams)
+	}
+
+	if len(value) > 2 && value[0] == '/' && value[len(value)-1] == '/' {
+		expr := value[1 : len(value)-1]
+		if !caseSensitive {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content expression: %w", fberrors.ErrInvalidRequestParams)
+		}
+
+		return re.MatchString, nil
+	}
+
+	if caseSensitive {
+		return func(line string) bool {
+			return strings.Contains(line, value)
+		}, nil
+	}
+
+	value = strings.ToLower(value)
+	return func(line string) bool {
+		return strings.Contains(strings.ToLower(line), value)
+	}, nil
+}
+
+// matchContent scans a text file and returns the lines matching the
+// matcher. Files that aren't text or that are bigger than MaxContentSize
+// are skipped.
+func matchContent(ctx context.Context, fs afero.Fs, path string, size int64,
+	checker rules.Checker, matcher contentMatcher) ([]ContentMatch, error) {
+	if size > MaxContentSize {
+		return nil, nil
+	}
+
+	info, err := files.NewFileInfo(&files.FileOptions{
+		Fs:         fs,
+		Path:       path,
+		Modify:     true,
+		Expand:     true,
+		ReadHeader: true,
+		Checker:    checker,
+	})
+	// Files that can't be read are skipped, like the walk does.
+	if err != nil || info.Type != "text" {
+		return nil, nil
+	}
+
+	fd, err := fs.Open(path)
+	if err != nil {
+		return nil, nil
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	var matches []ContentMatch
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+
+		text := scanner.Text()
+		if !matcher(text) {
+			continue
+		}
+
+		text = strings.TrimSpace(text)
+		if len(text) > maxSnippetLength {
+			text = strings.ToValidUTF8(text[:maxSnippetLength], "")
+		}
+
+		matches = append(matches, ContentMatch{Line: line, Snippet: text})
+		if len(matches) == maxContentMatches {
+			break
+		}
+	}
+
+	return matches, nil
+}
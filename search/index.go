@@ -0,0 +1,499 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+const (
+	// indexBatchSize is the number of entries written per transaction
+	// while building the index.
+	indexBatchSize = 1000
+
+	// indexReadSize is the number of entries read per transaction while
+	// walking the index, so that no transaction is held for the whole search.
+	indexReadSize = 1000
+
+	// indexWatchInterval is how often the filesystem events are applied.
+	indexWatchInterval = time.Second
+)
+
+var (
+	indexFilesBucket = []byte("files")
+	indexRootsBucket = []byte("roots")
+)
+
+// Index is a persistent index of the files under one or more roots of the
+// local filesystem, which makes searching large trees much faster than
+// walking them. Roots are indexed in the background the first time they
+// are searched and are kept up to date by watching the filesystem and by
+// the Update and Remove calls made after every mutation.
+//
+// A nil *Index is valid and behaves as an index that's never ready.
+type Index struct {
+	db      *bolt.DB
+	watcher *fsnotify.Watcher
+	// roots maps every known root to whether it's ready to be searched.
+	roots map[string]bool
+	mux   sync.Mutex
+}
+
+type indexEntry struct {
+	Size    int64       `json:"s"`
+	Mode    os.FileMode `json:"m"`
+	ModTime int64       `json:"t"`
+	// Gen is the time at which the entry was last written, used to
+	// drop the entries that disappeared while rebuilding a root.
+	Gen int64 `json:"g"`
+}
+
+// indexedFile implements os.FileInfo for an index entry.
+type indexedFile struct {
+	name  string
+	entry indexEntry
+}
+
+func (f *indexedFile) Name() string       { return f.name }
+func (f *indexedFile) Size() int64        { return f.entry.Size }
+func (f *indexedFile) Mode() os.FileMode  { return f.entry.Mode }
+func (f *indexedFile) ModTime() time.Time { return time.Unix(0, f.entry.ModTime) }
+func (f *indexedFile) IsDir() bool        { return f.entry.Mode.IsDir() }
+func (f *indexedFile) Sys() interface{}   { return nil }
+
+// OpenIndex opens the index stored at the given path, creating it if
+// needed. The roots indexed by a previous process are refreshed in the
+// background and can be searched in the meantime.
+func OpenIndex(dbPath string) (*Index, error) {
+	db, err := bolt.Open(dbPath, 0640, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(indexFilesBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists(indexRootsBucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			roots = append(roots, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	idx := &Index{
+		db:    db,
+		roots: map[string]bool{},
+	}
+
+	idx.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		// The index still works without watching, it just won't see
+		// the changes made outside of File Browser.
+		log.Printf("search index: failed to watch the filesystem: %v", err)
+	} else {
+		go idx.watch()
+	}
+
+	for _, root := range roots {
+		idx.roots[root] = true
+		go idx.build(root)
+	}
+
+	return idx, nil
+}
+
+// Close stops watching the filesystem and closes the index.
+func (i *Index) Close() error {
+	if i == nil {
+		return nil
+	}
+	if i.watcher != nil {
+		i.watcher.Close()
+	}
+	return i.db.Close()
+}
+
+// Ready reports whether root, a path of the local filesystem, can be
+// searched using the index. If root isn't indexed yet, it starts
+// indexing it in the background.
+func (i *Index) Ready(root string) bool {
+	if i == nil {
+		return false
+	}
+	root = cleanIndexPath(root)
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	building := false
+	for r, ready := range i.roots {
+		if isIndexPathUnder(root, r) {
+			if ready {
+				return true
+			}
+			building = true
+		}
+	}
+
+	if !building {
+		i.roots[root] = false
+		go i.build(root)
+	}
+
+	return false
+}
+
+// Search searches for a query in fs, whose root is root in the local
+// filesystem, using the index when it's ready and walking fs otherwise.
+func (i *Index) Search(ctx context.Context, root string,
+	fs afero.Fs, scope, query string, checker rules.Checker, found FoundFunc) error {
+	if !i.Ready(root) {
+		return Search(ctx, fs, scope, query, checker, found)
+	}
+
+	root = cleanIndexPath(root)
+	return search(ctx, fs, scope, query, checker, found, func(scope string, fn walkFunc) error {
+		return i.walk(ctx, root, scope, fn)
+	})
+}
+
+// Update refreshes the entries of the given path of the local filesystem
+// and, if it's a directory, of everything under it. Paths outside of the
+// indexed roots are ignored.
+func (i *Index) Update(p string) {
+	if i == nil {
+		return
+	}
+	p = cleanIndexPath(p)
+	if !i.covers(p) {
+		return
+	}
+
+	err := i.update(p, time.Now().UnixNano())
+	if err != nil {
+		log.Printf("search index: failed to update %s: %v", p, err)
+	}
+}
+
+// Remove removes the given path of the local filesystem, and everything
+// under it, from the index.
+func (i *Index) Remove(p string) {
+	if i == nil {
+		return
+	}
+	p = cleanIndexPath(p)
+	if !i.covers(p) {
+		return
+	}
+
+	if err := i.remove(p); err != nil {
+		log.Printf("search index: failed to remove %s: %v", p, err)
+	}
+}
+
+// covers reports whether p is under one of the roots of the index.
+func (i *Index) covers(p string) bool {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	for r := range i.roots {
+		if isIndexPathUnder(p, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// build indexes root from scratch, dropping the entries of files
+// that no longer exist, and marks it as ready.
+func (i *Index) build(root string) {
+	gen := time.Now().UnixNano()
+	err := i.update(root, gen)
+	if err == nil {
+		err = i.prune(root, gen)
+	}
+	if err == nil {
+		err = i.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(indexRootsBucket).Put([]byte(root), []byte(time.Now().Format(time.RFC3339)))
+		})
+	}
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	if err != nil {
+		log.Printf("search index: failed to index %s: %v", root, err)
+		delete(i.roots, root)
+		return
+	}
+	i.roots[root] = true
+}
+
+// update writes the entries of p and everything under it, and
+// watches the directories it finds.
+func (i *Index) update(p string, gen int64) error {
+	batch := map[string]indexEntry{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := i.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(indexFilesBucket)
+			for k, entry := range batch {
+				v, err := json.Marshal(entry)
+				if err != nil {
+					return err
+				}
+				if err := b.Put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		batch = map[string]indexEntry{}
+		return err
+	}
+
+	err := filepath.Walk(p, func(fPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if fPath == p && errors.Is(err, fs.ErrNotExist) {
+				return i.remove(p)
+			}
+			// Skip what can't be read rather than failing the whole tree.
+			return nil
+		}
+
+		if info.IsDir() {
+			i.watchDir(fPath)
+		}
+
+		batch[cleanIndexPath(fPath)] = indexEntry{
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime().UnixNano(),
+			Gen:     gen,
+		}
+		if len(batch) >= indexBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// prune removes the entries under root that are older than gen.
+func (i *Index) prune(root string, gen int64) error {
+	return i.deleteUnder(root, func(v []byte) bool {
+		var entry indexEntry
+		return json.Unmarshal(v, &entry) != nil || entry.Gen < gen
+	})
+}
+
+// remove removes the entries of p and everything under it.
+func (i *Index) remove(p string) error {
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(indexFilesBucket).Delete([]byte(p))
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.deleteUnder(p, func([]byte) bool { return true })
+}
+
+// deleteUnder removes the entries under p for which del returns true.
+// The keys are collected first as deleting while iterating a bolt
+// cursor may skip entries.
+func (i *Index) deleteUnder(p string, del func(v []byte) bool) error {
+	return i.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(indexFilesBucket)
+		prefix := indexPrefix(p)
+
+		var keys [][]byte
+		c := b.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if del(v) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// walk calls fn for scope and everything under it, with the paths made
+// relative to root. The entries are read in chunks so that fn can take
+// as long as it needs without holding a transaction.
+func (i *Index) walk(ctx context.Context, root, scope string, fn walkFunc) error {
+	base := cleanIndexPath(path.Join(root, scope))
+	relative := func(k string) string {
+		return path.Join("/", strings.TrimPrefix(k, root))
+	}
+
+	type item struct {
+		key   string
+		entry indexEntry
+	}
+
+	prefix := indexPrefix(base)
+
+	// The entry of base is read on its own, since its siblings, like
+	// base.txt or base-old, sort between it and the entries under it.
+	if string(prefix) != base {
+		var entry *indexEntry
+		err := i.db.View(func(tx *bolt.Tx) error {
+			v := tx.Bucket(indexFilesBucket).Get([]byte(base))
+			if v == nil {
+				return nil
+			}
+			var e indexEntry
+			if err := json.Unmarshal(v, &e); err == nil {
+				entry = &e
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			if err := fn(relative(base), &indexedFile{name: path.Base(base), entry: *entry}); err != nil {
+				return err
+			}
+		}
+	}
+
+	seek := prefix
+	resume := false
+	for {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		var items []item
+		err := i.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(indexFilesBucket).Cursor()
+			k, v := c.Seek(seek)
+			if resume && bytes.Equal(k, seek) {
+				k, v = c.Next()
+			}
+			for ; k != nil && len(items) < indexReadSize; k, v = c.Next() {
+				if !bytes.HasPrefix(k, prefix) {
+					break
+				}
+				var entry indexEntry
+				if err := json.Unmarshal(v, &entry); err != nil {
+					continue
+				}
+				items = append(items, item{key: string(k), entry: entry})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, it := range items {
+			f := &indexedFile{name: path.Base(it.key), entry: it.entry}
+			if err := fn(relative(it.key), f); err != nil {
+				return err
+			}
+		}
+
+		if len(items) < indexReadSize {
+			return nil
+		}
+		seek = []byte(items[len(items)-1].key)
+		resume = true
+	}
+}
+
+func (i *Index) watchDir(p string) {
+	if i.watcher == nil {
+		return
+	}
+	if err := i.watcher.Add(p); err != nil && !errors.Is(err, fsnotify.ErrClosed) {
+		// Most likely the inotify watch limit. Changes made through
+		// File Browser are still indexed.
+		log.Printf("search index: failed to watch %s: %v", p, err)
+	}
+}
+
+// watch updates the index with the changes reported by the watcher.
+// Events are coalesced for a short while, as writing a single file
+// usually produces a lot of them.
+func (i *Index) watch() {
+	ticker := time.NewTicker(indexWatchInterval)
+	defer ticker.Stop()
+
+	pending := map[string]struct{}{}
+	for {
+		select {
+		case event, ok := <-i.watcher.Events:
+			if !ok {
+				return
+			}
+			// Update also removes the paths that no longer exist.
+			pending[event.Name] = struct{}{}
+		case err, ok := <-i.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("search index: %v", err)
+		case <-ticker.C:
+			for p := range pending {
+				i.Update(p)
+			}
+			clear(pending)
+		}
+	}
+}
+
+func cleanIndexPath(p string) string {
+	return path.Clean("/" + filepath.ToSlash(p))
+}
+
+// indexPrefix returns the prefix shared by the keys under p.
+func indexPrefix(p string) []byte {
+	if p == "/" {
+		return []byte(p)
+	}
+	return []byte(p + "/")
+}
+
+// isIndexPathUnder reports whether p is root or is under root.
+func isIndexPathUnder(p, root string) bool {
+	return p == root || root == "/" || strings.HasPrefix(p, root+"/")
+}
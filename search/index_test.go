@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func waitIndexReady(t *testing.T, idx *Index, root string) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !idx.Ready(root) {
+		if time.Now().After(deadline) {
+			t.Fatalf("index of %s not ready in time", root)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func searchIndex(t *testing.T, idx *Index, root, query string) []string {
+	t.Helper()
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), root)
+	paths := []string{}
+	err := idx.Search(context.Background(), root, fs, "/", query, allowAll{}, func(path string, _ os.FileInfo, _ []ContentMatch) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slices.Sort(paths)
+	return paths
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "docs", "old"), 0755)
+	_ = os.WriteFile(filepath.Join(root, "docs", "report.txt"), []byte("the needle"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "docs", "old", "report.md"), []byte("nothing"), 0644)
+
+	dbPath := filepath.Join(t.TempDir(), "filebrowser.db.index")
+	idx, err := OpenIndex(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+
+	// Not indexed yet, the search walks the filesystem.
+	if got := searchIndex(t, idx, root, "report"); !slices.Equal(got, []string{"docs/old/report.md", "docs/report.txt"}) {
+		t.Errorf("unexpected results while indexing: %v", got)
+	}
+
+	waitIndexReady(t, idx, root)
+
+	testCases := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"name term": {
+			query:    "report",
+			expected: []string{"docs/old/report.md", "docs/report.txt"},
+		},
+		"extension condition": {
+			query:    "type:md report",
+			expected: []string{"docs/old/report.md"},
+		},
+		"content": {
+			query:    "content:needle",
+			expected: []string{"docs/report.txt"},
+		},
+	}
+
+	for name, tc := range testCases {
+		if got := searchIndex(t, idx, root, tc.query); !slices.Equal(got, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", name, tc.expected, got)
+		}
+	}
+
+	// Mutations are picked up through Update, even if nothing is watching.
+	added := filepath.Join(root, "docs", "new-report.txt")
+	_ = os.WriteFile(added, nil, 0644)
+	idx.Update(added)
+	_ = os.RemoveAll(filepath.Join(root, "docs", "old"))
+	idx.Update(filepath.Join(root, "docs", "old"))
+
+	if got := searchIndex(t, idx, root, "report"); !slices.Equal(got, []string{"docs/new-report.txt", "docs/report.txt"}) {
+		t.Errorf("unexpected results after updates: %v", got)
+	}
+
+	// Sub-roots are covered by the roots that contain them.
+	if !idx.Ready(filepath.Join(root, "docs")) {
+		t.Error("expected a sub-root to be ready")
+	}
+
+	if err := idx.Close(); err != nil {
+		t.Fatalf("failed to close index: %v", err)
+	}
+
+	// The indexed roots are still ready after reopening the index.
+	idx, err = OpenIndex(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+	defer idx.Close()
+
+	if !idx.Ready(root) {
+		t.Error("expected the root to be ready after reopening the index")
+	}
+}
+
+func TestIndexScopeWithSiblings(t *testing.T) {
+	t.Parallel()
+
+	// The siblings of the scope sort between its entry and the ones
+	// under it.
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	_ = os.MkdirAll(filepath.Join(root, "docs-old"), 0755)
+	_ = os.WriteFile(filepath.Join(root, "docs", "report.txt"), nil, 0644)
+	_ = os.WriteFile(filepath.Join(root, "docs-old", "report.txt"), nil, 0644)
+	_ = os.WriteFile(filepath.Join(root, "docs.txt"), nil, 0644)
+	_ = os.WriteFile(filepath.Join(root, "docs report.txt"), nil, 0644)
+
+	idx, err := OpenIndex(filepath.Join(t.TempDir(), "filebrowser.db.index"))
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	defer idx.Close()
+	waitIndexReady(t, idx, root)
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), root)
+	paths := []string{}
+	err = idx.Search(context.Background(), root, fs, "/docs", "report", allowAll{}, func(path string, _ os.FileInfo, _ []ContentMatch) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(paths, []string{"report.txt"}) {
+		t.Errorf("expected [report.txt], got %v", paths)
+	}
+}
+
+func TestNilIndexSearch(t *testing.T) {
+	t.Parallel()
+
+	var idx *Index
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/a.txt", nil, 0644)
+
+	paths := []string{}
+	err := idx.Search(context.Background(), "/", fs, "/", "a", allowAll{}, func(path string, _ os.FileInfo, _ []ContentMatch) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(paths, []string{"a.txt"}) {
+		t.Errorf("expected [a.txt], got %v", paths)
+	}
+}
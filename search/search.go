@@ -14,19 +14,46 @@ import (
 
 type searchOptions struct {
 	CaseSensitive bool
-	Conditions    []condition
-	Terms         []string
+	// Conditions holds the type conditions, any of which must match.
+	Conditions []condition
+	// Filters holds groups of conditions joined by OR. Every group must
+	// have at least one matching condition.
+	Filters [][]condition
+	Terms   []string
+	Content contentMatcher
 }
 
+// FoundFunc is called for every search result. When the query uses the
+// content operator, matches holds the matching lines of the file.
+type FoundFunc func(path string, f os.FileInfo, matches []ContentMatch) error
+
+// walkFunc is called for every file visited by a walker.
+type walkFunc func(fPath string, f os.FileInfo) error
+
+// walker visits scope and every file under it.
+type walker func(scope string, fn walkFunc) error
+
 // Search searches for a query in a fs.
 func Search(ctx context.Context,
-	fs afero.Fs, scope, query string, checker rules.Checker, found func(path string, f os.FileInfo) error) error {
-	search := parseSearch(query)
+	fs afero.Fs, scope, query string, checker rules.Checker, found FoundFunc) error {
+	return search(ctx, fs, scope, query, checker, found, func(scope string, fn walkFunc) error {
+		return afero.Walk(fs, scope, func(fPath string, f os.FileInfo, _ error) error {
+			return fn(fPath, f)
+		})
+	})
+}
+
+func search(ctx context.Context,
+	fs afero.Fs, scope, query string, checker rules.Checker, found FoundFunc, walk walker) error {
+	search, err := parseSearch(query)
+	if err != nil {
+		return err
+	}
 
 	scope = filepath.ToSlash(filepath.Clean(scope))
 	scope = path.Join("/", scope)
 
-	return afero.Walk(fs, scope, func(fPath string, f os.FileInfo, _ error) error {
+	return walk(scope, func(fPath string, f os.FileInfo) error {
 		if ctx.Err() != nil {
 			return context.Cause(ctx)
 		}
@@ -47,7 +74,21 @@ func Search(ctx context.Context,
 			match := false
 
 			for _, t := range search.Conditions {
-				if t(fPath) {
+				if t(fPath, f) {
+					match = true
+					break
+				}
+			}
+
+			if !match {
+				return nil
+			}
+		}
+
+		for _, group := range search.Filters {
+			match := false
+			for _, c := range group {
+				if c(fPath, f) {
 					match = true
 					break
 				}
@@ -59,6 +100,7 @@ func Search(ctx context.Context,
 		}
 
 		if len(search.Terms) > 0 {
+			match := false
 			for _, term := range search.Terms {
 				_, fileName := path.Split(fPath)
 				if !search.CaseSensitive {
@@ -66,12 +108,39 @@ func Search(ctx context.Context,
 					term = strings.ToLower(term)
 				}
 				if strings.Contains(fileName, term) {
-					return found(relativePath, f)
+					match = true
+					break
 				}
 			}
+
+			if !match {
+				return nil
+			}
+		}
+
+		if search.Content == nil {
+			return found(relativePath, f, nil)
+		}
+
+		if f.IsDir() {
+			return nil
+		}
+
+		// The content of the files the user can't download would leak through
+		// the matching lines, so they're skipped.
+		if pc, ok := checker.(rules.PermChecker); ok && !pc.CheckPerm(fPath, rules.PermDownload) {
+			return nil
+		}
+
+		matches, err := matchContent(ctx, fs, fPath, f.Size(), checker, search.Content)
+		if err != nil {
+			return err
+		}
+
+		if len(matches) == 0 {
 			return nil
 		}
 
-		return found(relativePath, f)
+		return found(relativePath, f, matches)
 	})
 }
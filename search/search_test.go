@@ -0,0 +1,233 @@
+package search
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+type allowAll struct{}
+
+func (allowAll) Check(string) bool { return true }
+
+func TestSearchContent(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/docs/a.txt", []byte("first line\nthe Needle is here\nlast line\n"), 0644)
+	_ = afero.WriteFile(fs, "/docs/b.txt", []byte("nothing to see\n"), 0644)
+	_ = afero.WriteFile(fs, "/docs/needle.bin", []byte{0x00, 0x01, 'n', 'e', 'e', 'd', 'l', 'e', 0x00}, 0644)
+
+	testCases := map[string]struct {
+		query         string
+		expectedPaths []string
+		expectedLine  int
+		expectErr     bool
+	}{
+		"case insensitive term": {
+			query:         "content:needle",
+			expectedPaths: []string{"docs/a.txt"},
+			expectedLine:  2,
+		},
+		"case sensitive term": {
+			query:         "case:sensitive content:needle",
+			expectedPaths: []string{},
+		},
+		"quoted term": {
+			query:         `content:"the needle"`,
+			expectedPaths: []string{"docs/a.txt"},
+			expectedLine:  2,
+		},
+		"regular expression": {
+			query:         `content:/^last\s/`,
+			expectedPaths: []string{"docs/a.txt"},
+			expectedLine:  3,
+		},
+		"combined with a name term": {
+			query:         "content:line b",
+			expectedPaths: []string{},
+		},
+		"invalid regular expression": {
+			query:     "content:/[/",
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			paths := []string{}
+			var matches []ContentMatch
+			err := Search(context.Background(), fs, "/", tc.query, allowAll{}, func(path string, _ os.FileInfo, m []ContentMatch) error {
+				paths = append(paths, path)
+				matches = append(matches, m...)
+				return nil
+			})
+
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error for query %q", tc.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(paths, tc.expectedPaths) {
+				t.Errorf("expected paths %v, got %v", tc.expectedPaths, paths)
+			}
+			if tc.expectedLine != 0 && (len(matches) != 1 || matches[0].Line != tc.expectedLine) {
+				t.Errorf("expected a single match on line %d, got %v", tc.expectedLine, matches)
+			}
+		})
+	}
+}
+
+type noDownload struct {
+	allowAll
+	revoked string
+}
+
+func (c noDownload) CheckPerm(path string, perm rules.Perm) bool {
+	return perm != rules.PermDownload || !strings.HasPrefix(path, c.revoked)
+}
+
+func TestSearchContentWithoutDownload(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/public/a.txt", []byte("the needle\n"), 0644)
+	_ = afero.WriteFile(fs, "/secret/b.txt", []byte("the needle\n"), 0644)
+
+	paths := []string{}
+	err := Search(context.Background(), fs, "/", "content:needle", noDownload{revoked: "/secret"},
+		func(path string, _ os.FileInfo, _ []ContentMatch) error {
+			paths = append(paths, path)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"public/a.txt"}; !slices.Equal(paths, expected) {
+		t.Errorf("expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	files := map[string]struct {
+		size    int
+		modTime time.Time
+	}{
+		"/logs/app.log":       {size: 20 << 20, modTime: old},
+		"/logs/app.log.gz":    {size: 1 << 10, modTime: old},
+		"/logs/debug.LOG":     {size: 10, modTime: time.Now()},
+		"/photos/cat.jpg":     {size: 2 << 20, modTime: time.Now()},
+		"/photos/dog.png":     {size: 5 << 20, modTime: old},
+		"/notes/todo.txt":     {size: 100, modTime: time.Now()},
+		"/notes/old-todo.txt": {size: 100, modTime: old},
+	}
+	for name, f := range files {
+		_ = afero.WriteFile(fs, name, make([]byte, f.size), 0644)
+		_ = fs.Chtimes(name, f.modTime, f.modTime)
+	}
+
+	testCases := map[string]struct {
+		query         string
+		expectedPaths []string
+		expectErr     bool
+	}{
+		"size greater than": {
+			query:         "size:>4M",
+			expectedPaths: []string{"logs/app.log", "photos/dog.png"},
+		},
+		"size with decimals": {
+			query:         "size:<=1.5kb",
+			expectedPaths: []string{"logs/app.log.gz", "logs/debug.LOG", "notes/old-todo.txt", "notes/todo.txt"},
+		},
+		"modified recently": {
+			query:         "modified:<7d type:jpg",
+			expectedPaths: []string{"photos/cat.jpg"},
+		},
+		"large stale files": {
+			query:         "size:>1M modified:>7d",
+			expectedPaths: []string{"logs/app.log", "photos/dog.png"},
+		},
+		"modified after a date": {
+			query:         "modified:>" + time.Now().AddDate(0, 0, -7).Format(time.DateOnly) + " todo",
+			expectedPaths: []string{"notes/todo.txt"},
+		},
+		"path": {
+			query:         "path:logs/ app",
+			expectedPaths: []string{"logs/app.log", "logs/app.log.gz"},
+		},
+		"extension ignores case": {
+			query:         "ext:log",
+			expectedPaths: []string{"logs/app.log", "logs/debug.LOG"},
+		},
+		"negated term": {
+			query:         "todo -old",
+			expectedPaths: []string{"notes/todo.txt"},
+		},
+		"negated filter": {
+			query:         "path:photos -ext:jpg",
+			expectedPaths: []string{"photos", "photos/dog.png"},
+		},
+		"or group": {
+			query:         "ext:jpg OR ext:gz",
+			expectedPaths: []string{"logs/app.log.gz", "photos/cat.jpg"},
+		},
+		"or group with terms": {
+			query:         "cat OR dog size:>3M",
+			expectedPaths: []string{"photos/dog.png"},
+		},
+		"invalid size": {
+			query:     "size:>10Q",
+			expectErr: true,
+		},
+		"invalid modification time": {
+			query:     "modified:<soon",
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			paths := []string{}
+			err := Search(context.Background(), fs, "/", tc.query, allowAll{}, func(path string, _ os.FileInfo, _ []ContentMatch) error {
+				paths = append(paths, path)
+				return nil
+			})
+
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error for query %q", tc.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			slices.Sort(paths)
+			if !slices.Equal(paths, tc.expectedPaths) {
+				t.Errorf("expected paths %v, got %v", tc.expectedPaths, paths)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Package sessions keeps the sessions opened by the logins, so that
+// they can be listed and revoked before their tokens expire.
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Session is a session of a user, identified by the ID of its tokens.
+type Session struct {
+	ID        string `storm:"id" json:"id"`
+	UserID    uint   `storm:"index" json:"userID"`
+	Created   int64  `json:"created"`
+	Expires   int64  `json:"expires"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Expired reports whether the session expired.
+func (s *Session) Expired() bool {
+	return s.Expires <= time.Now().Unix()
+}
+
+// StorageBackend is the interface to implement for a sessions storage.
+type StorageBackend interface {
+	Get(id string) (*Session, error)
+	FindByUserID(id uint) ([]*Session, error)
+	Save(s *Session) error
+	Delete(id string) error
+}
+
+// Storage is a sessions storage.
+type Storage struct {
+	back StorageBackend
+}
+
+// NewStorage creates a sessions storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// Create opens a new session, and removes the expired sessions of its
+// user.
+func (s *Storage) Create(session *Session) error {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	session.ID = base64.RawURLEncoding.EncodeToString(b)
+	session.Created = time.Now().Unix()
+	if err := s.back.Save(session); err != nil {
+		return err
+	}
+
+	_, err := s.FindByUserID(session.UserID)
+	return err
+}
+
+// Get gets a session by its id. The expired sessions are kept until
+// they're listed, since the proxy authentication renews them.
+func (s *Storage) Get(id string) (*Session, error) {
+	if id == "" {
+		return nil, fberrors.ErrNotExist
+	}
+	return s.back.Get(id)
+}
+
+// Save saves a session, like when it's renewed.
+func (s *Storage) Save(session *Session) error {
+	return s.back.Save(session)
+}
+
+// FindByUserID gets the active sessions of a user, from the newest to
+// the oldest, and removes the expired ones.
+func (s *Storage) FindByUserID(id uint) ([]*Session, error) {
+	list, err := s.back.FindByUserID(id)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	active := []*Session{}
+	for _, session := range list {
+		if !session.Expired() {
+			active = append(active, session)
+			continue
+		}
+		if err := s.back.Delete(session.ID); err != nil && !errors.Is(err, fberrors.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Created > active[j].Created
+	})
+	return active, nil
+}
+
+// Delete revokes a session.
+func (s *Storage) Delete(id string) error {
+	return s.back.Delete(id)
+}
+
+// DeleteByUserID revokes all the sessions of a user.
+func (s *Storage) DeleteByUserID(id uint) error {
+	list, err := s.back.FindByUserID(id)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, session := range list {
+		if err := s.back.Delete(session.ID); err != nil && !errors.Is(err, fberrors.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sessions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+type memoryBackend map[string]*Session
+
+func (m memoryBackend) Get(id string) (*Session, error) {
+	if s, ok := m[id]; ok {
+		return s, nil
+	}
+	return nil, fberrors.ErrNotExist
+}
+
+func (m memoryBackend) FindByUserID(id uint) ([]*Session, error) {
+	list := []*Session{}
+	for _, s := range m {
+		if s.UserID == id {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func (m memoryBackend) Save(s *Session) error {
+	m[s.ID] = s
+	return nil
+}
+
+func (m memoryBackend) Delete(id string) error {
+	if _, ok := m[id]; !ok {
+		return fberrors.ErrNotExist
+	}
+	delete(m, id)
+	return nil
+}
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	back := memoryBackend{}
+	s := NewStorage(back)
+
+	expired := &Session{ID: "expired", UserID: 1, Expires: time.Now().Add(-time.Minute).Unix()}
+	if err := back.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	active := &Session{UserID: 1, Expires: time.Now().Add(time.Hour).Unix()}
+	if err := s.Create(active); err != nil {
+		t.Fatal(err)
+	}
+	other := &Session{UserID: 2, Expires: time.Now().Add(time.Hour).Unix()}
+	if err := s.Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	if active.ID == "" || active.ID == other.ID {
+		t.Fatalf("got session IDs %q and %q, want two different IDs", active.ID, other.ID)
+	}
+	if _, err := s.Get("expired"); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Fatalf("the expired session wasn't removed, got error %v", err)
+	}
+	if _, err := s.Get(""); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Fatalf("got error %v for an empty ID, want ErrNotExist", err)
+	}
+
+	if err := s.DeleteByUserID(1); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := s.FindByUserID(1); err != nil || len(list) != 0 {
+		t.Fatalf("got sessions %v and error %v, want none", list, err)
+	}
+	if list, err := s.FindByUserID(2); err != nil || len(list) != 1 {
+		t.Fatalf("got sessions %v and error %v, want the session of the other user", list, err)
+	}
+}
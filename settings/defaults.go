@@ -1,6 +1,9 @@
 package settings
 
 import (
+	"slices"
+
+	"github.com/filebrowser/filebrowser/v2/backend"
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
@@ -11,7 +14,11 @@ type UserDefaults struct {
 	Scope                 string            `json:"scope"`
 	TmpDir                string            `json:"tmpDir"`
 	TrashDir              string            `json:"trashDir"`
+	VersionsDir           string            `json:"versionsDir"`
+	VersionsMaxCount      uint              `json:"versionsMaxCount"`
+	VersionsMaxAge        string            `json:"versionsMaxAge"`
 	QuotaFile             string            `json:"quotaFile"`
+	Quota                 users.Quota       `json:"quota"`
 	Locale                string            `json:"locale"`
 	ViewMode              users.ViewMode    `json:"viewMode"`
 	SingleClick           bool              `json:"singleClick"`
@@ -22,6 +29,8 @@ type UserDefaults struct {
 	HideDotfiles          bool              `json:"hideDotfiles"`
 	DateFormat            bool              `json:"dateFormat"`
 	AceEditorTheme        string            `json:"aceEditorTheme"`
+	Backend               backend.Config    `json:"backend"`
+	Mounts                []users.Mount     `json:"mounts"`
 }
 
 // Apply applies the default options to a user.
@@ -29,7 +38,11 @@ func (d *UserDefaults) Apply(u *users.User) {
 	u.Scope = d.Scope
 	u.TmpDir = d.TmpDir
 	u.TrashDir = d.TrashDir
+	u.VersionsDir = d.VersionsDir
+	u.VersionsMaxCount = d.VersionsMaxCount
+	u.VersionsMaxAge = d.VersionsMaxAge
 	u.QuotaFile = d.QuotaFile
+	u.Quota = d.Quota
 	u.Locale = d.Locale
 	u.ViewMode = d.ViewMode
 	u.SingleClick = d.SingleClick
@@ -40,4 +53,6 @@ func (d *UserDefaults) Apply(u *users.User) {
 	u.HideDotfiles = d.HideDotfiles
 	u.DateFormat = d.DateFormat
 	u.AceEditorTheme = d.AceEditorTheme
+	u.Backend = d.Backend
+	u.Mounts = slices.Clone(d.Mounts)
 }
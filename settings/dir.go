@@ -8,8 +8,6 @@ import (
 	"path"
 	"regexp"
 	"strings"
-
-	"github.com/spf13/afero"
 )
 
 var (
@@ -18,7 +16,8 @@ var (
 	dashes = regexp.MustCompile(`[\-]+`)
 )
 
-// MakeUserDir makes the user directory according to settings.
+// MakeUserDir makes the user directory according to settings, in the
+// default storage backend.
 func (s *Settings) MakeUserDir(username, userScope, serverRoot string) (string, error) {
 	userScope = strings.TrimSpace(userScope)
 	if userScope == "" && s.CreateUserDir {
@@ -32,7 +31,10 @@ func (s *Settings) MakeUserDir(username, userScope, serverRoot string) (string,
 
 	userScope = path.Join("/", userScope)
 
-	fs := afero.NewBasePathFs(afero.NewOsFs(), serverRoot)
+	fs, err := s.Defaults.Backend.NewFs(serverRoot, "/")
+	if err != nil {
+		return "", err
+	}
 	if err := fs.MkdirAll(userScope, os.ModePerm); err != nil {
 		return "", fmt.Errorf("failed to create user home dir: [%s]: %w", userScope, err)
 	}
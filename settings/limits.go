@@ -0,0 +1,16 @@
+package settings
+
+// Limits contains the bandwidth and request rate limits of the app.
+// A limit of zero is unlimited.
+type Limits struct {
+	// UserBandwidth is the bandwidth of each user, in bytes per second,
+	// shared by their uploads and downloads.
+	UserBandwidth uint64 `json:"userBandwidth"`
+	// ShareBandwidth is the bandwidth of each share, in bytes per second.
+	ShareBandwidth uint64 `json:"shareBandwidth"`
+	// LoginRate is the number of login requests a minute of each client.
+	LoginRate uint `json:"loginRate"`
+	// PublicRate is the number of requests a minute of each client to
+	// the public API of the shares.
+	PublicRate uint `json:"publicRate"`
+}
@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"log"
+	"time"
+)
+
+const DefaultLockoutAttempts = 10
+const DefaultLockoutDuration = 15 * time.Minute
+
+// Lockout contains the brute-force protection settings of the logins.
+type Lockout struct {
+	// Attempts is the number of failed logins of a username, or from an
+	// address, after which it's locked out. Zero disables the lockout.
+	Attempts uint `json:"attempts"`
+	// Duration is how long a lockout lasts, e.g. 15m.
+	Duration string `json:"duration"`
+}
+
+// DefaultLockout returns the lockout settings of the new installations,
+// and of the ones which didn't have lockout settings yet.
+func DefaultLockout() *Lockout {
+	return &Lockout{
+		Attempts: DefaultLockoutAttempts,
+		Duration: DefaultLockoutDuration.String(),
+	}
+}
+
+// GetDuration returns how long a lockout lasts.
+func (l *Lockout) GetDuration() time.Duration {
+	if l.Duration == "" {
+		return DefaultLockoutDuration
+	}
+
+	duration, err := time.ParseDuration(l.Duration)
+	if err != nil {
+		log.Printf("[WARN] Failed to parse lockout duration: %v", err)
+		return DefaultLockoutDuration
+	}
+	return duration
+}
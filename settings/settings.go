@@ -31,10 +31,13 @@ type Settings struct {
 	LogoutPage            string              `json:"logoutPage"`
 	Branding              Branding            `json:"branding"`
 	Tus                   Tus                 `json:"tus"`
+	Limits                Limits              `json:"limits"`
+	Lockout               *Lockout            `json:"lockout"`
 	Commands              map[string][]string `json:"commands"`
 	Shell                 []string            `json:"shell"`
 	Rules                 []rules.Rule        `json:"rules"`
 	MinimumPasswordLength uint                `json:"minimumPasswordLength"`
+	TwoFactorRequired     bool                `json:"twoFactorRequired"`
 	FileMode              fs.FileMode         `json:"fileMode"`
 	DirMode               fs.FileMode         `json:"dirMode"`
 	HideDotfiles          bool                `json:"hideDotfiles"`
@@ -63,6 +66,8 @@ type Server struct {
 	ImageResolutionCal    bool                `json:"imageResolutionCalculation"`
 	AuthHook              string              `json:"authHook"`
 	TokenExpirationTime   string              `json:"tokenExpirationTime"`
+	TrashRetention        string              `json:"trashRetention"`
+	TrustProxyHeaders     bool                `json:"trustProxyHeaders"`
 	HiddenFiles           map[string]struct{} `json:"hiddenFiles"` // Hostinger specific
 }
 
@@ -84,6 +89,21 @@ func (s *Server) GetTokenExpirationTime(fallback time.Duration) time.Duration {
 	return duration
 }
 
+// GetTrashRetention returns how long items are kept in the trash. A zero
+// duration means they're kept forever.
+func (s *Server) GetTrashRetention(fallback time.Duration) time.Duration {
+	if s.TrashRetention == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(s.TrashRetention)
+	if err != nil {
+		log.Printf("[WARN] Failed to parse trashRetention: %v", err)
+		return fallback
+	}
+	return duration
+}
+
 // GenerateKey generates a key of 512 bits.
 func GenerateKey() ([]byte, error) {
 	b := make([]byte, 64)
@@ -50,6 +50,12 @@ func (s *Storage) Get() (*Settings, error) {
 		}
 	}
 
+	// The lockout settings are only missing from the settings saved
+	// before they existed, since zero attempts disable the lockout.
+	if set.Lockout == nil {
+		set.Lockout = DefaultLockout()
+	}
+
 	if set.FileMode == 0 {
 		set.FileMode = DefaultFileMode
 	}
@@ -1,9 +1,60 @@
 package share
 
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+)
+
+// MaxAccesses is the number of accesses kept in the log of a link.
+const MaxAccesses = 100
+
+// slugRegexp matches the slugs which can be chosen for the links.
+var slugRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,63}$`)
+
 type CreateBody struct {
-	Password string `json:"password"`
-	Expires  string `json:"expires"`
-	Unit     string `json:"unit"`
+	Password     string  `json:"password"`
+	Expires      string  `json:"expires"`
+	Unit         string  `json:"unit"`
+	Upload       *Upload `json:"upload,omitempty"`
+	MaxDownloads uint    `json:"maxDownloads"`
+	// Paths are the files to share together, if there are several.
+	Paths []string `json:"paths,omitempty"`
+	// Slug, if set, is the hash chosen for the link.
+	Slug string       `json:"slug,omitempty"`
+	Perm *Permissions `json:"perm,omitempty"`
+}
+
+// UpdateBody are the changes to a link. The fields left out are kept,
+// and an empty password removes it.
+type UpdateBody struct {
+	Password     *string      `json:"password"`
+	Expires      *string      `json:"expires"`
+	Unit         string       `json:"unit"`
+	MaxDownloads *uint        `json:"maxDownloads"`
+	Perm         *Permissions `json:"perm"`
+}
+
+// Permissions are what the visitors of a link can do besides previewing
+// its files.
+type Permissions struct {
+	// Download lets them download the files.
+	Download bool `json:"download"`
+	// Archive lets them download the directories, and several files at
+	// once, as archives.
+	Archive bool `json:"archive"`
+}
+
+// ValidateSlug checks that a slug can be used as the hash of a link.
+func ValidateSlug(slug string) error {
+	if !slugRegexp.MatchString(slug) {
+		return fberrors.ErrInvalidSlug
+	}
+	return nil
 }
 
 // Link is the information needed to build a shareable link.
@@ -17,4 +68,207 @@ type Link struct {
 	// URL-Safe and is used to download links in password-protected shares via a
 	// query arg.
 	Token string `json:"token,omitempty"`
+	// Paths, if set, are the files shared together, relative to Path,
+	// which is their common directory. The share is then a virtual
+	// directory with only these files.
+	Paths []string `json:"paths,omitempty"`
+	// Upload, if set, lets the visitors upload files into the shared
+	// directory.
+	Upload *Upload `json:"upload,omitempty"`
+	// MaxDownloads, if set, disables the link once it was downloaded
+	// that many times.
+	MaxDownloads uint `json:"maxDownloads,omitempty"`
+	// Downloads is the number of downloads so far, and Accesses are the
+	// last accesses to the link, from the oldest to the newest.
+	Downloads uint     `json:"downloads"`
+	Accesses  []Access `json:"accesses,omitempty"`
+	// Perm, if set, restricts what the visitors can do.
+	Perm *Permissions `json:"perm,omitempty"`
+}
+
+// CanDownload reports whether the visitors can download the files of
+// the link, rather than only preview them.
+func (l *Link) CanDownload() bool {
+	return l.Perm == nil || l.Perm.Download
+}
+
+// CanArchive reports whether the visitors can download archives.
+func (l *Link) CanArchive() bool {
+	return l.Perm == nil || (l.Perm.Download && l.Perm.Archive)
+}
+
+// Access is an access to a link.
+type Access struct {
+	Time     int64  `json:"time"`
+	IP       string `json:"ip"`
+	File     string `json:"file"`
+	Download bool   `json:"download"`
+}
+
+// Includes reports whether a path relative to the share is one of its
+// files, under one of them or one of their parents.
+func (l *Link) Includes(p string) bool {
+	if len(l.Paths) == 0 {
+		return true
+	}
+
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return true
+	}
+
+	for _, shared := range l.Paths {
+		if p == shared || strings.HasPrefix(p, shared+"/") || strings.HasPrefix(shared, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonDir returns the common directory of several files, and their
+// paths relative to it.
+func CommonDir(list []string) (string, []string) {
+	dirs := make([]string, len(list))
+	for i, p := range list {
+		dirs[i] = path.Dir(p)
+	}
+
+	base := path.Clean("/" + fileutils.CommonPrefix('/', dirs...))
+	paths := make([]string, len(list))
+	for i, p := range list {
+		paths[i] = "/" + strings.TrimPrefix(strings.TrimPrefix(p, base), "/")
+	}
+	return base, paths
+}
+
+// Move points the link to dst, or to the files inside of it, if it
+// shares src or files inside of it, after src was renamed or moved.
+// It reports whether the link changed.
+func (l *Link) Move(src, dst string) bool {
+	moved := func(p string) (string, bool) {
+		if p == src {
+			return dst, true
+		}
+		if strings.HasPrefix(p, src+"/") {
+			return dst + strings.TrimPrefix(p, src), true
+		}
+		return p, false
+	}
+
+	if len(l.Paths) == 0 {
+		p, ok := moved(l.Path)
+		l.Path = p
+		return ok
+	}
+
+	changed := false
+	list := make([]string, len(l.Paths))
+	for i, shared := range l.Paths {
+		p, ok := moved(path.Join(l.Path, shared))
+		changed = changed || ok
+		list[i] = p
+	}
+	if !changed {
+		return false
+	}
+
+	// The files shared together may no longer have the same common
+	// directory.
+	l.Path, l.Paths = CommonDir(list)
+	return true
+}
+
+// Exhausted reports whether the link reached its maximum number of
+// downloads.
+func (l *Link) Exhausted() bool {
+	return l.MaxDownloads != 0 && l.Downloads >= l.MaxDownloads
+}
+
+// Record records an access to the link, and counts it if it's a
+// download.
+func (l *Link) Record(ip, file string, download bool) error {
+	if l.Exhausted() && download {
+		return fberrors.ErrShareExhausted
+	}
+
+	if download {
+		l.Downloads++
+	}
+
+	l.Accesses = append(l.Accesses, Access{
+		Time:     time.Now().Unix(),
+		IP:       ip,
+		File:     file,
+		Download: download,
+	})
+	if n := len(l.Accesses); n > MaxAccesses {
+		l.Accesses = l.Accesses[n-MaxAccesses:]
+	}
+	return nil
+}
+
+// Downloaded reports whether a visitor downloaded a file of the link
+// recently, that is among the last accesses.
+func (l *Link) Downloaded(ip, file string) bool {
+	for _, a := range l.Accesses {
+		if a.Download && a.IP == ip && a.File == file {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload are the limits of the uploads to a share.
+type Upload struct {
+	// WriteOnly hides the content of the directory from the visitors.
+	WriteOnly bool `json:"writeOnly"`
+	// MaxSize is the maximum size of a file, in bytes.
+	MaxSize int64 `json:"maxSize"`
+	// Types are the allowed extensions, like ".pdf".
+	Types []string `json:"types"`
+	// MaxFiles is the maximum number of uploads, and Count the number
+	// of uploads so far.
+	MaxFiles uint `json:"maxFiles"`
+	Count    uint `json:"count"`
+}
+
+// Clean normalizes the limits of the uploads.
+func (u *Upload) Clean() {
+	types := []string{}
+	for _, ext := range u.Types {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		types = append(types, ext)
+	}
+
+	u.Types = types
+	u.Count = 0
+	u.MaxSize = max(u.MaxSize, 0)
+}
+
+// Allows checks whether a file can be uploaded to the share. The zero
+// limits are unlimited.
+func (u *Upload) Allows(name string, size int64) error {
+	if u.MaxFiles != 0 && u.Count >= u.MaxFiles {
+		return fberrors.ErrUploadLimitReached
+	}
+	if u.MaxSize != 0 && size > u.MaxSize {
+		return fberrors.ErrUploadTooLarge
+	}
+	if len(u.Types) == 0 {
+		return nil
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	for _, t := range u.Types {
+		if t == ext {
+			return nil
+		}
+	}
+	return fberrors.ErrUploadTypeNotAllowed
 }
@@ -1,6 +1,8 @@
 package share
 
 import (
+	"errors"
+	"sync"
 	"time"
 
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
@@ -15,12 +17,15 @@ type StorageBackend interface {
 	Gets(path string, id uint) ([]*Link, error)
 	Save(s *Link) error
 	Delete(hash string) error
-	DeleteWithPathPrefix(path string) error
+	DeleteWithPathPrefix(path string, id uint) error
+	UpdatePathPrefix(src, dst string, id uint) error
 }
 
 // Storage is a storage.
 type Storage struct {
 	back StorageBackend
+	// mu serializes the updates of the links, like their counters.
+	mu sync.Mutex
 }
 
 // NewStorage creates a share links storage from a backend.
@@ -115,11 +120,63 @@ func (s *Storage) Save(l *Link) error {
 	return s.back.Save(l)
 }
 
+// Create saves a new link, unless its hash is taken by a link which
+// didn't expire.
+func (s *Storage) Create(l *Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.GetByHash(l.Hash)
+	if err == nil {
+		return fberrors.ErrExist
+	}
+	if !errors.Is(err, fberrors.ErrNotExist) {
+		return err
+	}
+
+	return s.back.Save(l)
+}
+
+// Update applies fn to the stored link with the hash and saves it, so
+// that the concurrent updates of a link aren't lost. The link isn't
+// saved if fn fails.
+func (s *Storage) Update(hash string, fn func(l *Link) error) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.back.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fn(link); err != nil {
+		return nil, err
+	}
+
+	if err := s.back.Save(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
 // Delete wraps a StorageBackend.Delete
 func (s *Storage) Delete(hash string) error {
 	return s.back.Delete(hash)
 }
 
-func (s *Storage) DeleteWithPathPrefix(path string) error {
-	return s.back.DeleteWithPathPrefix(path)
+// DeleteWithPathPrefix deletes the links of the user to path, or to the
+// files inside of it. The paths of the other users are in their own
+// scopes, so their links are left alone.
+func (s *Storage) DeleteWithPathPrefix(path string, id uint) error {
+	return s.back.DeleteWithPathPrefix(path, id)
+}
+
+// UpdatePathPrefix points the links of the user to src, or to the files
+// inside of it, to dst after src was renamed or moved. The links of the
+// other users have their own scopes, so they're left alone.
+func (s *Storage) UpdatePathPrefix(src, dst string, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.back.UpdatePathPrefix(src, dst, id)
 }
@@ -0,0 +1,63 @@
+package bolt
+
+import (
+	"errors"
+	"path"
+	"regexp"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+
+	"github.com/filebrowser/filebrowser/v2/audit"
+)
+
+type auditBackend struct {
+	db *storm.DB
+}
+
+func (s auditBackend) Save(e *audit.Entry) error {
+	return s.db.Save(e)
+}
+
+func (s auditBackend) Find(f *audit.Filter) ([]*audit.Entry, error) {
+	var matchers []q.Matcher
+	if f.Username != "" {
+		matchers = append(matchers, q.Eq("Username", f.Username))
+	}
+	if f.Action != "" {
+		matchers = append(matchers, q.Eq("Action", f.Action))
+	}
+	if f.Since != 0 {
+		matchers = append(matchers, q.Gte("Time", f.Since))
+	}
+	if f.Until != 0 {
+		matchers = append(matchers, q.Lte("Time", f.Until))
+	}
+	if f.Path != "" && path.Clean("/"+f.Path) != "/" {
+		expr := "^" + regexp.QuoteMeta(path.Clean("/"+f.Path)) + "(/|$)"
+		matchers = append(matchers, q.Or(q.Re("Path", expr), q.Re("Destination", expr)))
+	}
+
+	// The IDs are incremental, so iterating in reverse
+	// returns the most recent entries first.
+	query := s.db.Select(matchers...).Reverse()
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+
+	v := []*audit.Entry{}
+	err := query.Find(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return []*audit.Entry{}, nil
+	}
+
+	return v, err
+}
+
+func (s auditBackend) DeleteBefore(t int64) error {
+	err := s.db.Select(q.Lt("Time", t)).Delete(&audit.Entry{})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
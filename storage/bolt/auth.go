@@ -24,6 +24,8 @@ func (s authBackend) Get(t settings.AuthMethod) (auth.Auther, error) {
 		auther = &auth.HookAuth{}
 	case auth.MethodNoAuth:
 		auther = &auth.NoAuth{}
+	case auth.MethodOIDCAuth:
+		auther = &auth.OIDCAuth{}
 	default:
 		return nil, fberrors.ErrInvalidAuthMethod
 	}
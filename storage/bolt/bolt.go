@@ -3,10 +3,19 @@ package bolt
 import (
 	"github.com/asdine/storm/v3"
 
+	"github.com/filebrowser/filebrowser/v2/audit"
 	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/groups"
+	"github.com/filebrowser/filebrowser/v2/jobs"
+	"github.com/filebrowser/filebrowser/v2/lockout"
+	"github.com/filebrowser/filebrowser/v2/quota"
+	"github.com/filebrowser/filebrowser/v2/sessions"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/share"
 	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/throttle"
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/trash"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
 
@@ -16,6 +25,11 @@ func NewStorage(db *storm.DB) (*storage.Storage, error) {
 	shareStore := share.NewStorage(shareBackend{db: db})
 	settingsStore := settings.NewStorage(settingsBackend{db: db})
 	authStore := auth.NewStorage(authBackend{db: db}, userStore)
+	jobsStore := jobs.NewStorage(jobsBackend{db: db})
+	trashStore := trash.NewStorage(trashBackend{db: db})
+	auditStore := audit.NewStorage(auditBackend{db: db})
+	groupsStore := groups.NewStorage(groupsBackend{db: db}, userStore)
+	tokensStore := tokens.NewStorage(tokensBackend{db: db})
 
 	err := save(db, "version", 2)
 	if err != nil {
@@ -27,5 +41,14 @@ func NewStorage(db *storm.DB) (*storage.Storage, error) {
 		Users:    userStore,
 		Share:    shareStore,
 		Settings: settingsStore,
+		Jobs:     jobsStore,
+		Trash:    trashStore,
+		Audit:    auditStore,
+		Groups:   groupsStore,
+		Tokens:   tokensStore,
+		Sessions: sessions.NewStorage(sessionsBackend{db: db}),
+		Quotas:   quota.NewTracker(),
+		Limiter:  throttle.NewLimiter(),
+		Lockouts: lockout.NewTracker(lockout.NewMemoryBackend()),
 	}, nil
 }
@@ -0,0 +1,63 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/groups"
+)
+
+type groupsBackend struct {
+	db *storm.DB
+}
+
+func (s groupsBackend) GetBy(i interface{}) (*groups.Group, error) {
+	var arg string
+	switch i.(type) {
+	case uint:
+		arg = "ID"
+	case string:
+		arg = "Name"
+	default:
+		return nil, fberrors.ErrInvalidDataType
+	}
+
+	var v groups.Group
+	err := s.db.One(arg, i, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fberrors.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (s groupsBackend) Gets() ([]*groups.Group, error) {
+	var v []*groups.Group
+	err := s.db.All(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s groupsBackend) Save(g *groups.Group) error {
+	err := s.db.Save(g)
+	if errors.Is(err, storm.ErrAlreadyExists) {
+		return fberrors.ErrExist
+	}
+	return err
+}
+
+func (s groupsBackend) DeleteByID(id uint) error {
+	err := s.db.DeleteStruct(&groups.Group{ID: id})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/sessions"
+)
+
+type sessionsBackend struct {
+	db *storm.DB
+}
+
+func (s sessionsBackend) Get(id string) (*sessions.Session, error) {
+	var v sessions.Session
+	err := s.db.One("ID", id, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fberrors.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (s sessionsBackend) FindByUserID(id uint) ([]*sessions.Session, error) {
+	var v []*sessions.Session
+	err := s.db.Find("UserID", id, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s sessionsBackend) Save(session *sessions.Session) error {
+	return s.db.Save(session)
+}
+
+func (s sessionsBackend) Delete(id string) error {
+	err := s.db.DeleteStruct(&sessions.Session{ID: id})
+	if errors.Is(err, storm.ErrNotFound) {
+		return fberrors.ErrNotExist
+	}
+	return err
+}
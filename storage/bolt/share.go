@@ -2,6 +2,7 @@ package bolt
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/q"
@@ -76,15 +77,40 @@ func (s shareBackend) Delete(hash string) error {
 	return err
 }
 
-func (s shareBackend) DeleteWithPathPrefix(pathPrefix string) error {
+func (s shareBackend) DeleteWithPathPrefix(pathPrefix string, id uint) error {
 	var links []share.Link
-	if err := s.db.Prefix("Path", pathPrefix, &links); err != nil {
+	err := s.db.Select(q.Eq("UserID", id)).Find(&links)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
-	var err error
 	for _, link := range links {
-		err = errors.Join(err, s.db.DeleteStruct(&share.Link{Hash: link.Hash}))
+		if strings.HasPrefix(link.Path, pathPrefix) {
+			err = errors.Join(err, s.db.DeleteStruct(&share.Link{Hash: link.Hash}))
+		}
+	}
+	return err
+}
+
+func (s shareBackend) UpdatePathPrefix(src, dst string, id uint) error {
+	// The links to several files are kept under their common directory,
+	// which may be a parent of src, so all the links are checked.
+	var links []share.Link
+	err := s.db.Select(q.Eq("UserID", id)).Find(&links)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range links {
+		if links[i].Move(src, dst) {
+			err = errors.Join(err, s.db.Save(&links[i]))
+		}
 	}
 	return err
 }
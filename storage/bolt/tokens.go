@@ -0,0 +1,71 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/tokens"
+)
+
+type tokensBackend struct {
+	db *storm.DB
+}
+
+func (s tokensBackend) GetByID(id uint) (*tokens.Token, error) {
+	return s.one("ID", id)
+}
+
+func (s tokensBackend) GetByHash(hash string) (*tokens.Token, error) {
+	return s.one("Hash", hash)
+}
+
+func (s tokensBackend) one(field string, value interface{}) (*tokens.Token, error) {
+	var v tokens.Token
+	err := s.db.One(field, value, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fberrors.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (s tokensBackend) FindByUserID(id uint) ([]*tokens.Token, error) {
+	var v []*tokens.Token
+	err := s.db.Find("UserID", id, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s tokensBackend) All() ([]*tokens.Token, error) {
+	var v []*tokens.Token
+	err := s.db.All(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s tokensBackend) Save(t *tokens.Token) error {
+	err := s.db.Save(t)
+	if errors.Is(err, storm.ErrAlreadyExists) {
+		return fberrors.ErrExist
+	}
+	return err
+}
+
+func (s tokensBackend) Delete(id uint) error {
+	err := s.db.DeleteStruct(&tokens.Token{ID: id})
+	if errors.Is(err, storm.ErrNotFound) {
+		return fberrors.ErrNotExist
+	}
+	return err
+}
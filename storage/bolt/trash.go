@@ -0,0 +1,57 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/trash"
+)
+
+type trashBackend struct {
+	db *storm.DB
+}
+
+func (s trashBackend) All() ([]*trash.Item, error) {
+	var v []*trash.Item
+	err := s.db.All(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s trashBackend) Get(id string) (*trash.Item, error) {
+	var v trash.Item
+	err := s.db.One("ID", id, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fberrors.ErrNotExist
+	}
+
+	return &v, err
+}
+
+func (s trashBackend) FindByUserID(id uint) ([]*trash.Item, error) {
+	var v []*trash.Item
+	err := s.db.Select(q.Eq("UserID", id)).Find(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fberrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s trashBackend) Save(i *trash.Item) error {
+	return s.db.Save(i)
+}
+
+func (s trashBackend) Delete(id string) error {
+	err := s.db.DeleteStruct(&trash.Item{ID: id})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+// Package memory implements an in-memory storage backend for the
+// records identified by a string and owned by a user, like the jobs and
+// the trashed items. It's meant for the tests.
+package memory
+
+import (
+	"sync"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Backend keeps the records of type T in memory.
+type Backend[T any] struct {
+	items  map[string]T
+	id     func(*T) string
+	userID func(*T) uint
+	mux    sync.Mutex
+}
+
+// NewBackend creates a backend, which gets the ID and the owner of the
+// records with id and userID.
+func NewBackend[T any](id func(*T) string, userID func(*T) uint) *Backend[T] {
+	return &Backend[T]{items: map[string]T{}, id: id, userID: userID}
+}
+
+// All returns all the records.
+func (b *Backend[T]) All() ([]*T, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	var list []*T
+	for _, item := range b.items {
+		list = append(list, &item)
+	}
+	return list, nil
+}
+
+// Get returns the record with the ID.
+func (b *Backend[T]) Get(id string) (*T, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	item, ok := b.items[id]
+	if !ok {
+		return nil, fberrors.ErrNotExist
+	}
+	return &item, nil
+}
+
+// FindByUserID returns the records of a user.
+func (b *Backend[T]) FindByUserID(id uint) ([]*T, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	var list []*T
+	for _, item := range b.items {
+		if b.userID(&item) == id {
+			list = append(list, &item)
+		}
+	}
+	return list, nil
+}
+
+// Save saves a copy of a record.
+func (b *Backend[T]) Save(item *T) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.items[b.id(item)] = *item
+	return nil
+}
+
+// Delete removes the record with the ID.
+func (b *Backend[T]) Delete(id string) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	delete(b.items, id)
+	return nil
+}
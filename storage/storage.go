@@ -1,9 +1,19 @@
 package storage
 
 import (
+	"github.com/filebrowser/filebrowser/v2/audit"
 	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/groups"
+	"github.com/filebrowser/filebrowser/v2/jobs"
+	"github.com/filebrowser/filebrowser/v2/lockout"
+	"github.com/filebrowser/filebrowser/v2/quota"
+	"github.com/filebrowser/filebrowser/v2/search"
+	"github.com/filebrowser/filebrowser/v2/sessions"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/throttle"
+	"github.com/filebrowser/filebrowser/v2/tokens"
+	"github.com/filebrowser/filebrowser/v2/trash"
 	"github.com/filebrowser/filebrowser/v2/users"
 )
 
@@ -14,4 +24,19 @@ type Storage struct {
 	Share    *share.Storage
 	Auth     *auth.Storage
 	Settings *settings.Storage
+	Jobs     *jobs.Storage
+	Trash    *trash.Storage
+	Audit    *audit.Storage
+	Groups   *groups.Storage
+	Tokens   *tokens.Storage
+	Sessions *sessions.Storage
+	// Index is the optional search index, nil when disabled.
+	Index *search.Index
+	// Quotas tracks the disk usage of the users with a quota.
+	Quotas *quota.Tracker
+	// Limiter limits the bandwidth and the request rate of the clients.
+	Limiter *throttle.Limiter
+	// Lockouts tracks the failed logins, in memory unless the server
+	// shares them through redis.
+	Lockouts *lockout.Tracker
 }
@@ -0,0 +1,188 @@
+// Package throttle limits the bandwidth and the request rate of the
+// clients with token buckets.
+package throttle
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// idleTimeout is how long an unused bucket is kept. By then, it's
+// full again, so forgetting it changes nothing.
+const idleTimeout = time.Minute
+
+// Bucket is a token bucket refilled at a constant rate, up to its
+// burst. Wait lets the tokens go below zero, so that large reads and
+// writes are throttled on average rather than split.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full token bucket.
+func NewBucket(rate, burst float64) *Bucket {
+	return &Bucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+func (b *Bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// Allow takes a token if there's one left. Otherwise, it returns how
+// long it takes for the next one to be available.
+func (b *Bucket) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Wait takes n tokens and waits until they're paid back, or the
+// context is done.
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.tokens -= float64(n)
+	tokens := b.tokens
+	b.mu.Unlock()
+
+	if tokens >= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(-tokens / b.rate * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type entry struct {
+	bucket *Bucket
+	used   time.Time
+}
+
+// Limiter keeps a bucket per key, such as a user, a share or a client
+// address, so that the limits are shared by the concurrent requests. A
+// nil Limiter limits nothing.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*entry
+	pruned  time.Time
+}
+
+// NewLimiter creates a limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: map[string]*entry{}, pruned: time.Now()}
+}
+
+// Bucket returns the bucket of a key, which is replaced if the rate
+// or the burst changed since it was created.
+func (l *Limiter) Bucket(key string, rate, burst float64) *Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.pruned) > idleTimeout {
+		for k, e := range l.buckets {
+			if now.Sub(e.used) > idleTimeout {
+				delete(l.buckets, k)
+			}
+		}
+		l.pruned = now
+	}
+
+	e, ok := l.buckets[key]
+	if !ok || e.bucket.rate != rate || e.bucket.burst != burst {
+		e = &entry{bucket: NewBucket(rate, burst)}
+		l.buckets[key] = e
+	}
+	e.used = now
+
+	return e.bucket
+}
+
+// Allow checks if a request of a key is within a rate of perMinute
+// requests a minute. If it isn't, it returns how long to wait before
+// retrying. A rate of zero is unlimited.
+func (l *Limiter) Allow(key string, perMinute uint) (bool, time.Duration) {
+	if l == nil || perMinute == 0 {
+		return true, 0
+	}
+
+	return l.Bucket(key, float64(perMinute)/60, float64(perMinute)).Allow()
+}
+
+// Reader limits the bandwidth of r to bytesPerSecond, shared with the
+// other readers and writers of the key. A bandwidth of zero is
+// unlimited.
+func (l *Limiter) Reader(ctx context.Context, key string, bytesPerSecond uint64, r io.Reader) io.Reader {
+	if l == nil || bytesPerSecond == 0 {
+		return r
+	}
+
+	return &reader{ctx: ctx, r: r, bucket: l.bandwidth(key, bytesPerSecond)}
+}
+
+// Writer is like Reader, but for a writer.
+func (l *Limiter) Writer(ctx context.Context, key string, bytesPerSecond uint64, w io.Writer) io.Writer {
+	if l == nil || bytesPerSecond == 0 {
+		return w
+	}
+
+	return &writer{ctx: ctx, w: w, bucket: l.bandwidth(key, bytesPerSecond)}
+}
+
+// bandwidth returns the bucket of a key allowing a burst of a second.
+func (l *Limiter) bandwidth(key string, bytesPerSecond uint64) *Bucket {
+	rate := float64(bytesPerSecond)
+	return l.Bucket(key, rate, rate)
+}
+
+type reader struct {
+	ctx    context.Context
+	r      io.Reader
+	bucket *Bucket
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.bucket.Wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type writer struct {
+	ctx    context.Context
+	w      io.Writer
+	bucket *Bucket
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if err := w.bucket.Wait(w.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
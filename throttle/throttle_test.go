@@ -0,0 +1,78 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter()
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("a", 3); !ok {
+			t.Fatalf("request %d within the burst was denied", i)
+		}
+	}
+
+	ok, retry := limiter.Allow("a", 3)
+	if ok {
+		t.Fatal("request over the rate was allowed")
+	}
+	if retry <= 0 || retry > 20*time.Second {
+		t.Fatalf("got retry after %v, want about 20s", retry)
+	}
+
+	if ok, _ := limiter.Allow("b", 3); !ok {
+		t.Fatal("the requests of another key were limited")
+	}
+
+	// A lower rate replaces the bucket.
+	if ok, _ := limiter.Allow("a", 2); !ok {
+		t.Fatal("the bucket wasn't replaced when the rate changed")
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	t.Parallel()
+
+	var limiter *Limiter
+	if ok, _ := limiter.Allow("a", 1); !ok {
+		t.Fatal("a nil limiter denied a request")
+	}
+
+	r := bytes.NewReader(nil)
+	if limiter.Reader(context.Background(), "a", 1, r) != r {
+		t.Fatal("a nil limiter wrapped a reader")
+	}
+}
+
+func TestLimiterBandwidth(t *testing.T) {
+	t.Parallel()
+
+	const rate = 20000
+	limiter := NewLimiter()
+
+	// The burst of a second goes through right away, the rest at
+	// the rate of the bucket, shared by the reader and the writer.
+	start := time.Now()
+	r := limiter.Reader(context.Background(), "a", rate, bytes.NewReader(make([]byte, rate)))
+	w := limiter.Writer(context.Background(), "a", rate, io.Discard)
+	if _, err := io.Copy(w, r); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("copied %d bytes at %d bytes per second in %v", rate, rate, elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = limiter.Writer(ctx, "a", rate, io.Discard)
+	if _, err := w.Write(make([]byte, rate)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want the write to be canceled", err)
+	}
+}
@@ -0,0 +1,119 @@
+package tokens
+
+import (
+	"errors"
+	"time"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// usedInterval is how often the last use of a token is saved, so that
+// the requests don't all write to the database.
+const usedInterval = time.Minute
+
+// StorageBackend is the interface to implement for a tokens storage.
+type StorageBackend interface {
+	GetByID(id uint) (*Token, error)
+	GetByHash(hash string) (*Token, error)
+	FindByUserID(id uint) ([]*Token, error)
+	All() ([]*Token, error)
+	Save(t *Token) error
+	Delete(id uint) error
+}
+
+// Storage is a tokens storage.
+type Storage struct {
+	back StorageBackend
+}
+
+// NewStorage creates a tokens storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// Create generates the secret of a new token, saves the token and
+// returns the secret, which can't be retrieved afterwards.
+func (s *Storage) Create(t *Token) (string, error) {
+	secret, err := Generate()
+	if err != nil {
+		return "", err
+	}
+
+	t.ID = 0
+	t.Hash = Hash(secret)
+	t.Created = time.Now().Unix()
+	if err := t.Clean(); err != nil {
+		return "", err
+	}
+
+	if err := s.back.Save(t); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Authenticate returns the token of a secret, if it didn't expire, and
+// records its use.
+func (s *Storage) Authenticate(secret string) (*Token, error) {
+	t, err := s.back.GetByHash(Hash(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Expired() {
+		return nil, fberrors.ErrNotExist
+	}
+
+	now := time.Now()
+	if now.Sub(time.Unix(t.LastUsed, 0)) > usedInterval {
+		t.LastUsed = now.Unix()
+		if err := s.back.Save(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// Get gets a token by its id.
+func (s *Storage) Get(id uint) (*Token, error) {
+	return s.back.GetByID(id)
+}
+
+// FindByUserID gets the tokens of a user.
+func (s *Storage) FindByUserID(id uint) ([]*Token, error) {
+	list, err := s.back.FindByUserID(id)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Token{}, nil
+	}
+	return list, err
+}
+
+// All gets all the tokens.
+func (s *Storage) All() ([]*Token, error) {
+	list, err := s.back.All()
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Token{}, nil
+	}
+	return list, err
+}
+
+// Delete revokes a token.
+func (s *Storage) Delete(id uint) error {
+	return s.back.Delete(id)
+}
+
+// DeleteByUserID revokes the tokens of a user, like when it's deleted.
+func (s *Storage) DeleteByUserID(id uint) error {
+	list, err := s.FindByUserID(id)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range list {
+		if err := s.back.Delete(t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	a, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsToken(a) || a == b {
+		t.Fatalf("got tokens %q and %q, want two different tokens", a, b)
+	}
+	if Hash(a) == Hash(b) || Hash(a) != Hash(a) {
+		t.Fatal("the hashes don't identify the tokens")
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	t.Parallel()
+
+	all := users.Permissions{Admin: true, Execute: true, Create: true, Rename: true, Modify: true, Delete: true, Share: true, Download: true}
+
+	unscoped := &Token{}
+	perm := all
+	unscoped.Restrict(&perm)
+	if perm != all || !unscoped.Allows(rules.PermDelete) {
+		t.Fatalf("a token without scope restricted the permissions to %+v", perm)
+	}
+
+	scoped := &Token{Perm: &users.Permissions{Download: true, Create: true}}
+	perm = users.Permissions{Download: true, Delete: true}
+	scoped.Restrict(&perm)
+	if perm != (users.Permissions{Download: true}) {
+		t.Fatalf("got permissions %+v, want download only", perm)
+	}
+	if scoped.Allows(rules.PermDelete) || !scoped.Allows(rules.PermCreate) {
+		t.Fatal("the scope of the token doesn't decide what the rules can grant")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		expires int64
+		want    bool
+	}{
+		"never":  {0, false},
+		"past":   {time.Now().Add(-time.Minute).Unix(), true},
+		"future": {time.Now().Add(time.Hour).Unix(), false},
+	}
+	for name, tc := range cases {
+		if got := (&Token{Expires: tc.expires}).Expired(); got != tc.want {
+			t.Errorf("%s: Expired() = %v; want %v", name, got, tc.want)
+		}
+	}
+}
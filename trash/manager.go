@@ -0,0 +1,301 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/ids"
+	"github.com/filebrowser/filebrowser/v2/users"
+	"github.com/filebrowser/filebrowser/v2/versions"
+)
+
+const (
+	// DefaultRetention is how long items are kept in the trash
+	// before being purged.
+	DefaultRetention = time.Hour * 24 * 30
+
+	// purgeInterval is how often the trash is checked for expired items.
+	purgeInterval = time.Hour
+)
+
+// UserGetter returns the user with the given ID, including its Fs.
+type UserGetter func(id uint) (*users.User, error)
+
+// Manager moves files to the users' trash directories and keeps track of
+// where they came from so that they can be restored.
+type Manager struct {
+	store *Storage
+}
+
+// NewManager creates a trash manager.
+func NewManager(store *Storage) *Manager {
+	return &Manager{store: store}
+}
+
+// Move moves src, a path relative to the user's scope, to the user's
+// trash directory. Items with the same name are kept side by side.
+func (m *Manager) Move(user *users.User, src string, fileMode, dirMode fs.FileMode) (*Item, error) {
+	if user.TrashDir == "" {
+		return nil, fberrors.ErrNotExist
+	}
+
+	src = path.Clean("/" + src)
+	trashDir := path.Clean("/" + user.TrashDir)
+
+	info, err := user.Fs.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := user.Fs.MkdirAll(trashDir, dirMode); err != nil {
+		return nil, err
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return nil, err
+	}
+
+	item := &Item{
+		ID:        id,
+		UserID:    user.ID,
+		Path:      src,
+		TrashPath: uniquePath(user.Fs, path.Join(trashDir, info.Name())),
+		IsDir:     info.IsDir(),
+		Size:      info.Size(),
+		Deleted:   time.Now().Unix(),
+	}
+
+	if err := fileutils.MoveFile(user.Fs, src, item.TrashPath, fileMode, dirMode); err != nil {
+		return nil, err
+	}
+
+	if err := m.store.Save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// List returns the items in the user's trash, most recently deleted
+// first. Items that were removed from the trash directory by other
+// means are forgotten.
+func (m *Manager) List(user *users.User) ([]*Item, error) {
+	list, err := m.store.FindByUserID(user.ID)
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return []*Item{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*Item, 0, len(list))
+	for _, item := range list {
+		if _, err := user.Fs.Stat(item.TrashPath); errors.Is(err, fs.ErrNotExist) {
+			if err := m.store.Delete(item.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Deleted > items[j].Deleted
+	})
+
+	return items, nil
+}
+
+// Get returns an item of the user's trash.
+func (m *Manager) Get(user *users.User, id string) (*Item, error) {
+	item, err := m.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.UserID != user.ID {
+		return nil, fberrors.ErrNotExist
+	}
+
+	return item, nil
+}
+
+// Restore moves an item back to its original location, which must not
+// exist anymore.
+func (m *Manager) Restore(user *users.User, item *Item, fileMode, dirMode fs.FileMode) error {
+	if _, err := user.Fs.Stat(item.Path); err == nil {
+		return fberrors.ErrExist
+	}
+
+	if err := user.Fs.MkdirAll(path.Dir(item.Path), dirMode); err != nil {
+		return err
+	}
+
+	if err := fileutils.MoveFile(user.Fs, item.TrashPath, item.Path, fileMode, dirMode); err != nil {
+		return err
+	}
+
+	return m.store.Delete(item.ID)
+}
+
+// Delete permanently deletes an item, along with the versions of its
+// original path unless something else was created there since.
+func (m *Manager) Delete(user *users.User, item *Item) error {
+	if err := user.Fs.RemoveAll(item.TrashPath); err != nil {
+		return err
+	}
+
+	if _, err := user.Fs.Stat(item.Path); errors.Is(err, fs.ErrNotExist) {
+		if err := versions.Delete(user, item.Path); err != nil {
+			return err
+		}
+	}
+
+	return m.store.Delete(item.ID)
+}
+
+// Empty permanently deletes everything in the user's trash directory
+// that canDelete allows. Items are checked against their original path,
+// and what was put in the trash directory by other means against its
+// path in the trash.
+func (m *Manager) Empty(user *users.User, canDelete func(p string) bool) error {
+	list, err := m.store.FindByUserID(user.ID)
+	if err != nil && !errors.Is(err, fberrors.ErrNotExist) {
+		return err
+	}
+
+	kept := map[string]bool{}
+	for _, item := range list {
+		if !canDelete(item.Path) {
+			kept[item.TrashPath] = true
+			continue
+		}
+		if err := m.Delete(user, item); err != nil {
+			return err
+		}
+	}
+
+	if user.TrashDir == "" {
+		return nil
+	}
+
+	// Also remove what was put in the trash directory by other means.
+	trashDir := path.Clean("/" + user.TrashDir)
+	entries, err := afero.ReadDir(user.Fs, trashDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		p := path.Join(trashDir, entry.Name())
+		if kept[p] || !canDelete(p) {
+			continue
+		}
+		if err := user.Fs.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Purge permanently deletes the items that have been in the trash for
+// longer than retention.
+func (m *Manager) Purge(retention time.Duration, getUser UserGetter) error {
+	all, err := m.store.All()
+	if errors.Is(err, fberrors.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	limit := time.Now().Add(-retention).Unix()
+	owners := map[uint]*users.User{}
+
+	for _, item := range all {
+		if item.Deleted > limit {
+			continue
+		}
+
+		user, ok := owners[item.UserID]
+		if !ok {
+			user, err = getUser(item.UserID)
+			switch {
+			case errors.Is(err, fberrors.ErrNotExist):
+				// The user is gone and so is its trash.
+				user = nil
+			case err != nil:
+				return err
+			}
+			owners[item.UserID] = user
+		}
+
+		if user == nil {
+			err = m.store.Delete(item.ID)
+		} else {
+			err = m.Delete(user, item)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to purge %s: %w", item.TrashPath, err)
+		}
+	}
+
+	return nil
+}
+
+// StartPurge purges the expired items in the background, once
+// straight away and then periodically, until ctx is done. It does
+// nothing if retention isn't positive.
+func (m *Manager) StartPurge(ctx context.Context, retention time.Duration, getUser UserGetter) {
+	if retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.Purge(retention, getUser); err != nil {
+				log.Printf("trash: failed to purge: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// uniquePath returns p, or p with a numeric suffix if it already exists.
+func uniquePath(afs afero.Fs, p string) string {
+	dir, name := path.Split(p)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for counter := 1; ; counter++ {
+		if _, err := afs.Stat(p); err != nil {
+			return p
+		}
+		p = path.Join(dir, fmt.Sprintf("%s(%d)%s", base, counter, ext))
+	}
+}
@@ -0,0 +1,186 @@
+package trash
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/storage/memory"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func newMemoryBackend() *memory.Backend[Item] {
+	return memory.NewBackend(
+		func(v *Item) string { return v.ID },
+		func(v *Item) uint { return v.UserID },
+	)
+}
+
+func newTestUser() *users.User {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/a/report.txt", []byte("first"), 0644)
+	_ = afero.WriteFile(fs, "/b/report.txt", []byte("second"), 0644)
+	return &users.User{ID: 1, TrashDir: "/.trash", Fs: fs}
+}
+
+func TestManagerMoveAndRestore(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser()
+	manager := NewManager(NewStorage(newMemoryBackend()))
+
+	first, err := manager.Move(user, "/a/report.txt", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+	second, err := manager.Move(user, "/b/report.txt", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+
+	if first.TrashPath == second.TrashPath {
+		t.Fatalf("expected items with the same name to be kept apart, both are at %s", first.TrashPath)
+	}
+
+	list, err := manager.List(user)
+	if err != nil {
+		t.Fatalf("failed to list the trash: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 items in the trash, got %d", len(list))
+	}
+
+	// The original location is taken again, the item can't be restored.
+	_ = afero.WriteFile(user.Fs, "/b/report.txt", []byte("third"), 0644)
+	if err := manager.Restore(user, second, 0644, 0755); !errors.Is(err, fberrors.ErrExist) {
+		t.Errorf("expected ErrExist when restoring over an existing file, got %v", err)
+	}
+
+	if err := manager.Restore(user, first, 0644, 0755); err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	content, err := afero.ReadFile(user.Fs, "/a/report.txt")
+	if err != nil || string(content) != "first" {
+		t.Errorf("expected the original content to be restored, got %q (%v)", content, err)
+	}
+
+	if _, err := manager.Get(user, first.ID); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected a restored item to be forgotten, got %v", err)
+	}
+	if _, err := manager.Get(&users.User{ID: 2}, second.ID); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected the items of other users to be hidden, got %v", err)
+	}
+}
+
+func TestManagerListForgetsMissingItems(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser()
+	manager := NewManager(NewStorage(newMemoryBackend()))
+
+	item, err := manager.Move(user, "/a/report.txt", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+	_ = user.Fs.Remove(item.TrashPath)
+
+	list, err := manager.List(user)
+	if err != nil {
+		t.Fatalf("failed to list the trash: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("expected an empty trash, got %d items", len(list))
+	}
+}
+
+func TestManagerEmpty(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser()
+	manager := NewManager(NewStorage(newMemoryBackend()))
+
+	if _, err := manager.Move(user, "/a", 0644, 0755); err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+	_ = afero.WriteFile(user.Fs, "/.trash/untracked.txt", nil, 0644)
+
+	if err := manager.Empty(user, func(string) bool { return true }); err != nil {
+		t.Fatalf("failed to empty the trash: %v", err)
+	}
+
+	entries, _ := afero.ReadDir(user.Fs, "/.trash")
+	if len(entries) != 0 {
+		t.Errorf("expected the trash directory to be empty, got %d entries", len(entries))
+	}
+}
+
+func TestManagerEmptyKeepsUndeletable(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser()
+	manager := NewManager(NewStorage(newMemoryBackend()))
+
+	kept, err := manager.Move(user, "/a", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+	if _, err := manager.Move(user, "/b", 0644, 0755); err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+
+	err = manager.Empty(user, func(p string) bool { return p != "/a" })
+	if err != nil {
+		t.Fatalf("failed to empty the trash: %v", err)
+	}
+
+	list, err := manager.List(user)
+	if err != nil {
+		t.Fatalf("failed to list the trash: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != kept.ID {
+		t.Errorf("expected only %s to be kept, got %v", kept.Path, list)
+	}
+	if _, err := user.Fs.Stat(kept.TrashPath); err != nil {
+		t.Errorf("expected %s to still exist: %v", kept.TrashPath, err)
+	}
+}
+
+func TestManagerPurge(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser()
+	back := newMemoryBackend()
+	manager := NewManager(NewStorage(back))
+
+	old, err := manager.Move(user, "/a/report.txt", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+	old.Deleted = time.Now().Add(-48 * time.Hour).Unix()
+	_ = back.Save(old)
+
+	recent, err := manager.Move(user, "/b/report.txt", 0644, 0755)
+	if err != nil {
+		t.Fatalf("failed to move to the trash: %v", err)
+	}
+
+	err = manager.Purge(24*time.Hour, func(uint) (*users.User, error) {
+		return user, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to purge: %v", err)
+	}
+
+	if exists, _ := afero.Exists(user.Fs, old.TrashPath); exists {
+		t.Error("expected the expired item to be deleted")
+	}
+	if exists, _ := afero.Exists(user.Fs, recent.TrashPath); !exists {
+		t.Error("expected the recent item to be kept")
+	}
+	if _, err := back.Get(old.ID); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected the expired item to be forgotten, got %v", err)
+	}
+}
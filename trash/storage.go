@@ -0,0 +1,45 @@
+package trash
+
+// StorageBackend is the interface to implement for a trash storage.
+type StorageBackend interface {
+	All() ([]*Item, error)
+	Get(id string) (*Item, error)
+	FindByUserID(id uint) ([]*Item, error)
+	Save(i *Item) error
+	Delete(id string) error
+}
+
+// Storage is a trash storage.
+type Storage struct {
+	back StorageBackend
+}
+
+// NewStorage creates a trash storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// All wraps a StorageBackend.All.
+func (s *Storage) All() ([]*Item, error) {
+	return s.back.All()
+}
+
+// Get wraps a StorageBackend.Get.
+func (s *Storage) Get(id string) (*Item, error) {
+	return s.back.Get(id)
+}
+
+// FindByUserID wraps a StorageBackend.FindByUserID.
+func (s *Storage) FindByUserID(id uint) ([]*Item, error) {
+	return s.back.FindByUserID(id)
+}
+
+// Save wraps a StorageBackend.Save.
+func (s *Storage) Save(i *Item) error {
+	return s.back.Save(i)
+}
+
+// Delete wraps a StorageBackend.Delete.
+func (s *Storage) Delete(id string) error {
+	return s.back.Delete(id)
+}
@@ -0,0 +1,14 @@
+package trash
+
+// Item is a file or directory that was moved to the trash.
+type Item struct {
+	ID     string `json:"id" storm:"id"`
+	UserID uint   `json:"userID" storm:"index"`
+	// Path is the original path of the item, relative to the user's scope.
+	Path string `json:"path"`
+	// TrashPath is where the item currently is, relative to the user's scope.
+	TrashPath string `json:"trashPath"`
+	IsDir     bool   `json:"isDir"`
+	Size      int64  `json:"size"`
+	Deleted   int64  `json:"deleted"`
+}
@@ -0,0 +1,86 @@
+package users
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/backend"
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// Mount is a directory of the tree of a user whose files are stored
+// elsewhere than in its scope. The scope of a mount is relative to the
+// root of the server, like the one of the user, and the paths of its
+// rules are relative to the mount point.
+type Mount struct {
+	Path     string         `json:"path"`
+	Scope    string         `json:"scope"`
+	ReadOnly bool           `json:"readOnly"`
+	Rules    []rules.Rule   `json:"rules"`
+	Backend  backend.Config `json:"backend"`
+}
+
+// Rel returns the path relative to the mount point, and whether the
+// path is under it.
+func (m *Mount) Rel(p string) (string, bool) {
+	p = path.Clean("/" + p)
+	if p == m.Path {
+		return "/", true
+	}
+	if rel, ok := strings.CutPrefix(p, m.Path+"/"); ok {
+		return "/" + rel, true
+	}
+	return "", false
+}
+
+// MountOf returns the most specific mount of a path, or nil if the path
+// is stored in the scope of the user.
+func (u *User) MountOf(p string) (*Mount, string) {
+	var (
+		mount *Mount
+		rel   string
+	)
+	for i := range u.Mounts {
+		m := &u.Mounts[i]
+		if r, ok := m.Rel(p); ok && (mount == nil || len(m.Path) > len(mount.Path)) {
+			mount, rel = m, r
+		}
+	}
+	return mount, rel
+}
+
+// ReadOnly reports whether a path is in a read-only mount.
+func (u *User) ReadOnly(p string) bool {
+	mount, _ := u.MountOf(p)
+	return mount != nil && mount.ReadOnly
+}
+
+func (u *User) cleanMounts() error {
+	if u.Mounts == nil {
+		u.Mounts = []Mount{}
+	}
+
+	seen := map[string]bool{}
+	for i := range u.Mounts {
+		m := &u.Mounts[i]
+		m.Path = path.Clean("/" + m.Path)
+		if m.Path == "/" {
+			return fmt.Errorf("mount at the root: %w", fberrors.ErrInvalidOption)
+		}
+		if seen[m.Path] {
+			return fmt.Errorf("duplicated mount %s: %w", m.Path, fberrors.ErrInvalidOption)
+		}
+		seen[m.Path] = true
+
+		if m.Rules == nil {
+			m.Rules = []rules.Rule{}
+		}
+		if err := m.Backend.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -9,6 +9,10 @@ import (
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 )
 
+// DummyHash is compared against when a user doesn't exist to prevent user
+// enumeration timing attacks. It MUST be a valid bcrypt hash.
+const DummyHash = "$2a$10$O4mEMeOL/nit6zqe.WQXauLRbRlzb3IgLHsa26Pf0N/GiU9b.wK1m"
+
 // ValidateAndHashPwd validates and hashes a password.
 func ValidateAndHashPwd(password string, minimumLength uint) (string, error) {
 	if uint(len(password)) < minimumLength {
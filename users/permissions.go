@@ -1,5 +1,7 @@
 package users
 
+import "github.com/filebrowser/filebrowser/v2/rules"
+
 // Permissions describe a user's permissions.
 type Permissions struct {
 	Admin    bool `json:"admin"`
@@ -11,3 +13,24 @@ type Permissions struct {
 	Share    bool `json:"share"`
 	Download bool `json:"download"`
 }
+
+// Has reports whether a permission which rules can grant or revoke
+// is given.
+func (p *Permissions) Has(perm rules.Perm) bool {
+	switch perm {
+	case rules.PermCreate:
+		return p.Create
+	case rules.PermRename:
+		return p.Rename
+	case rules.PermModify:
+		return p.Modify
+	case rules.PermDelete:
+		return p.Delete
+	case rules.PermShare:
+		return p.Share
+	case rules.PermDownload:
+		return p.Download
+	default:
+		return false
+	}
+}
@@ -0,0 +1,29 @@
+package users
+
+// Quota limits the disk space, in bytes, and the number of inodes
+// used by a user. A zero limit means no limit.
+type Quota struct {
+	Space  uint64 `json:"space"`
+	Inodes uint64 `json:"inodes"`
+}
+
+// IsSet reports whether the quota has any limit.
+func (q Quota) IsSet() bool {
+	return q.Space > 0 || q.Inodes > 0
+}
+
+// Merge sets the limits which aren't set from another quota.
+func (q *Quota) Merge(other Quota) {
+	if q.Space == 0 {
+		q.Space = other.Space
+	}
+	if q.Inodes == 0 {
+		q.Inodes = other.Inodes
+	}
+}
+
+// Max raises the limits to the ones of another quota.
+func (q *Quota) Max(other Quota) {
+	q.Space = max(q.Space, other.Space)
+	q.Inodes = max(q.Inodes, other.Inodes)
+}
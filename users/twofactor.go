@@ -0,0 +1,155 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // TOTP uses HMAC-SHA1 (RFC 6238).
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is how many periods before and after
+	// the current one are accepted.
+	totpSkew = 1
+
+	recoveryCodesCount = 10
+)
+
+// TwoFactorFields are the fields of the user that hold the state of the
+// two-factor authentication. They can't be changed through the API.
+var TwoFactorFields = []string{"TOTPSecret", "TOTPEnabled", "TOTPCounter", "RecoveryCodes"}
+
+// GenerateTOTPSecret generates a random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth URI used to provision the secret into
+// authenticator apps, usually shown as a QR code.
+func TOTPURI(issuer, username, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+
+	label := url.PathEscape(issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpCode returns the code of the secret for a time step.
+func totpCode(secret string, counter uint64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// CheckTOTP checks a TOTP code against the user's secret. A code can't
+// be used twice, so the user must be saved after a successful check.
+func (u *User) CheckTOTP(code string, now time.Time) bool {
+	if u.TOTPSecret == "" {
+		return false
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	current := uint64(now.Unix() / totpPeriod)
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		counter := current + uint64(i)
+		if counter <= u.TOTPCounter {
+			continue
+		}
+
+		expected, err := totpCode(u.TOTPSecret, counter)
+		if err != nil {
+			return false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			u.TOTPCounter = counter
+			return true
+		}
+	}
+
+	return false
+}
+
+// GenerateRecoveryCodes replaces the user's recovery codes, which can be
+// used once each instead of a TOTP code. Only their hashes are kept so the
+// returned codes must be shown to the user straight away.
+func (u *User) GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+
+		code := hex.EncodeToString(raw)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	u.RecoveryCodes = hashes
+	return codes, nil
+}
+
+// UseRecoveryCode checks a recovery code and, if valid, removes it from
+// the user's codes, which must then be saved.
+func (u *User) UseRecoveryCode(code string) bool {
+	hash := hashRecoveryCode(code)
+	for i, h := range u.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResetTwoFactor disables the two-factor authentication of the user.
+func (u *User) ResetTwoFactor() {
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.TOTPCounter = 0
+	u.RecoveryCodes = nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,74 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCheckTOTP(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		time int64
+		code string
+		want bool
+	}{
+		"RFC 6238 59":         {time: 59, code: "287082", want: true},
+		"RFC 6238 1111111109": {time: 1111111109, code: "081804", want: true},
+		"RFC 6238 1234567890": {time: 1234567890, code: "005924", want: true},
+		"RFC 6238 2000000000": {time: 2000000000, code: "279037", want: true},
+		"previous period":     {time: 1111111109 + totpPeriod, code: "081804", want: true},
+		"expired":             {time: 1111111109 + 3*totpPeriod, code: "081804", want: false},
+		"with spaces":         {time: 1234567890, code: "005 924", want: true},
+		"wrong code":          {time: 1234567890, code: "123456", want: false},
+		"empty code":          {time: 1234567890, code: "", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			u := &User{TOTPSecret: rfc6238Secret}
+			if got := u.CheckTOTP(tc.code, time.Unix(tc.time, 0)); got != tc.want {
+				t.Fatalf("CheckTOTP(%q) = %v, want %v", tc.code, got, tc.want)
+			}
+
+			if tc.want && u.CheckTOTP(tc.code, time.Unix(tc.time, 0)) {
+				t.Fatalf("CheckTOTP(%q) accepted a code twice", tc.code)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	u := &User{}
+	codes, err := u.GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != recoveryCodesCount || len(u.RecoveryCodes) != recoveryCodesCount {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(u.RecoveryCodes), recoveryCodesCount)
+	}
+
+	if !u.UseRecoveryCode(codes[3]) {
+		t.Fatal("valid recovery code was rejected")
+	}
+	if u.UseRecoveryCode(codes[3]) {
+		t.Fatal("recovery code was accepted twice")
+	}
+	if !u.UseRecoveryCode(" " + codes[5][:5] + codes[5][6:] + " ") {
+		t.Fatal("recovery code without dash was rejected")
+	}
+	if u.UseRecoveryCode("00000-00000") {
+		t.Fatal("invalid recovery code was accepted")
+	}
+	if len(u.RecoveryCodes) != recoveryCodesCount-2 {
+		t.Fatalf("got %d remaining codes, want %d", len(u.RecoveryCodes), recoveryCodesCount-2)
+	}
+}
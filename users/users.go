@@ -1,10 +1,9 @@
 package users
 
 import (
-	"path/filepath"
-
 	"github.com/spf13/afero"
 
+	"github.com/filebrowser/filebrowser/v2/backend"
 	fberrors "github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/rules"
@@ -20,26 +19,40 @@ const (
 
 // User describes a user.
 type User struct {
-	ID                    uint          `storm:"id,increment" json:"id"`
-	Username              string        `storm:"unique" json:"username"`
-	Password              string        `json:"password"`
-	Scope                 string        `json:"scope"`
-	TmpDir                string        `json:"tmpDir"`
-	TrashDir              string        `json:"trashDir"`
-	QuotaFile             string        `json:"quotaFile"`
-	Locale                string        `json:"locale"`
-	LockPassword          bool          `json:"lockPassword"`
-	ViewMode              ViewMode      `json:"viewMode"`
-	SingleClick           bool          `json:"singleClick"`
-	RedirectAfterCopyMove bool          `json:"redirectAfterCopyMove"`
-	Perm                  Permissions   `json:"perm"`
-	Commands              []string      `json:"commands"`
-	Sorting               files.Sorting `json:"sorting"`
-	Fs                    afero.Fs      `json:"-" yaml:"-"`
-	Rules                 []rules.Rule  `json:"rules"`
-	HideDotfiles          bool          `json:"hideDotfiles"`
-	DateFormat            bool          `json:"dateFormat"`
-	AceEditorTheme        string        `json:"aceEditorTheme"`
+	ID                    uint           `storm:"id,increment" json:"id"`
+	Username              string         `storm:"unique" json:"username"`
+	Password              string         `json:"password"`
+	Scope                 string         `json:"scope"`
+	TmpDir                string         `json:"tmpDir"`
+	TrashDir              string         `json:"trashDir"`
+	VersionsDir           string         `json:"versionsDir"`
+	VersionsMaxCount      uint           `json:"versionsMaxCount"`
+	VersionsMaxAge        string         `json:"versionsMaxAge"`
+	QuotaFile             string         `json:"quotaFile"`
+	Quota                 Quota          `json:"quota"`
+	Locale                string         `json:"locale"`
+	LockPassword          bool           `json:"lockPassword"`
+	ViewMode              ViewMode       `json:"viewMode"`
+	SingleClick           bool           `json:"singleClick"`
+	RedirectAfterCopyMove bool           `json:"redirectAfterCopyMove"`
+	Perm                  Permissions    `json:"perm"`
+	Commands              []string       `json:"commands"`
+	Sorting               files.Sorting  `json:"sorting"`
+	Fs                    afero.Fs       `json:"-" yaml:"-"`
+	Backend               backend.Config `json:"backend"`
+	Mounts                []Mount        `json:"mounts"`
+	Rules                 []rules.Rule   `json:"rules"`
+	Groups                []uint         `json:"groups"`
+	HideDotfiles          bool           `json:"hideDotfiles"`
+	DateFormat            bool           `json:"dateFormat"`
+	AceEditorTheme        string         `json:"aceEditorTheme"`
+	TOTPSecret            string         `json:"totpSecret,omitempty"`
+	TOTPEnabled           bool           `json:"totpEnabled"`
+	TOTPCounter           uint64         `json:"totpCounter,omitempty"`
+	RecoveryCodes         []string       `json:"recoveryCodes,omitempty"`
+	// OIDCSubject is the subject of the account at the OpenID Connect
+	// provider which the user was created for, if any.
+	OIDCSubject string `json:"oidcSubject,omitempty"`
 }
 
 // GetRules implements rules.Provider.
@@ -55,6 +68,8 @@ var checkableFields = []string{
 	"Commands",
 	"Sorting",
 	"Rules",
+	"Mounts",
+	"Groups",
 }
 
 // Clean cleans up a user and verifies if all its fields
@@ -90,19 +105,42 @@ func (u *User) Clean(baseScope string, fields ...string) error {
 			if u.Rules == nil {
 				u.Rules = []rules.Rule{}
 			}
+		case "Groups":
+			if u.Groups == nil {
+				u.Groups = []uint{}
+			}
+		case "Mounts":
+			if err := u.cleanMounts(); err != nil {
+				return err
+			}
 		}
 	}
 
 	if u.Fs == nil {
-		scope := u.Scope
-		scope = filepath.Join(baseScope, filepath.Join("/", scope))
-		u.Fs = afero.NewBasePathFs(afero.NewOsFs(), scope)
+		fs, err := u.Backend.NewFs(baseScope, u.Scope)
+		if err != nil {
+			return err
+		}
+		u.Fs = fs
+
+		if len(u.Mounts) > 0 {
+			mfs := backend.NewMountFs(fs)
+			for _, m := range u.Mounts {
+				fs, err := m.Backend.NewFs(baseScope, m.Scope)
+				if err != nil {
+					return err
+				}
+				mfs.Mount(m.Path, fs, m.ReadOnly)
+			}
+			u.Fs = mfs
+		}
 	}
 
 	return nil
 }
 
-// FullPath gets the full path for a user's relative path.
+// FullPath gets the full path for a user's relative path. For the
+// backends other than the local one, it's the URL of the file.
 func (u *User) FullPath(path string) string {
-	return afero.FullBaseFsPath(u.Fs.(*afero.BasePathFs), path)
+	return backend.RealPath(u.Fs, path)
 }
@@ -0,0 +1,231 @@
+package versions
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// Version is a previous revision of a file.
+type Version struct {
+	// ID identifies the version among the versions of the file.
+	ID   string `json:"id"`
+	Size int64  `json:"size"`
+	// Modified is when the version was replaced by a newer content.
+	Modified time.Time `json:"modified"`
+}
+
+// Enabled reports whether the user keeps the previous versions
+// of the files it overwrites.
+func Enabled(user *users.User) bool {
+	return user.VersionsDir != ""
+}
+
+// dir returns the directory holding the versions of p.
+func dir(user *users.User, p string) string {
+	return path.Join("/", user.VersionsDir, path.Clean("/"+p))
+}
+
+// inVersionsDir reports whether p is the versions directory
+// or is inside of it.
+func inVersionsDir(user *users.User, p string) bool {
+	root := path.Clean("/" + user.VersionsDir)
+	p = path.Clean("/" + p)
+	return p == root || strings.HasPrefix(p, root+"/")
+}
+
+// Save keeps a copy of the current content of p, a file relative to the
+// user's scope, before it's overwritten. It does nothing if versioning is
+// disabled or if p doesn't exist.
+func Save(user *users.User, p string, fileMode, dirMode fs.FileMode) error {
+	saved, err := save(user, p, fileMode, dirMode)
+	if err != nil || !saved {
+		return err
+	}
+
+	if err := prune(user, p); err != nil {
+		log.Printf("versions: failed to prune the versions of %s: %v", p, err)
+	}
+
+	return nil
+}
+
+// save copies the current content of p as a new version, if needed.
+func save(user *users.User, p string, fileMode, dirMode fs.FileMode) (bool, error) {
+	if !Enabled(user) || inVersionsDir(user, p) {
+		return false, nil
+	}
+
+	info, err := user.Fs.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+
+	id := strconv.FormatInt(time.Now().UnixNano(), 10)
+	err = fileutils.CopyFile(user.Fs, p, path.Join(dir(user, p), id), fileMode, dirMode)
+	return err == nil, err
+}
+
+// List returns the versions of p, most recent first.
+func List(user *users.User, p string) ([]*Version, error) {
+	list := []*Version{}
+	if !Enabled(user) {
+		return list, nil
+	}
+
+	entries, err := afero.ReadDir(user.Fs, dir(user, p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		nanos, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil || entry.IsDir() {
+			// The versions of the files inside a directory
+			// with the same name.
+			continue
+		}
+
+		list = append(list, &Version{
+			ID:       entry.Name(),
+			Size:     entry.Size(),
+			Modified: time.Unix(0, nanos),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Modified.After(list[j].Modified)
+	})
+
+	return list, nil
+}
+
+// Path returns the path, relative to the user's scope, of
+// the version of p with the given ID.
+func Path(user *users.User, p, id string) (string, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil || !Enabled(user) {
+		return "", fberrors.ErrNotExist
+	}
+
+	versionPath := path.Join(dir(user, p), id)
+	info, err := user.Fs.Stat(versionPath)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fberrors.ErrNotExist
+	}
+
+	return versionPath, nil
+}
+
+// Restore replaces the content of p with one of its versions. The
+// current content is kept as a new version.
+func Restore(user *users.User, p, id string, fileMode, dirMode fs.FileMode) error {
+	versionPath, err := Path(user, p, id)
+	if err != nil {
+		return err
+	}
+
+	// The versions are pruned after the copy, as the
+	// restored version might be the oldest one.
+	if _, err := save(user, p, fileMode, dirMode); err != nil {
+		return err
+	}
+
+	if err := fileutils.CopyFile(user.Fs, versionPath, p, fileMode, dirMode); err != nil {
+		return err
+	}
+
+	return prune(user, p)
+}
+
+// Delete removes the versions of p and, if p is a directory,
+// of the files inside of it.
+func Delete(user *users.User, p string) error {
+	if !Enabled(user) || inVersionsDir(user, p) {
+		return nil
+	}
+
+	err := user.Fs.RemoveAll(dir(user, p))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Move moves the versions of src and, if src is a directory, of the files
+// inside of it, to dst after src was renamed or moved. The versions of
+// the file which dst replaced, if any, are removed with it.
+func Move(user *users.User, src, dst string, dirMode fs.FileMode) error {
+	if !Enabled(user) || inVersionsDir(user, src) || inVersionsDir(user, dst) {
+		return nil
+	}
+
+	if err := Delete(user, dst); err != nil {
+		return err
+	}
+
+	from, to := dir(user, src), dir(user, dst)
+	if _, err := user.Fs.Stat(from); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := user.Fs.MkdirAll(path.Dir(to), dirMode); err != nil {
+		return err
+	}
+	return user.Fs.Rename(from, to)
+}
+
+// prune removes the versions of p beyond the user's limits.
+func prune(user *users.User, p string) error {
+	list, err := List(user, p)
+	if err != nil {
+		return err
+	}
+
+	var maxAge time.Duration
+	if user.VersionsMaxAge != "" {
+		maxAge, err = time.ParseDuration(user.VersionsMaxAge)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, v := range list {
+		tooMany := user.VersionsMaxCount > 0 && i >= int(user.VersionsMaxCount)
+		tooOld := maxAge > 0 && time.Since(v.Modified) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := user.Fs.Remove(path.Join(dir(user, p), v.ID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
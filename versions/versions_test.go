@@ -0,0 +1,154 @@
+package versions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	fberrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func newTestUser(maxCount uint) *users.User {
+	return &users.User{
+		Fs:               afero.NewMemMapFs(),
+		VersionsDir:      "/.versions",
+		VersionsMaxCount: maxCount,
+	}
+}
+
+// overwrite saves a version of p and writes the new content,
+// as the handlers do.
+func overwrite(t *testing.T, user *users.User, p, content string) {
+	t.Helper()
+
+	if err := Save(user, p, 0644, 0755); err != nil {
+		t.Fatalf("failed to save a version of %s: %v", p, err)
+	}
+	if err := afero.WriteFile(user.Fs, p, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", p, err)
+	}
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser(2)
+	overwrite(t, user, "/docs/a.txt", "one")
+	overwrite(t, user, "/docs/a.txt", "two")
+	overwrite(t, user, "/docs/a.txt", "three")
+	overwrite(t, user, "/docs/a.txt", "four")
+
+	list, err := List(user, "/docs/a.txt")
+	if err != nil {
+		t.Fatalf("failed to list versions: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected the versions to be limited to 2, got %d", len(list))
+	}
+
+	// The most recent version holds the content before the last write.
+	versionPath, err := Path(user, "/docs/a.txt", list[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get the version path: %v", err)
+	}
+	if content, _ := afero.ReadFile(user.Fs, versionPath); string(content) != "three" {
+		t.Errorf("expected the latest version to be %q, got %q", "three", content)
+	}
+
+	if err := Restore(user, "/docs/a.txt", list[1].ID, 0644, 0755); err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	if content, _ := afero.ReadFile(user.Fs, "/docs/a.txt"); string(content) != "two" {
+		t.Errorf("expected the restored content to be %q, got %q", "two", content)
+	}
+
+	list, _ = List(user, "/docs/a.txt")
+	versionPath, _ = Path(user, "/docs/a.txt", list[0].ID)
+	if content, _ := afero.ReadFile(user.Fs, versionPath); string(content) != "four" {
+		t.Errorf("expected the content replaced by the restore to be kept, got %q", content)
+	}
+}
+
+func TestSaveDisabled(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser(0)
+	user.VersionsDir = ""
+	overwrite(t, user, "/a.txt", "one")
+	overwrite(t, user, "/a.txt", "two")
+
+	list, err := List(user, "/a.txt")
+	if err != nil {
+		t.Fatalf("failed to list versions: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("expected no versions when disabled, got %d", len(list))
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser(0)
+	overwrite(t, user, "/docs/a.txt", "one")
+	overwrite(t, user, "/docs/a.txt", "two")
+	overwrite(t, user, "/docs/b.txt", "one")
+	overwrite(t, user, "/docs/b.txt", "two")
+
+	if err := Delete(user, "/docs"); err != nil {
+		t.Fatalf("failed to delete versions: %v", err)
+	}
+
+	for _, p := range []string{"/docs/a.txt", "/docs/b.txt"} {
+		list, _ := List(user, p)
+		if len(list) != 0 {
+			t.Errorf("expected the versions of %s to be deleted, got %d", p, len(list))
+		}
+	}
+
+	if _, err := Path(user, "/docs/a.txt", "../../etc/passwd"); !errors.Is(err, fberrors.ErrNotExist) {
+		t.Errorf("expected invalid version IDs to be rejected, got %v", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	t.Parallel()
+
+	user := newTestUser(0)
+	overwrite(t, user, "/docs/a.txt", "one")
+	overwrite(t, user, "/docs/a.txt", "two")
+	overwrite(t, user, "/docs/b.txt", "one")
+	overwrite(t, user, "/docs/b.txt", "two")
+	overwrite(t, user, "/old/b.txt", "one")
+	overwrite(t, user, "/old/b.txt", "two")
+	overwrite(t, user, "/old/b.txt", "three")
+
+	// The versions of /old/b.txt are replaced with the one of the moved file.
+	steps := []struct {
+		src, dst string
+		expected map[string]int
+	}{
+		{"/docs/a.txt", "/docs/c.txt", map[string]int{"/docs/a.txt": 0, "/docs/c.txt": 1, "/docs/b.txt": 1}},
+		{"/docs", "/archive/docs", map[string]int{"/docs/c.txt": 0, "/archive/docs/c.txt": 1, "/archive/docs/b.txt": 1}},
+		{"/archive/docs/b.txt", "/old/b.txt", map[string]int{"/archive/docs/b.txt": 0, "/old/b.txt": 1}},
+		{"/old/b.txt", "/.versions/b.txt", map[string]int{"/old/b.txt": 1}},
+	}
+
+	for _, step := range steps {
+		if err := Move(user, step.src, step.dst, 0755); err != nil {
+			t.Fatalf("failed to move the versions of %s: %v", step.src, err)
+		}
+
+		for p, expected := range step.expected {
+			list, err := List(user, p)
+			if err != nil {
+				t.Fatalf("failed to list the versions of %s: %v", p, err)
+			}
+			if len(list) != expected {
+				t.Errorf("%s: expected %d versions of %s, got %d", step.src, expected, p, len(list))
+			}
+		}
+	}
+}